@@ -0,0 +1,7 @@
+data "modtm_module_dependencies" "this" {
+  module_path = path.module
+}
+
+output "dependency_sources" {
+  value = [for d in data.modtm_module_dependencies.this.dependencies : d.source]
+}
@@ -0,0 +1,7 @@
+data "modtm_module_parents" "this" {
+  module_path = path.module
+}
+
+output "module_parent_sources" {
+  value = [for p in data.modtm_module_parents.this.parents : p.source]
+}
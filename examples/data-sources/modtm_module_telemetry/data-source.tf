@@ -0,0 +1,6 @@
+data "modtm_module_telemetry" "this" {
+  module_path = path.module
+  tags = {
+    avm_git_file = "main.tf"
+  }
+}
@@ -0,0 +1,5 @@
+data "modtm_modules" "this" {}
+
+output "module_inventory" {
+  value = { for m in data.modtm_modules.this.modules : m.key => "${m.source}@${m.version}" }
+}
@@ -0,0 +1,5 @@
+data "modtm_provider_config" "this" {}
+
+output "telemetry_endpoint_source" {
+  value = data.modtm_provider_config.this.endpoint_source
+}
@@ -0,0 +1,5 @@
+data "modtm_provider_locks" "this" {}
+
+output "provider_versions" {
+  value = { for p in data.modtm_provider_locks.this.providers : p.address => p.version }
+}
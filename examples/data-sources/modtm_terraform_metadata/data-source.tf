@@ -0,0 +1,8 @@
+data "modtm_terraform_metadata" "this" {}
+
+resource "modtm_telemetry" "this" {
+  tags = {
+    terraform_version = data.modtm_terraform_metadata.this.terraform_version
+    workspace         = data.modtm_terraform_metadata.this.workspace
+  }
+}
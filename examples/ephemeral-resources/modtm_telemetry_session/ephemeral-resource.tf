@@ -0,0 +1,5 @@
+ephemeral "modtm_telemetry_session" "pipeline" {
+  tags = {
+    pipeline = "release"
+  }
+}
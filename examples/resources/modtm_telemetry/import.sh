@@ -0,0 +1,2 @@
+# The import ID is the resource's id and the base64 encoded JSON object of its tags, separated by a colon.
+terraform import modtm_telemetry.this "00000000-0000-0000-0000-000000000000:$(echo -n '{"module_source":"registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm"}' | base64 -w0)"
@@ -0,0 +1,7 @@
+resource "modtm_telemetry_event" "upgraded" {
+  event_name = "module_upgraded"
+  tags = {
+    avm_module_source  = provider::modtm::module_source(path.module)
+    avm_module_version = provider::modtm::module_version(path.module)
+  }
+}
@@ -0,0 +1,212 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// AppConfigurationModel describes provider's `app_configuration` block.
+type AppConfigurationModel struct {
+	Endpoint         types.String `tfsdk:"endpoint"`
+	ConnectionString types.String `tfsdk:"connection_string"`
+	KeyPrefix        types.String `tfsdk:"key_prefix"`
+	Label            types.String `tfsdk:"label"`
+}
+
+const (
+	appConfigurationApiVersion = "1.0"
+	// appConfigurationTimeout is how long the provider waits for a key to be read from the store.
+	appConfigurationTimeout          = 5 * time.Second
+	defaultAppConfigurationKeyPrefix = "modtm:"
+	// appConfigurationResource is the AAD resource of Azure App Configuration data plane.
+	appConfigurationResource = "https://azconfig.io"
+)
+
+// appConfigurationStore reads the provider's settings from an Azure App Configuration store, authenticating either
+// with the HMAC credential of a connection string or with an AAD token.
+type appConfigurationStore struct {
+	endpoint   string
+	credential string
+	secret     []byte
+	// token is the source of AAD tokens when there's no connection string.
+	token     *aadTokenSource
+	keyPrefix string
+	label     string
+	client    *http.Client
+	crypto    cryptoPolicy
+}
+
+// appConfigurationSettings are the settings read from the store, zero values mean the keys don't exist.
+type appConfigurationSettings struct {
+	endpoint      string
+	samplingRules []samplingRule
+}
+
+// newAppConfigurationStore returns the store described by m, connectionString is used when m doesn't set one.
+func newAppConfigurationStore(m AppConfigurationModel, connectionString string, client *http.Client, crypto cryptoPolicy) (*appConfigurationStore, error) {
+	s := &appConfigurationStore{
+		endpoint:  m.Endpoint.ValueString(),
+		keyPrefix: defaultAppConfigurationKeyPrefix,
+		label:     m.Label.ValueString(),
+		client:    client,
+		crypto:    crypto,
+	}
+	if !m.KeyPrefix.IsNull() {
+		s.keyPrefix = m.KeyPrefix.ValueString()
+	}
+	if !m.ConnectionString.IsNull() {
+		connectionString = m.ConnectionString.ValueString()
+	}
+	if connectionString != "" {
+		for _, part := range strings.Split(connectionString, ";") {
+			name, value, _ := strings.Cut(part, "=")
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "endpoint":
+				s.endpoint = value
+			case "id":
+				s.credential = value
+			case "secret":
+				secret, err := base64.StdEncoding.DecodeString(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid secret in app configuration connection string: %w", err)
+				}
+				s.secret = secret
+			}
+		}
+		if s.credential == "" || s.secret == nil {
+			return nil, fmt.Errorf("app configuration connection string must contain `Endpoint`, `Id` and `Secret`")
+		}
+	} else {
+		token, err := newAadTokenSource(appConfigurationResource, client)
+		if err != nil {
+			return nil, err
+		}
+		s.token = token
+	}
+	if s.endpoint == "" {
+		return nil, fmt.Errorf("app configuration endpoint is not set")
+	}
+	s.endpoint = strings.TrimSuffix(s.endpoint, "/")
+	return s, nil
+}
+
+// settings reads the `endpoint` and `sampling_rules` keys, a key that cannot be read is left empty.
+func (s *appConfigurationStore) settings(ctx context.Context) appConfigurationSettings {
+	var settings appConfigurationSettings
+	if endpoint, err := s.get(ctx, "endpoint"); err != nil {
+		traceLog(ctx, fmt.Sprintf("Failed to read endpoint from app configuration: %s", err.Error()))
+	} else {
+		settings.endpoint = endpoint
+	}
+	if raw, err := s.get(ctx, "sampling_rules"); err != nil {
+		traceLog(ctx, fmt.Sprintf("Failed to read sampling rules from app configuration: %s", err.Error()))
+	} else if raw != "" {
+		rules, err := parseSamplingRules(raw)
+		if err != nil {
+			traceLog(ctx, fmt.Sprintf("Ignore invalid sampling rules from app configuration: %s", err.Error()))
+		}
+		settings.samplingRules = rules
+	}
+	return settings
+}
+
+// get returns the value of the key with the store's prefix and label, or an empty string if the key doesn't exist.
+func (s *appConfigurationStore) get(ctx context.Context, key string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, appConfigurationTimeout)
+	defer cancel()
+	query := url.Values{"api-version": []string{appConfigurationApiVersion}}
+	if s.label != "" {
+		query.Set("label", s.label)
+	}
+	u := fmt.Sprintf("%s/kv/%s?%s", s.endpoint, url.PathEscape(s.keyPrefix+key), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	if err = s.authorize(ctx, req); err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("app configuration responded %d", resp.StatusCode)
+	}
+	var kv struct {
+		Value string `json:"value"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&kv); err != nil {
+		return "", err
+	}
+	return kv.Value, nil
+}
+
+// authorize signs the request with the HMAC credential, or sets an AAD bearer token when there's no credential.
+// See https://learn.microsoft.com/azure/azure-app-configuration/rest-api-authentication-hmac.
+func (s *appConfigurationStore) authorize(ctx context.Context, req *http.Request) error {
+	if s.credential == "" {
+		token, err := s.token.get(ctx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+	contentHash := sha256.Sum256(nil)
+	hashBase64 := base64.StdEncoding.EncodeToString(contentHash[:])
+	date := timeNow().UTC().Format(http.TimeFormat)
+	stringToSign := fmt.Sprintf("%s\n%s\n%s;%s;%s", req.Method, req.URL.RequestURI(), date, req.URL.Host, hashBase64)
+	mac, err := s.crypto.newHMAC("sha256", s.secret)
+	if err != nil {
+		return err
+	}
+	_, _ = mac.Write([]byte(stringToSign))
+	req.Header.Set("x-ms-date", date)
+	req.Header.Set("x-ms-content-sha256", hashBase64)
+	req.Header.Set("Authorization", fmt.Sprintf("HMAC-SHA256 Credential=%s&SignedHeaders=x-ms-date;host;x-ms-content-sha256&Signature=%s",
+		s.credential, base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+	return nil
+}
+
+// parseSamplingRules parses sampling rules stored as a JSON array of objects with `module_source_regex` and `rate`.
+func parseSamplingRules(raw string) ([]samplingRule, error) {
+	var items []struct {
+		ModuleSourceRegex string  `json:"module_source_regex"`
+		Rate              float64 `json:"rate"`
+	}
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		return nil, err
+	}
+	rules := make([]samplingRule, 0, len(items))
+	for _, item := range items {
+		regex, err := regexp.Compile(item.ModuleSourceRegex)
+		if err != nil {
+			return nil, err
+		}
+		if item.Rate < 0 || item.Rate > 1 {
+			return nil, fmt.Errorf("sampling rate %v of %q is not between 0 and 1", item.Rate, item.ModuleSourceRegex)
+		}
+		rules = append(rules, samplingRule{moduleSourceRegex: regex, rate: item.Rate})
+	}
+	return rules, nil
+}
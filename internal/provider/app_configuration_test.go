@@ -0,0 +1,153 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAppConfigurationSecret = []byte("app-configuration-secret")
+
+// newMockAppConfigurationServer serves keys from kv, requests are verified against the HMAC credential `test-id`
+// unless they carry the bearer token `token` of fakeTokenCredential.
+func newMockAppConfigurationServer(t *testing.T, kv map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("Authorization") != "Bearer token" {
+			stringToSign := fmt.Sprintf("%s\n%s\n%s;%s;%s", request.Method, request.URL.RequestURI(), request.Header.Get("x-ms-date"), request.Host, request.Header.Get("x-ms-content-sha256"))
+			mac := hmac.New(sha256.New, testAppConfigurationSecret)
+			_, _ = mac.Write([]byte(stringToSign))
+			expected := fmt.Sprintf("HMAC-SHA256 Credential=test-id&SignedHeaders=x-ms-date;host;x-ms-content-sha256&Signature=%s", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+			if request.Header.Get("Authorization") != expected {
+				writer.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		assert.Equal(t, appConfigurationApiVersion, request.URL.Query().Get("api-version"))
+		key := strings.TrimPrefix(request.URL.Path, "/kv/") + "@" + request.URL.Query().Get("label")
+		value, ok := kv[key]
+		if !ok {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(writer).Encode(map[string]string{"key": key, "value": value})
+	}))
+}
+
+func testAppConfigurationConnectionString(endpoint string) string {
+	return fmt.Sprintf("Endpoint=%s;Id=test-id;Secret=%s", endpoint, base64.StdEncoding.EncodeToString(testAppConfigurationSecret))
+}
+
+func TestAppConfigurationStore_settingsWithConnectionString(t *testing.T) {
+	server := newMockAppConfigurationServer(t, map[string]string{
+		"modtm:endpoint@prod":       "https://telemetry.contoso.com",
+		"modtm:sampling_rules@prod": `[{"module_source_regex":"^Azure/","rate":0.5}]`,
+	})
+	defer server.Close()
+	store, err := newAppConfigurationStore(AppConfigurationModel{
+		ConnectionString: types.StringValue(testAppConfigurationConnectionString(server.URL)),
+		Label:            types.StringValue("prod"),
+	}, "", http.DefaultClient, cryptoPolicy{})
+	require.NoError(t, err)
+
+	settings := store.settings(context.Background())
+	assert.Equal(t, "https://telemetry.contoso.com", settings.endpoint)
+	require.Len(t, settings.samplingRules, 1)
+	assert.Equal(t, "^Azure/", settings.samplingRules[0].moduleSourceRegex.String())
+	assert.Equal(t, 0.5, settings.samplingRules[0].rate)
+}
+
+func TestAppConfigurationStore_settingsWithAADToken(t *testing.T) {
+	server := newMockAppConfigurationServer(t, map[string]string{
+		"contoso/endpoint@": "https://telemetry.contoso.com",
+	})
+	defer server.Close()
+	store, err := newAppConfigurationStore(AppConfigurationModel{
+		Endpoint:  types.StringValue(server.URL + "/"),
+		KeyPrefix: types.StringValue("contoso/"),
+	}, "", http.DefaultClient, cryptoPolicy{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://azconfig.io/.default", store.token.scope)
+	store.token.credential = &fakeTokenCredential{ttl: time.Hour}
+
+	settings := store.settings(context.Background())
+	assert.Equal(t, "https://telemetry.contoso.com", settings.endpoint)
+	assert.Empty(t, settings.samplingRules)
+}
+
+func TestAppConfigurationStore_wrongSecretReadsNothing(t *testing.T) {
+	server := newMockAppConfigurationServer(t, map[string]string{
+		"modtm:endpoint@": "https://telemetry.contoso.com",
+	})
+	defer server.Close()
+	store, err := newAppConfigurationStore(AppConfigurationModel{}, fmt.Sprintf("Endpoint=%s;Id=test-id;Secret=%s", server.URL, base64.StdEncoding.EncodeToString([]byte("wrong"))), http.DefaultClient, cryptoPolicy{})
+	require.NoError(t, err)
+
+	_, err = store.get(context.Background(), "endpoint")
+	assert.ErrorContains(t, err, "401")
+	assert.Empty(t, store.settings(context.Background()).endpoint)
+}
+
+func TestNewAppConfigurationStore_invalid(t *testing.T) {
+	cases := map[string]AppConfigurationModel{
+		"no endpoint":                        {},
+		"no secret":                          {ConnectionString: types.StringValue("Endpoint=https://contoso.azconfig.io;Id=test-id")},
+		"invalid secret":                     {ConnectionString: types.StringValue("Endpoint=https://contoso.azconfig.io;Id=test-id;Secret=!")},
+		"connection string without endpoint": {ConnectionString: types.StringValue("Id=test-id;Secret=c2VjcmV0")},
+	}
+	for desc, m := range cases {
+		t.Run(desc, func(t *testing.T) {
+			_, err := newAppConfigurationStore(m, "", http.DefaultClient, cryptoPolicy{})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseSamplingRules_invalid(t *testing.T) {
+	for _, raw := range []string{`{}`, `[{"module_source_regex":"[","rate":0.5}]`, `[{"module_source_regex":".*","rate":2}]`} {
+		_, err := parseSamplingRules(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestAccTelemetryResource_endpointByAppConfiguration(t *testing.T) {
+	ms := newMockServer()
+	defer ms.close()
+	server := newMockAppConfigurationServer(t, map[string]string{
+		"modtm:endpoint@": ms.serverUrl(),
+	})
+	defer server.Close()
+	t.Setenv("MODTM_ENDPOINT", "")
+	t.Setenv("MODTM_APP_CONFIGURATION_CONNECTION_STRING", testAppConfigurationConnectionString(server.URL))
+	tags := map[string]string{
+		"module_source": "foo",
+	}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccTelemetryResourceConfig("", true, tags),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testChecksForTags(tags, resourceIdIsUuidCheck())...,
+				),
+			},
+		},
+	})
+	assertEventTags(t, "create", tags, ms)
+}
@@ -0,0 +1,125 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultAppInsightsIngestionEndpoint is used when the connection string doesn't carry `IngestionEndpoint`.
+const defaultAppInsightsIngestionEndpoint = "https://dc.services.visualstudio.com/"
+
+// newAppInsightsRoute returns a route that sends all events to the Application Insights resource of the connection
+// string as custom events.
+func newAppInsightsRoute(connectionString string, client *http.Client) (eventRoute, error) {
+	instrumentationKey, ingestionEndpoint, err := parseAppInsightsConnectionString(connectionString)
+	if err != nil {
+		return eventRoute{}, err
+	}
+	return eventRoute{
+		endpoint:     strings.TrimSuffix(ingestionEndpoint, "/") + "/v2/track",
+		samplingRate: 1,
+		client:       &appInsightsTelemetryClient{client: client, instrumentationKey: instrumentationKey},
+	}, nil
+}
+
+// parseAppInsightsConnectionString reads the instrumentation key and the ingestion endpoint from a connection string
+// like `InstrumentationKey=00000000-0000-0000-0000-000000000000;IngestionEndpoint=https://westeurope-5.in.applicationinsights.azure.com/`.
+func parseAppInsightsConnectionString(connectionString string) (string, string, error) {
+	instrumentationKey := ""
+	ingestionEndpoint := defaultAppInsightsIngestionEndpoint
+	for _, pair := range strings.Split(connectionString, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.EqualFold(k, "InstrumentationKey"):
+			instrumentationKey = v
+		case strings.EqualFold(k, "IngestionEndpoint"):
+			ingestionEndpoint = v
+		}
+	}
+	if instrumentationKey == "" {
+		return "", "", errors.New("the connection string doesn't contain `InstrumentationKey`")
+	}
+	if !strings.HasPrefix(ingestionEndpoint, "https://") {
+		return "", "", fmt.Errorf("the `IngestionEndpoint` of the connection string must be an HTTPS URL, got %q", ingestionEndpoint)
+	}
+	return instrumentationKey, ingestionEndpoint, nil
+}
+
+var _ telemetryClient = &appInsightsTelemetryClient{}
+
+// appInsightsTelemetryClient sends events to the track API of Application Insights, the event name becomes the name
+// of a custom event and the tags become its custom dimensions.
+type appInsightsTelemetryClient struct {
+	client             *http.Client
+	instrumentationKey string
+}
+
+type appInsightsEnvelope struct {
+	Name string          `json:"name"`
+	Time string          `json:"time"`
+	IKey string          `json:"iKey"`
+	Data appInsightsData `json:"data"`
+}
+
+type appInsightsData struct {
+	BaseType string               `json:"baseType"`
+	BaseData appInsightsEventData `json:"baseData"`
+}
+
+type appInsightsEventData struct {
+	Ver        int               `json:"ver"`
+	Name       string            `json:"name"`
+	Properties map[string]string `json:"properties"`
+}
+
+func (a *appInsightsTelemetryClient) discoverEndpoint(ctx context.Context) (string, error) {
+	return "", errors.New("application insights doesn't support endpoint discovery")
+}
+
+func (a *appInsightsTelemetryClient) send(ctx context.Context, endpoint string, tags map[string]string) error {
+	envelope := appInsightsEnvelope{
+		Name: fmt.Sprintf("Microsoft.ApplicationInsights.%s.Event", strings.ReplaceAll(a.instrumentationKey, "-", "")),
+		Time: timeNow().UTC().Format(time.RFC3339Nano),
+		IKey: a.instrumentationKey,
+		Data: appInsightsData{
+			BaseType: "EventData",
+			BaseData: appInsightsEventData{
+				Ver:        2,
+				Name:       tags["event"],
+				Properties: tags,
+			},
+		},
+	}
+	body, err := json.Marshal([]appInsightsEnvelope{envelope})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withSendTimeout(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("application insights responded %d", resp.StatusCode)
+	}
+	return nil
+}
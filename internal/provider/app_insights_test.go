@@ -0,0 +1,68 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAppInsightsConnectionString(t *testing.T) {
+	key, endpoint, err := parseAppInsightsConnectionString("InstrumentationKey=00000000-0000-0000-0000-000000000001;IngestionEndpoint=https://westeurope-5.in.applicationinsights.azure.com/;LiveEndpoint=https://westeurope.livediagnostics.monitor.azure.com/")
+	require.NoError(t, err)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000001", key)
+	assert.Equal(t, "https://westeurope-5.in.applicationinsights.azure.com/", endpoint)
+
+	_, endpoint, err = parseAppInsightsConnectionString("instrumentationkey=00000000-0000-0000-0000-000000000001")
+	require.NoError(t, err)
+	assert.Equal(t, defaultAppInsightsIngestionEndpoint, endpoint)
+
+	_, _, err = parseAppInsightsConnectionString("IngestionEndpoint=https://westeurope-5.in.applicationinsights.azure.com/")
+	assert.ErrorContains(t, err, "InstrumentationKey")
+
+	_, _, err = parseAppInsightsConnectionString("InstrumentationKey=00000000-0000-0000-0000-000000000001;IngestionEndpoint=http://contoso.com/")
+	assert.ErrorContains(t, err, "HTTPS")
+}
+
+func TestAppInsightsTelemetryClient_send(t *testing.T) {
+	var envelopes []appInsightsEnvelope
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		path = request.URL.Path
+		data, _ := io.ReadAll(request.Body)
+		_ = json.Unmarshal(data, &envelopes)
+	}))
+	defer server.Close()
+
+	route, err := newAppInsightsRoute("InstrumentationKey=00000000-0000-0000-0000-000000000001;IngestionEndpoint=https://contoso.in.applicationinsights.azure.com/", server.Client())
+	require.NoError(t, err)
+	assert.Equal(t, "https://contoso.in.applicationinsights.azure.com/v2/track", route.endpoint)
+
+	err = route.client.send(context.Background(), server.URL+"/v2/track", map[string]string{"event": "create", "module_source": "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, "/v2/track", path)
+	require.Len(t, envelopes, 1)
+	assert.Equal(t, "Microsoft.ApplicationInsights.00000000000000000000000000000001.Event", envelopes[0].Name)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000001", envelopes[0].IKey)
+	assert.Equal(t, "EventData", envelopes[0].Data.BaseType)
+	assert.Equal(t, "create", envelopes[0].Data.BaseData.Name)
+	assert.Equal(t, map[string]string{"event": "create", "module_source": "foo"}, envelopes[0].Data.BaseData.Properties)
+}
+
+func TestAppInsightsTelemetryClient_sendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := &appInsightsTelemetryClient{client: server.Client(), instrumentationKey: "key"}
+	assert.ErrorContains(t, client.send(context.Background(), server.URL, map[string]string{"event": "create"}), "400")
+}
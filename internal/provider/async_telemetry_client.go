@@ -0,0 +1,157 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+const (
+	sendModeSync  = "sync"
+	sendModeAsync = "async"
+
+	// asyncQueueSize bounds the events waiting for the background workers in async send mode.
+	asyncQueueSize = 1024
+	// asyncWorkers is the number of events sent at the same time in async send mode, unless `max_concurrent_sends`
+	// is lower.
+	asyncWorkers = 4
+)
+
+var sendModes = []string{sendModeSync, sendModeAsync}
+
+var errAsyncQueueFull = errors.New("telemetry event dropped since async send queue is full")
+
+// deliveryFailureHandler is called in the background when a queued event could not be delivered, with the delivery
+// error and a client that sends events immediately, so the caller could retry the event elsewhere or keep it.
+type deliveryFailureHandler func(ctx context.Context, client telemetryClient, err error)
+
+// queuingClient is implemented by the clients whose send only queues the event, the event is delivered after send
+// has returned so its caller never learns the outcome of the delivery.
+type queuingClient interface {
+	telemetryClient
+	// enqueue queues the event like send, onFailure is called when the event is dequeued but cannot be delivered.
+	// It's not called for an event that is lost because the provider exits first.
+	enqueue(ctx context.Context, endpoint string, tags map[string]string, onFailure deliveryFailureHandler) error
+}
+
+var _ queuingClient = &asyncTelemetryClient{}
+
+// asyncTelemetryClient queues events and returns immediately, background workers send them with next, so
+// resources don't wait for the endpoint. Delivery failures are logged and passed to the failure handler of the
+// event. Events still queued when the provider exits are sent by flush until its deadline.
+type asyncTelemetryClient struct {
+	next  telemetryClient
+	queue chan asyncEvent
+	// pending counts the events that have been queued but not sent yet.
+	pending sync.WaitGroup
+}
+
+// asyncEvent is a queued event, timeout is what's left of the request timeout when it's queued.
+type asyncEvent struct {
+	ctx       context.Context
+	timeout   time.Duration
+	endpoint  string
+	tags      map[string]string
+	onFailure deliveryFailureHandler
+}
+
+// newAsyncTelemetryClient returns a client that queues up to queueSize events, sent by asyncWorkers background
+// workers, or by maxConcurrentSends workers when it's positive and lower, so the events sent in the background respect
+// `max_concurrent_sends` too.
+func newAsyncTelemetryClient(next telemetryClient, queueSize int, maxConcurrentSends int64) *asyncTelemetryClient {
+	a := &asyncTelemetryClient{
+		next:  next,
+		queue: make(chan asyncEvent, queueSize),
+	}
+	workers := asyncWorkers
+	if maxConcurrentSends > 0 && maxConcurrentSends < asyncWorkers {
+		workers = int(maxConcurrentSends)
+	}
+	for i := 0; i < workers; i++ {
+		go a.work()
+	}
+	return a
+}
+
+func (a *asyncTelemetryClient) discoverEndpoint(ctx context.Context) (string, error) {
+	return a.next.discoverEndpoint(ctx)
+}
+
+// send queues the event, it only fails when the queue is full.
+func (a *asyncTelemetryClient) send(ctx context.Context, endpoint string, tags map[string]string) error {
+	return a.enqueue(ctx, endpoint, tags, nil)
+}
+
+// enqueue queues the event, it only fails when the queue is full. The event is sent with the logger of ctx, but it's
+// not cancelled with ctx since the caller doesn't wait for it.
+func (a *asyncTelemetryClient) enqueue(ctx context.Context, endpoint string, tags map[string]string, onFailure deliveryFailureHandler) error {
+	timeout := sendTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	a.pending.Add(1)
+	select {
+	case a.queue <- asyncEvent{ctx: context.WithoutCancel(ctx), timeout: timeout, endpoint: endpoint, tags: maps.Clone(tags), onFailure: onFailure}:
+		traceLog(ctx, fmt.Sprintf("queued %s telemetry event for %s", tags["event"], endpoint))
+		return nil
+	default:
+		a.pending.Done()
+		return errAsyncQueueFull
+	}
+}
+
+func (a *asyncTelemetryClient) work() {
+	for e := range a.queue {
+		a.deliver(e)
+		a.pending.Done()
+	}
+}
+
+// deliver sends the event with next, or hands it over to next if it queues events too, e.g. the batch transport.
+// The failure handler runs without the request timeout of the failed send, it applies its own.
+func (a *asyncTelemetryClient) deliver(e asyncEvent) {
+	if q, ok := a.next.(queuingClient); ok {
+		if err := q.enqueue(e.ctx, e.endpoint, e.tags, e.onFailure); err != nil {
+			errorLog(e.ctx, fmt.Sprintf("error on queueing %s telemetry event for %s: %+v", e.tags["event"], e.endpoint, err))
+		}
+		return
+	}
+	ctx, cancel := context.WithTimeout(e.ctx, e.timeout)
+	err := a.next.send(ctx, e.endpoint, e.tags)
+	cancel()
+	if err == nil {
+		return
+	}
+	errorLog(e.ctx, fmt.Sprintf("error on sending queued %s telemetry event to %s: %+v", e.tags["event"], e.endpoint, err))
+	if e.onFailure != nil {
+		e.onFailure(e.ctx, a.next, err)
+	}
+}
+
+// flush waits for the queued events to be sent until ctx is done, the events that are still queued then are lost.
+func (a *asyncTelemetryClient) flush(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		a.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		traceLog(ctx, fmt.Sprintf("timeout on flushing %d queued telemetry events", len(a.queue)))
+	}
+}
+
+// prewarm prewarms the connection of next, if it supports it.
+func (a *asyncTelemetryClient) prewarm(ctx context.Context, endpoint string) error {
+	if p, ok := a.next.(prewarmer); ok {
+		return p.prewarm(ctx, endpoint)
+	}
+	return nil
+}
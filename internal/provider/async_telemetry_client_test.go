@@ -0,0 +1,107 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedTelemetryClient blocks every send until the gate is closed.
+type gatedTelemetryClient struct {
+	fakeTelemetryClient
+	gate chan struct{}
+}
+
+func (g *gatedTelemetryClient) send(ctx context.Context, endpoint string, tags map[string]string) error {
+	select {
+	case <-g.gate:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return g.fakeTelemetryClient.send(ctx, endpoint, tags)
+}
+
+func TestAsyncTelemetryClient_sendsInBackground(t *testing.T) {
+	next := &gatedTelemetryClient{gate: make(chan struct{})}
+	client := newAsyncTelemetryClient(next, 1, 0)
+	for i := 0; i < asyncWorkers+1; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		require.NoError(t, client.send(ctx, "https://telemetry.contoso.com", map[string]string{"event": "create", "sequence": strconv.Itoa(i)}))
+		// The caller doesn't wait for the event, cancelling its context doesn't cancel the event.
+		cancel()
+		if i < asyncWorkers {
+			// Wait for a worker to pick the event up, so the queue only holds the last one.
+			require.Eventually(t, func() bool { return len(client.queue) == 0 }, time.Second, time.Millisecond)
+		}
+	}
+	assert.ErrorIs(t, client.send(context.Background(), "https://telemetry.contoso.com", map[string]string{"event": "create"}), errAsyncQueueFull)
+	assert.Empty(t, next.sentEvents())
+
+	close(next.gate)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.flush(ctx)
+	assert.Len(t, next.sentEvents(), asyncWorkers+1)
+}
+
+func TestAsyncTelemetryClient_flushStopsAtDeadline(t *testing.T) {
+	next := &gatedTelemetryClient{gate: make(chan struct{})}
+	defer close(next.gate)
+	client := newAsyncTelemetryClient(next, asyncQueueSize, 0)
+	require.NoError(t, client.send(context.Background(), "https://telemetry.contoso.com", map[string]string{"event": "create"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	client.flush(ctx)
+	assert.Empty(t, next.sentEvents())
+}
+
+func TestAsyncTelemetryClient_failedDeliveryRunsFailureHandler(t *testing.T) {
+	next := &fakeTelemetryClient{sendErr: errors.New("outage")}
+	client := newAsyncTelemetryClient(next, asyncQueueSize, 0)
+	failed := make(chan error, 1)
+	require.NoError(t, client.enqueue(context.Background(), "https://telemetry.contoso.com", map[string]string{"event": "create"}, func(ctx context.Context, c telemetryClient, err error) {
+		assert.Same(t, next, c)
+		failed <- err
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.flush(ctx)
+	assert.EqualError(t, <-failed, "outage")
+}
+
+func TestAsyncTelemetryClient_handsEventsOverToQueuingClient(t *testing.T) {
+	next := newBatchTelemetryClient(http.DefaultClient)
+	client := newAsyncTelemetryClient(next, asyncQueueSize, 0)
+	require.NoError(t, client.enqueue(context.Background(), "https://telemetry.contoso.com", map[string]string{"event": "create"}, func(context.Context, telemetryClient, error) {}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.flush(ctx)
+	require.Len(t, next.batches["https://telemetry.contoso.com"], 1)
+	assert.NotNil(t, next.batches["https://telemetry.contoso.com"][0].onFailure)
+}
+
+func TestAsyncTelemetryClient_respectsMaxConcurrentSends(t *testing.T) {
+	next := &gatedTelemetryClient{gate: make(chan struct{})}
+	client := newAsyncTelemetryClient(next, asyncQueueSize, 1)
+	require.NoError(t, client.send(context.Background(), "https://telemetry.contoso.com", map[string]string{"event": "create"}))
+	require.Eventually(t, func() bool { return len(client.queue) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, client.send(context.Background(), "https://telemetry.contoso.com", map[string]string{"event": "update"}))
+	// The only worker is busy with the first event, so the second one waits in the queue.
+	assert.Never(t, func() bool { return len(client.queue) == 0 }, 50*time.Millisecond, time.Millisecond)
+
+	close(next.gate)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.flush(ctx)
+	assert.Len(t, next.sentEvents(), 2)
+}
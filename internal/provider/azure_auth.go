@@ -0,0 +1,75 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// aadTokenExpiryMargin is how long before its expiry an AAD token is refreshed, so a token never
+// expires while a request is in flight.
+const aadTokenExpiryMargin = 5 * time.Minute
+
+// imdsTokenUrl is the managed identity token endpoint of Azure Instance Metadata Service.
+var imdsTokenUrl = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// aadTokenSource acquires AAD tokens for a resource, e.g. a collector or an Azure service, and reuses them until
+// they're about to expire.
+type aadTokenSource struct {
+	credential azcore.TokenCredential
+	scope      string
+
+	mu    sync.Mutex
+	token azcore.AccessToken
+}
+
+// newAadTokenSource returns the token source of resource backed by azidentity's DefaultAzureCredential, which tries
+// the service principal and the workload identity set by the `AZURE_*` environment variables, the managed identity,
+// then the Azure CLI and Azure Developer CLI sessions. Token requests are sent with client, so they go through the
+// provider's proxy, TLS and FIPS settings.
+func newAadTokenSource(resource string, client *http.Client) (*aadTokenSource, error) {
+	credential, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+		ClientOptions: azcore.ClientOptions{Transport: aadTransport{client: client}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &aadTokenSource{credential: credential, scope: strings.TrimSuffix(resource, "/") + "/.default"}, nil
+}
+
+func (s *aadTokenSource) get(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.Token != "" && timeNow().Add(aadTokenExpiryMargin).Before(s.token.ExpiresOn) {
+		return s.token.Token, nil
+	}
+	token, err := s.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{s.scope}})
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	return token.Token, nil
+}
+
+// aadTransport sends the token requests of azidentity with the provider's client, except the requests to the
+// managed identity endpoint of IMDS, which must never be reached through a proxy.
+type aadTransport struct {
+	client *http.Client
+}
+
+func (t aadTransport) Do(req *http.Request) (*http.Response, error) {
+	if u, err := url.Parse(imdsTokenUrl); err == nil && req.URL.Host == u.Host {
+		return (&http.Client{Transport: &http.Transport{Proxy: nil}}).Do(req)
+	}
+	return t.client.Do(req)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTokenCredential struct {
+	ttl    time.Duration
+	err    error
+	scopes [][]string
+}
+
+func (c *fakeTokenCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.scopes = append(c.scopes, options.Scopes)
+	if c.err != nil {
+		return azcore.AccessToken{}, c.err
+	}
+	return azcore.AccessToken{Token: "token", ExpiresOn: timeNow().Add(c.ttl)}, nil
+}
+
+func TestAadTokenSource_reusesTokenUntilExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return now
+	})
+	defer stub.Reset()
+
+	credential := &fakeTokenCredential{ttl: time.Hour}
+	s := &aadTokenSource{credential: credential, scope: "api://collector/.default"}
+	for i := 0; i < 2; i++ {
+		token, err := s.get(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "token", token)
+	}
+	assert.Equal(t, [][]string{{"api://collector/.default"}}, credential.scopes)
+
+	now = now.Add(time.Hour - aadTokenExpiryMargin - time.Second)
+	_, err := s.get(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, credential.scopes, 1)
+
+	now = now.Add(time.Second)
+	_, err = s.get(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, credential.scopes, 2)
+}
+
+func TestNewAadTokenSource_scope(t *testing.T) {
+	s, err := newAadTokenSource("api://collector/", http.DefaultClient)
+	require.NoError(t, err)
+	assert.Equal(t, "api://collector/.default", s.scope)
+}
+
+func TestHttpTelemetryClient_sendWithAadToken(t *testing.T) {
+	var authorization string
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+		authorization = request.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	client := newHttpTelemetryClient(http.DefaultClient, payloadEncodingJSON)
+	client.token = &aadTokenSource{credential: &fakeTokenCredential{ttl: time.Hour}, scope: "api://collector/.default"}
+	require.NoError(t, client.send(context.Background(), server.URL, map[string]string{"event": "create"}))
+	assert.Equal(t, "Bearer token", authorization)
+
+	client.token = &aadTokenSource{credential: &fakeTokenCredential{err: errors.New("no identity")}, scope: "api://collector/.default"}
+	assert.ErrorContains(t, client.send(context.Background(), server.URL, map[string]string{"event": "read"}), "no identity")
+	assert.Equal(t, 1, requests)
+}
+
+func TestAadTransport_usesProviderClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer server.Close()
+	var used bool
+	client := &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		used = true
+		return http.DefaultTransport.RoundTrip(request)
+	})}
+
+	request, err := http.NewRequest(http.MethodPost, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := aadTransport{client: client}.Do(request)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.True(t, used)
+}
+
+type roundTripperFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync"
+)
+
+// azureContextSalt is the salt of the hashed subscription and tenant ids, so the hashes of this provider don't
+// match plain hashes of the ids that might be stored elsewhere.
+const azureContextSalt = "modtm-azure-context"
+
+// azureContextIds returns the subscription and tenant ids of the Azure context from `ARM_SUBSCRIPTION_ID` and
+// `ARM_TENANT_ID` environment variables. When imds is true and the subscription id is not set, it's read from
+// IMDS if the provider runs on an Azure VM or agent. IMDS doesn't know the tenant id.
+func azureContextIds(ctx context.Context, imds bool) (subscriptionId, tenantId string) {
+	subscriptionId = os.Getenv("ARM_SUBSCRIPTION_ID")
+	tenantId = os.Getenv("ARM_TENANT_ID")
+	if subscriptionId == "" && imds {
+		if compute, ok := readImdsCompute(ctx); ok {
+			subscriptionId = compute.SubscriptionId
+		}
+	}
+	return subscriptionId, tenantId
+}
+
+// hashAzureContextId returns the salted SHA-256 hash of an id, ids are GUIDs so they're compared case-insensitively.
+// It returns an empty string if id is empty.
+func hashAzureContextId(policy cryptoPolicy, id string) string {
+	if id == "" {
+		return ""
+	}
+	h, err := policy.newHMAC("sha256", []byte(azureContextSalt))
+	if err != nil {
+		return ""
+	}
+	_, _ = h.Write([]byte(strings.ToLower(id)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// azureContextStage tags the event with `azure_subscription_hash` and `azure_tenant_hash` when enabled, so the
+// service could count distinct deployments without learning the raw ids. The ids are only read once, by the
+// first event.
+func azureContextStage(enabled, imds bool, policy cryptoPolicy) eventStage {
+	var once sync.Once
+	var hashes map[string]string
+	return eventStage{
+		name: stageAzureContext,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			if !enabled {
+				return true
+			}
+			once.Do(func() {
+				subscriptionId, tenantId := azureContextIds(ctx, imds)
+				hashes = map[string]string{
+					"azure_subscription_hash": hashAzureContextId(policy, subscriptionId),
+					"azure_tenant_hash":       hashAzureContextId(policy, tenantId),
+				}
+			})
+			for k, v := range hashes {
+				if _, ok := e.tags[k]; !ok && v != "" {
+					e.tags[k] = v
+				}
+			}
+			return true
+		},
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashAzureContextId(t *testing.T) {
+	policy := cryptoPolicy{}
+	id := "00000000-0000-0000-0000-0000000000AB"
+	assert.Len(t, hashAzureContextId(policy, id), 64)
+	assert.Equal(t, hashAzureContextId(policy, id), hashAzureContextId(policy, "00000000-0000-0000-0000-0000000000ab"))
+	assert.NotContains(t, hashAzureContextId(policy, id), "0000000000ab")
+	assert.Empty(t, hashAzureContextId(policy, ""))
+}
+
+func TestAzureContextStage(t *testing.T) {
+	t.Setenv("ARM_SUBSCRIPTION_ID", "00000000-0000-0000-0000-000000000001")
+	t.Setenv("ARM_TENANT_ID", "00000000-0000-0000-0000-000000000002")
+	policy := cryptoPolicy{}
+
+	e := &telemetryEvent{tags: map[string]string{}}
+	assert.True(t, azureContextStage(true, false, policy).process(context.Background(), e))
+	assert.Equal(t, map[string]string{
+		"azure_subscription_hash": hashAzureContextId(policy, "00000000-0000-0000-0000-000000000001"),
+		"azure_tenant_hash":       hashAzureContextId(policy, "00000000-0000-0000-0000-000000000002"),
+	}, e.tags)
+
+	e = &telemetryEvent{tags: map[string]string{}}
+	assert.True(t, azureContextStage(false, false, policy).process(context.Background(), e))
+	assert.Empty(t, e.tags)
+}
+
+func TestAzureContextStage_subscriptionFromImds(t *testing.T) {
+	t.Setenv("ARM_SUBSCRIPTION_ID", "")
+	t.Setenv("ARM_TENANT_ID", "")
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(`{"location":"eastus2","vmSize":"Standard_D4s_v3","subscriptionId":"00000000-0000-0000-0000-000000000003"}`))
+	}))
+	defer server.Close()
+	stub := gostub.Stub(&imdsInstanceUrl, server.URL)
+	defer stub.Reset()
+	policy := cryptoPolicy{}
+
+	e := &telemetryEvent{tags: map[string]string{}}
+	assert.True(t, azureContextStage(true, true, policy).process(context.Background(), e))
+	assert.Equal(t, map[string]string{
+		"azure_subscription_hash": hashAzureContextId(policy, "00000000-0000-0000-0000-000000000003"),
+	}, e.tags)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// imdsInstanceUrl is the compute metadata endpoint of Azure Instance Metadata Service.
+var imdsInstanceUrl = "http://169.254.169.254/metadata/instance/compute?api-version=2021-02-01"
+
+// imdsTimeout is how long the provider waits for IMDS, it's short since IMDS doesn't exist outside of Azure.
+const imdsTimeout = time.Second
+
+// azureEnvironment holds coarse, non-identifying facts about the Azure VM or agent the provider runs on.
+type azureEnvironment struct {
+	region   string
+	vmFamily string
+}
+
+// imdsCompute is the part of the IMDS compute metadata that the provider reads.
+type imdsCompute struct {
+	Location       string `json:"location"`
+	VmSize         string `json:"vmSize"`
+	SubscriptionId string `json:"subscriptionId"`
+}
+
+// readImdsCompute reads the compute metadata from IMDS, ok is false when the provider doesn't run on Azure.
+func readImdsCompute(ctx context.Context) (compute imdsCompute, ok bool) {
+	ctx, cancel := context.WithTimeout(ctx, imdsTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsInstanceUrl, nil)
+	if err != nil {
+		return compute, false
+	}
+	req.Header.Set("Metadata", "true")
+	// IMDS must never be reached through a proxy.
+	resp, err := (&http.Client{Transport: &http.Transport{Proxy: nil}}).Do(req)
+	if err != nil {
+		traceLog(ctx, fmt.Sprintf("IMDS is not available: %s", err.Error()))
+		return compute, false
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return compute, false
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&compute); err != nil || compute.Location == "" {
+		return imdsCompute{}, false
+	}
+	return compute, true
+}
+
+// detectAzureEnvironment reads the region and the VM size from IMDS, ok is false when the provider doesn't run on Azure.
+func detectAzureEnvironment(ctx context.Context) (env azureEnvironment, ok bool) {
+	compute, ok := readImdsCompute(ctx)
+	if !ok {
+		return env, false
+	}
+	return azureEnvironment{region: compute.Location, vmFamily: vmSizeFamily(compute.VmSize)}, true
+}
+
+// vmSizeFamily returns the family of a VM size without the tier, the vCPU count, the features and the version,
+// e.g. `D` for `Standard_D4s_v3` and `NC` for `Standard_NC24ads_A100_v4`.
+func vmSizeFamily(vmSize string) string {
+	size := vmSize
+	if tier, rest, found := strings.Cut(vmSize, "_"); found && (strings.EqualFold(tier, "Standard") || strings.EqualFold(tier, "Basic")) {
+		size = rest
+	}
+	end := strings.IndexFunc(size, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if end < 0 {
+		end = len(size)
+	}
+	return strings.ToUpper(size[:end])
+}
+
+// azureEnvironmentStage tags the event with `azure_region` and `azure_vm_family` when enabled and the provider runs
+// on an Azure VM or agent. IMDS is only queried once, by the first event.
+func azureEnvironmentStage(enabled bool) eventStage {
+	var once sync.Once
+	var env azureEnvironment
+	var onAzure bool
+	return eventStage{
+		name: stageAzureEnvironment,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			if !enabled {
+				return true
+			}
+			once.Do(func() {
+				env, onAzure = detectAzureEnvironment(ctx)
+			})
+			if !onAzure {
+				return true
+			}
+			for k, v := range map[string]string{"azure_region": env.region, "azure_vm_family": env.vmFamily} {
+				if _, ok := e.tags[k]; !ok && v != "" {
+					e.tags[k] = v
+				}
+			}
+			return true
+		},
+	}
+}
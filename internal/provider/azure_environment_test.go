@@ -0,0 +1,65 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVmSizeFamily(t *testing.T) {
+	cases := map[string]string{
+		"Standard_D4s_v3":          "D",
+		"Standard_NC24ads_A100_v4": "NC",
+		"Basic_A1":                 "A",
+		"Standard_DC2s_v2":         "DC",
+		"E8s_v5":                   "E",
+		"":                         "",
+	}
+	for vmSize, expected := range cases {
+		assert.Equal(t, expected, vmSizeFamily(vmSize), vmSize)
+	}
+}
+
+func TestAzureEnvironmentStage(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "true", request.Header.Get("Metadata"))
+		_, _ = writer.Write([]byte(`{"location":"eastus2","vmSize":"Standard_D4s_v3","name":"build-agent-42","subscriptionId":"00000000-0000-0000-0000-000000000000"}`))
+	}))
+	defer server.Close()
+	stub := gostub.Stub(&imdsInstanceUrl, server.URL)
+	defer stub.Reset()
+
+	stage := azureEnvironmentStage(true)
+	for i := 0; i < 2; i++ {
+		e := &telemetryEvent{tags: map[string]string{}}
+		assert.True(t, stage.process(context.Background(), e))
+		assert.Equal(t, map[string]string{"azure_region": "eastus2", "azure_vm_family": "D"}, e.tags)
+	}
+	assert.Equal(t, int32(1), requests.Load())
+
+	e := &telemetryEvent{tags: map[string]string{}}
+	assert.True(t, azureEnvironmentStage(false).process(context.Background(), e))
+	assert.Empty(t, e.tags)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestAzureEnvironmentStage_notOnAzure(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	stub := gostub.Stub(&imdsInstanceUrl, server.URL)
+	defer stub.Reset()
+
+	e := &telemetryEvent{tags: map[string]string{}}
+	assert.True(t, azureEnvironmentStage(true).process(context.Background(), e))
+	assert.Empty(t, e.tags)
+}
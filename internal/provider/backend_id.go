@@ -0,0 +1,118 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultBackendIdSalt is used when `backend_id_salt` is not set.
+const defaultBackendIdSalt = "modtm-backend-id"
+
+// backendIdentityAttributes are the backend configuration attributes that tell where the state file lives,
+// credentials and other settings are left out so the same state file always has the same id. All attributes
+// are used for backends that are not listed.
+var backendIdentityAttributes = map[string][]string{
+	"azurerm":    {"storage_account_name", "container_name", "key"},
+	"s3":         {"bucket", "key", "region", "workspace_key_prefix"},
+	"gcs":        {"bucket", "prefix"},
+	"local":      {"path", "workspace_dir"},
+	"remote":     {"hostname", "organization", "workspaces"},
+	"cloud":      {"hostname", "organization", "workspaces", "project"},
+	"kubernetes": {"namespace", "secret_suffix"},
+	"consul":     {"address", "path"},
+	"http":       {"address"},
+	"oss":        {"bucket", "prefix", "key"},
+	"cos":        {"bucket", "prefix", "key"},
+	"pg":         {"conn_str", "schema_name"},
+}
+
+// backendStateModel is the backend configuration cached by `terraform init` in `$TF_DATA_DIR/terraform.tfstate`.
+type backendStateModel struct {
+	Backend *struct {
+		Type   string         `json:"type"`
+		Config map[string]any `json:"config"`
+	} `json:"backend"`
+}
+
+// backendId returns a salted hash identifying the state file of the current working dir and workspace,
+// computed from the backend configuration cached in dataDir. It returns an empty string if the working
+// dir hasn't been initialized.
+func backendId(dataDir, workspace, salt string, policy cryptoPolicy) (string, error) {
+	content, err := os.ReadFile(filepath.Clean(filepath.Join(dataDir, "terraform.tfstate")))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var state backendStateModel
+	if err = json.Unmarshal(content, &state); err != nil {
+		return "", err
+	}
+	backendType := "local"
+	config := map[string]any{}
+	if state.Backend != nil && state.Backend.Type != "" {
+		backendType = state.Backend.Type
+		config = state.Backend.Config
+	}
+	if backendType == "local" {
+		// Relative paths of local state are relative to the working dir.
+		p, _ := config["path"].(string)
+		if p == "" {
+			p = "terraform.tfstate"
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		config["path"] = p
+	}
+
+	attributes, ok := backendIdentityAttributes[backendType]
+	if !ok {
+		for k := range config {
+			attributes = append(attributes, k)
+		}
+	}
+	sort.Strings(attributes)
+	parts := []string{backendType, workspace}
+	for _, attribute := range attributes {
+		if v, ok := config[attribute]; ok && v != nil {
+			parts = append(parts, fmt.Sprintf("%s=%v", attribute, v))
+		}
+	}
+	if salt == "" {
+		salt = defaultBackendIdSalt
+	}
+	h, err := policy.newHMAC("sha256", []byte(salt))
+	if err != nil {
+		return "", err
+	}
+	_, _ = h.Write([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// backendIdStage tags the event with `backend_id` unless id is empty or the tag already exists.
+func backendIdStage(id string) eventStage {
+	return eventStage{
+		name: stageBackendId,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			if id == "" {
+				return true
+			}
+			if _, ok := e.tags["backend_id"]; !ok {
+				e.tags["backend_id"] = id
+			}
+			return true
+		},
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeBackendState(t *testing.T, content string) string {
+	dataDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "terraform.tfstate"), []byte(content), 0600))
+	return dataDir
+}
+
+const azurermBackendState = `{
+  "version": 3,
+  "backend": {
+    "type": "azurerm",
+    "config": {
+      "storage_account_name": "tfstate",
+      "container_name": "state",
+      "key": "%s",
+      "access_key": "%s",
+      "use_msi": null
+    }
+  }
+}`
+
+func TestBackendId_ignoresCredentials(t *testing.T) {
+	id1, err := backendId(writeBackendState(t, fmt.Sprintf(azurermBackendState, "prod.tfstate", "secret1")), "default", "", cryptoPolicy{})
+	require.NoError(t, err)
+	id2, err := backendId(writeBackendState(t, fmt.Sprintf(azurermBackendState, "prod.tfstate", "secret2")), "default", "", cryptoPolicy{})
+	require.NoError(t, err)
+	assert.Len(t, id1, 64)
+	assert.Equal(t, id1, id2)
+}
+
+func TestBackendId_distinctStateFiles(t *testing.T) {
+	dataDir := writeBackendState(t, fmt.Sprintf(azurermBackendState, "prod.tfstate", "secret"))
+	id, err := backendId(dataDir, "default", "", cryptoPolicy{})
+	require.NoError(t, err)
+
+	otherKey, err := backendId(writeBackendState(t, fmt.Sprintf(azurermBackendState, "dev.tfstate", "secret")), "default", "", cryptoPolicy{})
+	require.NoError(t, err)
+	assert.NotEqual(t, id, otherKey)
+
+	otherWorkspace, err := backendId(dataDir, "staging", "", cryptoPolicy{})
+	require.NoError(t, err)
+	assert.NotEqual(t, id, otherWorkspace)
+
+	otherSalt, err := backendId(dataDir, "default", "pepper", cryptoPolicy{})
+	require.NoError(t, err)
+	assert.NotEqual(t, id, otherSalt)
+}
+
+func TestBackendId_uninitializedWorkingDir(t *testing.T) {
+	id, err := backendId(t.TempDir(), "default", "", cryptoPolicy{})
+	require.NoError(t, err)
+	assert.Empty(t, id)
+}
+
+func TestBackendId_implicitLocalBackendUsesWorkingDir(t *testing.T) {
+	dataDir := writeBackendState(t, `{"version": 3}`)
+	chdir(t, t.TempDir())
+	id1, err := backendId(dataDir, "default", "", cryptoPolicy{fipsMode: true})
+	require.NoError(t, err)
+	assert.NotEmpty(t, id1)
+	chdir(t, t.TempDir())
+	id2, err := backendId(dataDir, "default", "", cryptoPolicy{fipsMode: true})
+	require.NoError(t, err)
+	assert.NotEqual(t, id1, id2)
+}
+
+func TestBackendIdStage(t *testing.T) {
+	e := &telemetryEvent{name: "create", tags: map[string]string{}}
+	assert.True(t, backendIdStage("abc").process(context.Background(), e))
+	assert.Equal(t, "abc", e.tags["backend_id"])
+
+	e = &telemetryEvent{name: "create", tags: map[string]string{}}
+	assert.True(t, backendIdStage("").process(context.Background(), e))
+	assert.NotContains(t, e.tags, "backend_id")
+}
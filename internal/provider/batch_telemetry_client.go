@@ -0,0 +1,108 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+)
+
+var _ queuingClient = &batchTelemetryClient{}
+
+// batchTelemetryClient queues events in memory and sends all events of an endpoint in a single HTTP POST request
+// with a JSON array as body when the provider exits, reducing the number of requests of large compositions to one
+// per endpoint and run.
+type batchTelemetryClient struct {
+	*httpTelemetryClient
+	mu sync.Mutex
+	// endpoints keeps the order in which endpoints received their first event.
+	endpoints []string
+	batches   map[string][]batchedEvent
+}
+
+// batchedEvent is a queued event and the handler of its delivery failure, if any.
+type batchedEvent struct {
+	tags      map[string]string
+	onFailure deliveryFailureHandler
+}
+
+func newBatchTelemetryClient(client *http.Client) *batchTelemetryClient {
+	return &batchTelemetryClient{
+		httpTelemetryClient: newHttpTelemetryClient(client, payloadEncodingJSON),
+		batches:             make(map[string][]batchedEvent),
+	}
+}
+
+// send queues the tags, it never fails since nothing is sent until flush.
+func (b *batchTelemetryClient) send(ctx context.Context, endpoint string, tags map[string]string) error {
+	return b.enqueue(ctx, endpoint, tags, nil)
+}
+
+// enqueue queues the tags, it never fails since nothing is sent until flush.
+func (b *batchTelemetryClient) enqueue(ctx context.Context, endpoint string, tags map[string]string, onFailure deliveryFailureHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.batches[endpoint]; !ok {
+		b.endpoints = append(b.endpoints, endpoint)
+	}
+	b.batches[endpoint] = append(b.batches[endpoint], batchedEvent{tags: maps.Clone(tags), onFailure: onFailure})
+	traceLog(ctx, fmt.Sprintf("queued %s telemetry event for %s", tags["event"], endpoint))
+	return nil
+}
+
+// flush sends the queued events of every endpoint, events that cannot be sent until ctx is done are lost. When the
+// batch of an endpoint fails, the failure handlers of its events run one by one with a client that sends every event
+// in its own request.
+func (b *batchTelemetryClient) flush(ctx context.Context) {
+	b.mu.Lock()
+	endpoints, batches := b.endpoints, b.batches
+	b.endpoints, b.batches = nil, make(map[string][]batchedEvent)
+	b.mu.Unlock()
+	for _, endpoint := range endpoints {
+		err := b.postBatch(ctx, endpoint, batches[endpoint])
+		if err == nil {
+			traceLog(ctx, fmt.Sprintf("sent %d telemetry events to %s", len(batches[endpoint]), endpoint))
+			continue
+		}
+		errorLog(ctx, fmt.Sprintf("error on sending %d telemetry events to %s: %+v", len(batches[endpoint]), endpoint, err))
+		for _, e := range batches[endpoint] {
+			if e.onFailure != nil {
+				e.onFailure(ctx, b.httpTelemetryClient, err)
+			}
+		}
+	}
+}
+
+func (b *batchTelemetryClient) postBatch(ctx context.Context, endpoint string, events []batchedEvent) error {
+	payloads := make([]any, 0, len(events))
+	for _, e := range events {
+		payloads = append(payloads, payload(b.format, e.tags))
+	}
+	body, err := json.Marshal(payloads)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if err = b.setHeaders(ctx, req); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return &endpointStatusError{status: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTelemetryClient_sendsAllEventsInOneRequestPerEndpoint(t *testing.T) {
+	var mu sync.Mutex
+	batches := make(map[string][][]map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var events []map[string]string
+		data, _ := io.ReadAll(request.Body)
+		_ = json.Unmarshal(data, &events)
+		mu.Lock()
+		defer mu.Unlock()
+		batches[request.URL.Path] = append(batches[request.URL.Path], events)
+	}))
+	defer server.Close()
+	client := newBatchTelemetryClient(http.DefaultClient)
+	for _, event := range []string{"create", "read", "delete"} {
+		require.NoError(t, client.send(context.Background(), server.URL+"/a", map[string]string{"event": event}))
+	}
+	require.NoError(t, client.send(context.Background(), server.URL+"/b", map[string]string{"event": "update"}))
+
+	mu.Lock()
+	assert.Empty(t, batches)
+	mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.flush(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string][][]map[string]string{
+		"/a": {{{"event": "create"}, {"event": "read"}, {"event": "delete"}}},
+		"/b": {{{"event": "update"}}},
+	}, batches)
+}
+
+func TestBatchTelemetryClient_flushEmptiesQueue(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	client := newBatchTelemetryClient(http.DefaultClient)
+	require.NoError(t, client.send(context.Background(), server.URL, map[string]string{"event": "create"}))
+	client.flush(context.Background())
+	client.flush(context.Background())
+	assert.Equal(t, 1, requests)
+}
+
+func TestBatchTelemetryClient_v2Payload(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ = io.ReadAll(request.Body)
+	}))
+	defer server.Close()
+
+	client := newBatchTelemetryClient(http.DefaultClient)
+	client.format = payloadFormatV2
+	require.NoError(t, client.send(context.Background(), server.URL, map[string]string{"event": "create", "resource_id": "id", "timestamp": "t", "module_source": "foo"}))
+	client.flush(context.Background())
+	assert.JSONEq(t, `[{"schema_version":2,"event":"create","resource_id":"id","timestamp":"t","tags":{"module_source":"foo"}}]`, string(body))
+}
+
+func TestBatchTelemetryClient_failedBatchRunsFailureHandlers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	client := newBatchTelemetryClient(http.DefaultClient)
+	var failed []string
+	for _, event := range []string{"create", "delete"} {
+		require.NoError(t, client.enqueue(context.Background(), server.URL, map[string]string{"event": event}, func(ctx context.Context, client telemetryClient, err error) {
+			assert.NotNil(t, client)
+			assert.Error(t, err)
+			failed = append(failed, event)
+		}))
+	}
+	require.NoError(t, client.send(context.Background(), server.URL, map[string]string{"event": "read"}))
+	client.flush(context.Background())
+	assert.Equal(t, []string{"create", "delete"}, failed)
+}
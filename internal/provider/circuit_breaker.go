@@ -0,0 +1,93 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultCircuitBreakerCooldown is how long sends to an unreachable endpoint are skipped, unless it's overridden by
+// `circuit_breaker_cooldown`.
+const defaultCircuitBreakerCooldown = time.Minute
+
+// endpointStatusError is returned when the endpoint has been reached but responded with a non-2xx status.
+type endpointStatusError struct {
+	status int
+}
+
+func (e *endpointStatusError) Error() string {
+	return fmt.Sprintf("telemetry endpoint responded %d", e.status)
+}
+
+// circuitOpenError is returned for events that are skipped since their endpoint is unreachable.
+type circuitOpenError struct {
+	endpoint string
+	until    time.Time
+}
+
+func (e *circuitOpenError) Error() string {
+	return fmt.Sprintf("telemetry endpoint %s is unreachable, events are skipped until %s", e.endpoint, e.until.Format(time.RFC3339))
+}
+
+var _ telemetryClient = &circuitBreakerClient{}
+
+// circuitBreakerClient stops sending events to an endpoint for cooldown once a send has failed to reach it, so an
+// offline machine doesn't wait out the request timeout of every event. The circuit of the endpoint is open until
+// the cooldown has passed, then the next event is sent to probe the endpoint. Server errors don't open the circuit,
+// nor does the cancellation of an operation.
+type circuitBreakerClient struct {
+	next     telemetryClient
+	cooldown time.Duration
+	mu       sync.Mutex
+	// openUntil is when the circuit of each unreachable endpoint closes.
+	openUntil map[string]time.Time
+}
+
+func newCircuitBreakerClient(next telemetryClient, cooldown time.Duration) *circuitBreakerClient {
+	return &circuitBreakerClient{
+		next:      next,
+		cooldown:  cooldown,
+		openUntil: make(map[string]time.Time),
+	}
+}
+
+func (c *circuitBreakerClient) discoverEndpoint(ctx context.Context) (string, error) {
+	return c.next.discoverEndpoint(ctx)
+}
+
+func (c *circuitBreakerClient) send(ctx context.Context, endpoint string, tags map[string]string) error {
+	c.mu.Lock()
+	until, open := c.openUntil[endpoint]
+	c.mu.Unlock()
+	if open && timeNow().Before(until) {
+		err := &circuitOpenError{endpoint: endpoint, until: until}
+		traceLog(ctx, fmt.Sprintf("skip %s telemetry event: %s", tags["event"], err.Error()))
+		return err
+	}
+	err := c.next.send(ctx, endpoint, tags)
+	var statusErr *endpointStatusError
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case err == nil || errors.As(err, &statusErr):
+		delete(c.openUntil, endpoint)
+	case errors.Is(err, context.Canceled):
+	default:
+		c.openUntil[endpoint] = timeNow().Add(c.cooldown)
+		traceLog(ctx, fmt.Sprintf("telemetry endpoint %s is unreachable, skip its events for %s", endpoint, c.cooldown))
+	}
+	return err
+}
+
+// prewarm prewarms the connection of next, if it supports it.
+func (c *circuitBreakerClient) prewarm(ctx context.Context, endpoint string) error {
+	if p, ok := c.next.(prewarmer); ok {
+		return p.prewarm(ctx, endpoint)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerClient_skipsUnreachableEndpoint(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return now
+	})
+	defer stub.Reset()
+
+	next := &fakeTelemetryClient{sendErr: syscall.ECONNREFUSED}
+	client := newCircuitBreakerClient(next, time.Minute)
+	tags := map[string]string{"event": "create"}
+	assert.ErrorIs(t, client.send(context.Background(), "https://a.contoso.com", tags), syscall.ECONNREFUSED)
+	var openErr *circuitOpenError
+	assert.ErrorAs(t, client.send(context.Background(), "https://a.contoso.com", tags), &openErr)
+	assert.Len(t, next.sentEvents(), 1, "the event to the unreachable endpoint is skipped")
+
+	next.sendErr = nil
+	assert.NoError(t, client.send(context.Background(), "https://b.contoso.com", tags), "other endpoints are not affected")
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, client.send(context.Background(), "https://a.contoso.com", tags), "the endpoint is probed after the cool-down")
+	assert.NoError(t, client.send(context.Background(), "https://a.contoso.com", tags))
+	assert.Len(t, next.sentEvents(), 4)
+}
+
+func TestCircuitBreakerClient_serverErrorAndCancellationKeepCircuitClosed(t *testing.T) {
+	for desc, err := range map[string]error{
+		"server error": &endpointStatusError{status: http.StatusServiceUnavailable},
+		"cancellation": errors.Join(errors.New("timeout on create telemetry resource"), context.Canceled),
+	} {
+		t.Run(desc, func(t *testing.T) {
+			next := &fakeTelemetryClient{sendErr: err}
+			client := newCircuitBreakerClient(next, time.Minute)
+			for i := 0; i < 2; i++ {
+				assert.ErrorIs(t, client.send(context.Background(), "https://a.contoso.com", map[string]string{"event": "create"}), err)
+			}
+			assert.Len(t, next.sentEvents(), 2)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// deliveryStatePrivateKey is the key of the delivery state in the resource's private state.
+const deliveryStatePrivateKey = "delivery"
+
+// deliveryState is the delivery metadata of a telemetry resource. It's kept in the resource's private state
+// rather than in schema attributes, so it never shows up in plans or in the user-facing state.
+type deliveryState struct {
+	// LastSentAt is when an event of the resource was last accepted by the telemetry endpoint.
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	// LastQueuedAt is when an event of the resource was last queued by the `async` send mode or the `batch`
+	// transport, whether it has been delivered is unknown since it's sent after the resource's operation.
+	LastQueuedAt *time.Time `json:"last_queued_at,omitempty"`
+	// FailureCount is the number of consecutive events that could not be sent.
+	FailureCount int `json:"failure_count,omitempty"`
+}
+
+// heartbeatEvent is the event sent instead of the read event when the resource's `heartbeat_interval` has elapsed.
+const heartbeatEvent = "heartbeat"
+
+// heartbeatDue returns true when interval is set and no event has been accepted by the endpoint, or queued to be
+// sent, for that long, including when no event has ever been. An invalid interval never makes the heartbeat due.
+func (s deliveryState) heartbeatDue(interval string) bool {
+	if interval == "" {
+		return false
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		return false
+	}
+	last := s.LastSentAt
+	if s.LastQueuedAt != nil && (last == nil || s.LastQueuedAt.After(*last)) {
+		last = s.LastQueuedAt
+	}
+	return last == nil || timeNow().Sub(*last) >= d
+}
+
+// deliveryAttempt is the outcome of an attempt to send an event to the telemetry endpoint.
+type deliveryAttempt struct {
+	err error
+	// queued is true when the event has only been queued, its delivery happens after the resource's operation.
+	queued bool
+	// truncatedTags are the keys of the tags that have been truncated to fit the payload size limit.
+	truncatedTags []string
+}
+
+type privateStateGetter interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+type privateStateSetter interface {
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// readDeliveryState reads the delivery state from the private state. An invalid delivery state is ignored,
+// it's only metadata and must never block the resource's lifecycle.
+func readDeliveryState(ctx context.Context, private privateStateGetter) (deliveryState, diag.Diagnostics) {
+	var s deliveryState
+	content, diags := private.GetKey(ctx, deliveryStatePrivateKey)
+	if diags.HasError() || len(content) == 0 {
+		return s, diags
+	}
+	if err := json.Unmarshal(content, &s); err != nil {
+		traceLog(ctx, fmt.Sprintf("ignore invalid delivery state: %+v", err))
+		return deliveryState{}, diags
+	}
+	return s, diags
+}
+
+func writeDeliveryState(ctx context.Context, private privateStateSetter, s deliveryState) diag.Diagnostics {
+	content, err := json.Marshal(s)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Failed to encode delivery state", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, deliveryStatePrivateKey, content)
+}
+
+// record updates the state with the outcome of a delivery attempt, a nil attempt means no event has been
+// sent, e.g. the telemetry is disabled or the event has been filtered out.
+func (s *deliveryState) record(attempt *deliveryAttempt) {
+	if attempt == nil {
+		return
+	}
+	if attempt.err != nil {
+		s.FailureCount++
+		return
+	}
+	now := timeNow().UTC()
+	if attempt.queued {
+		// The outcome of the delivery is unknown, the failures and the last accepted event are left as they are.
+		s.LastQueuedAt = &now
+		return
+	}
+	s.LastSentAt = &now
+	s.FailureCount = 0
+}
+
+// updateDeliveryState records the attempt in the private state, private state data is carried over from the
+// request to the response by the framework so the previous state is read from the response. A warning is returned
+// when tags of the event have been truncated.
+func updateDeliveryState(ctx context.Context, private interface {
+	privateStateGetter
+	privateStateSetter
+}, attempt *deliveryAttempt) diag.Diagnostics {
+	if attempt == nil {
+		return nil
+	}
+	s, diags := readDeliveryState(ctx, private)
+	if diags.HasError() {
+		return diags
+	}
+	if len(attempt.truncatedTags) > 0 {
+		diags.AddWarning("Telemetry tags truncated", fmt.Sprintf("The telemetry payload is larger than `max_payload_size`, the values of these tags have been truncated: %s.", strings.Join(attempt.truncatedTags, ", ")))
+	}
+	s.record(attempt)
+	return append(diags, writeDeliveryState(ctx, private, s)...)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePrivateState map[string][]byte
+
+func (f fakePrivateState) GetKey(_ context.Context, key string) ([]byte, diag.Diagnostics) {
+	return f[key], nil
+}
+
+func (f fakePrivateState) SetKey(_ context.Context, key string, value []byte) diag.Diagnostics {
+	f[key] = value
+	return nil
+}
+
+func TestUpdateDeliveryState(t *testing.T) {
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return now
+	})
+	defer stub.Reset()
+	ctx := context.Background()
+	private := fakePrivateState{}
+
+	require.False(t, updateDeliveryState(ctx, private, &deliveryAttempt{err: errors.New("outage")}).HasError())
+	require.False(t, updateDeliveryState(ctx, private, &deliveryAttempt{err: errors.New("outage")}).HasError())
+	s, diags := readDeliveryState(ctx, private)
+	require.False(t, diags.HasError())
+	assert.Equal(t, deliveryState{FailureCount: 2}, s)
+
+	require.False(t, updateDeliveryState(ctx, private, nil).HasError())
+	s, _ = readDeliveryState(ctx, private)
+	assert.Equal(t, 2, s.FailureCount)
+
+	require.False(t, updateDeliveryState(ctx, private, &deliveryAttempt{}).HasError())
+	s, _ = readDeliveryState(ctx, private)
+	assert.Equal(t, deliveryState{LastSentAt: &now}, s)
+}
+
+func TestUpdateDeliveryState_queuedEventIsNotRecordedAsSent(t *testing.T) {
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return now
+	})
+	defer stub.Reset()
+	ctx := context.Background()
+	private := fakePrivateState{}
+
+	require.False(t, updateDeliveryState(ctx, private, &deliveryAttempt{err: errors.New("outage")}).HasError())
+	require.False(t, updateDeliveryState(ctx, private, &deliveryAttempt{queued: true}).HasError())
+	s, _ := readDeliveryState(ctx, private)
+	assert.Equal(t, deliveryState{LastQueuedAt: &now, FailureCount: 1}, s)
+}
+
+func TestReadDeliveryState_invalidStateIsIgnored(t *testing.T) {
+	private := fakePrivateState{deliveryStatePrivateKey: []byte(`{"failure_count":"many"}`)}
+	s, diags := readDeliveryState(context.Background(), private)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, deliveryState{}, s)
+}
+
+func TestUpdateDeliveryState_truncatedTagsWarning(t *testing.T) {
+	diags := updateDeliveryState(context.Background(), fakePrivateState{}, &deliveryAttempt{truncatedTags: []string{"description"}})
+	require.False(t, diags.HasError())
+	require.Len(t, diags.Warnings(), 1)
+	assert.Contains(t, diags.Warnings()[0].Detail(), "description")
+}
+
+func TestDeliveryState_heartbeatDue(t *testing.T) {
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return now
+	})
+	defer stub.Reset()
+	lastSentAt := now.Add(-2 * time.Hour)
+	sent := deliveryState{LastSentAt: &lastSentAt}
+
+	assert.False(t, sent.heartbeatDue(""), "no heartbeat without interval")
+	assert.False(t, sent.heartbeatDue("invalid"))
+	assert.False(t, sent.heartbeatDue("3h"))
+	assert.True(t, sent.heartbeatDue("2h"))
+	assert.True(t, sent.heartbeatDue("1h"))
+	assert.True(t, deliveryState{}.heartbeatDue("1h"), "heartbeat is due when no event has been sent")
+
+	lastQueuedAt := now.Add(-30 * time.Minute)
+	queued := deliveryState{LastSentAt: &lastSentAt, LastQueuedAt: &lastQueuedAt}
+	assert.False(t, queued.heartbeatDue("1h"), "a queued event counts as sent")
+	assert.True(t, queued.heartbeatDue("30m"))
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultEndpointCacheTTL is how long the discovered default endpoint is reused, unless it's overridden by
+	// `endpoint_cache_ttl`.
+	defaultEndpointCacheTTL = 24 * time.Hour
+	// endpointCacheBypassEnv turns the endpoint cache off, the default endpoint is discovered every time.
+	endpointCacheBypassEnv = "MODTM_ENDPOINT_CACHE_BYPASS"
+)
+
+// endpointCache keeps the discovered default endpoint in a local file, so repeated plans don't read the blob every
+// time, and runs that cannot reach the blob could use the last known endpoint. A nil *endpointCache always discovers
+// the endpoint.
+type endpointCache struct {
+	path string
+	ttl  time.Duration
+}
+
+type cachedEndpoint struct {
+	Endpoint     string    `json:"endpoint"`
+	DiscoveredAt time.Time `json:"discovered_at"`
+}
+
+func newEndpointCache(path string, ttl time.Duration) *endpointCache {
+	if bypass, _ := strconv.ParseBool(os.Getenv(endpointCacheBypassEnv)); bypass || path == "" {
+		return nil
+	}
+	return &endpointCache{path: path, ttl: ttl}
+}
+
+// defaultEndpointCachePath returns the endpoint cache file in the user's cache directory, or an empty string if
+// there's no such directory.
+func defaultEndpointCachePath() string {
+	return userCachePath("endpoint.json")
+}
+
+// discover returns the cached endpoint if it has been discovered within the TTL, otherwise it discovers the endpoint
+// with client and caches it. The expired endpoint is returned when the discovery fails, unless the discovered endpoint
+// is malformed. The cached endpoint is validated again since the file could have been edited.
+func (c *endpointCache) discover(ctx context.Context, client telemetryClient) (string, error) {
+	if c == nil {
+		return client.discoverEndpoint(ctx)
+	}
+	cached, ok := c.read()
+	if ok && timeNow().Sub(cached.DiscoveredAt) < c.ttl {
+		traceLog(ctx, fmt.Sprintf("Use provider's endpoint cached in %s", c.path))
+		return cached.Endpoint, nil
+	}
+	endpoint, err := client.discoverEndpoint(ctx)
+	if err != nil {
+		var invalidErr *invalidEndpointError
+		if ok && !errors.As(err, &invalidErr) {
+			traceLog(ctx, fmt.Sprintf("Failed to discover provider's endpoint, use the expired one cached in %s: %+v", c.path, err))
+			return cached.Endpoint, nil
+		}
+		return "", err
+	}
+	content, err := json.Marshal(cachedEndpoint{Endpoint: endpoint, DiscoveredAt: timeNow().UTC()})
+	if err == nil {
+		err = writeFileAtomic(c.path, content)
+	}
+	if err != nil {
+		traceLog(ctx, fmt.Sprintf("error on caching provider's endpoint in %s: %+v", c.path, err))
+	}
+	return endpoint, nil
+}
+
+// read returns the cached endpoint, false if there's none or it's invalid.
+func (c *endpointCache) read() (cachedEndpoint, bool) {
+	var cached cachedEndpoint
+	content, err := os.ReadFile(c.path)
+	if err != nil || json.Unmarshal(content, &cached) != nil {
+		return cached, false
+	}
+	if cached.Endpoint, err = discoveredEndpointPolicy.validate(cached.Endpoint); err != nil {
+		return cached, false
+	}
+	return cached, true
+}
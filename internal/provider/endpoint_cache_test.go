@@ -0,0 +1,72 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointCache_discover(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return now
+	})
+	defer stub.Reset()
+
+	cache := &endpointCache{path: filepath.Join(t.TempDir(), "modtm", "endpoint.json"), ttl: time.Hour}
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.azurewebsites.net"}
+	endpoint, err := cache.discover(context.Background(), client)
+	require.NoError(t, err)
+	assert.Equal(t, "https://telemetry.azurewebsites.net", endpoint)
+	assert.Equal(t, 1, client.discoverCalls)
+
+	client.endpoint = "https://telemetry2.azurewebsites.net"
+	now = now.Add(59 * time.Minute)
+	endpoint, err = cache.discover(context.Background(), client)
+	require.NoError(t, err)
+	assert.Equal(t, "https://telemetry.azurewebsites.net", endpoint, "the cached endpoint is used within the TTL")
+	assert.Equal(t, 1, client.discoverCalls)
+
+	now = now.Add(time.Minute)
+	endpoint, err = cache.discover(context.Background(), client)
+	require.NoError(t, err)
+	assert.Equal(t, "https://telemetry2.azurewebsites.net", endpoint, "the endpoint is discovered again once it has expired")
+	assert.Equal(t, 2, client.discoverCalls)
+
+	client.discoverErr = errors.New("offline")
+	now = now.Add(time.Hour)
+	endpoint, err = cache.discover(context.Background(), client)
+	require.NoError(t, err)
+	assert.Equal(t, "https://telemetry2.azurewebsites.net", endpoint, "the expired endpoint is used when the discovery fails")
+
+	client.discoverErr = &invalidEndpointError{reason: "empty"}
+	_, err = cache.discover(context.Background(), client)
+	assert.Error(t, err, "a malformed discovery document is not covered up by the cache")
+}
+
+func TestEndpointCache_ignoresInvalidCachedEndpoint(t *testing.T) {
+	cache := &endpointCache{path: filepath.Join(t.TempDir(), "endpoint.json"), ttl: time.Hour}
+	require.NoError(t, os.WriteFile(cache.path, []byte(`{"endpoint":"https://telemetry.contoso.com","discovered_at":"2999-01-01T00:00:00Z"}`), 0600))
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.azurewebsites.net"}
+	endpoint, err := cache.discover(context.Background(), client)
+	require.NoError(t, err)
+	assert.Equal(t, "https://telemetry.azurewebsites.net", endpoint)
+	assert.Equal(t, 1, client.discoverCalls)
+}
+
+func TestNewEndpointCache_bypass(t *testing.T) {
+	t.Setenv(endpointCacheBypassEnv, "true")
+	assert.Nil(t, newEndpointCache(filepath.Join(t.TempDir(), "endpoint.json"), time.Hour))
+	t.Setenv(endpointCacheBypassEnv, "false")
+	assert.NotNil(t, newEndpointCache(filepath.Join(t.TempDir(), "endpoint.json"), time.Hour))
+}
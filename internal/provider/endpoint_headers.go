@@ -0,0 +1,31 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// endpointTokenEnv sets `bearer_token` when it's not set in the provider block.
+const endpointTokenEnv = "MODTM_ENDPOINT_TOKEN"
+
+// newEndpointHeaders returns the headers sent with every event, bearerToken wins over an `Authorization` header
+// in headers. It returns nil when there's no header.
+func newEndpointHeaders(headers types.Map, bearerToken string) http.Header {
+	h := make(http.Header)
+	for k, v := range headers.Elements() {
+		if s, ok := v.(types.String); ok {
+			h.Set(k, s.ValueString())
+		}
+	}
+	if bearerToken != "" {
+		h.Set("Authorization", "Bearer "+bearerToken)
+	}
+	if len(h) == 0 {
+		return nil
+	}
+	return h
+}
@@ -0,0 +1,58 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEndpointHeaders(t *testing.T) {
+	assert.Nil(t, newEndpointHeaders(types.MapNull(types.StringType), ""))
+
+	headers := newEndpointHeaders(types.MapValueMust(types.StringType, map[string]attr.Value{
+		"x-api-key":     types.StringValue("key"),
+		"Authorization": types.StringValue("Basic Zm9vOmJhcg=="),
+	}), "token")
+	assert.Equal(t, "key", headers.Get("X-Api-Key"))
+	assert.Equal(t, "Bearer token", headers.Get("Authorization"))
+}
+
+func TestHttpTelemetryClient_sendWithHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received = request.Header.Clone()
+	}))
+	defer server.Close()
+
+	client := newHttpTelemetryClient(http.DefaultClient, payloadEncodingJSON)
+	client.headers = newEndpointHeaders(types.MapValueMust(types.StringType, map[string]attr.Value{
+		"x-api-key": types.StringValue("key"),
+	}), "token")
+	require.NoError(t, client.send(context.Background(), server.URL, map[string]string{"event": "create"}))
+	assert.Equal(t, "key", received.Get("X-Api-Key"))
+	assert.Equal(t, "Bearer token", received.Get("Authorization"))
+	assert.Equal(t, "application/json", received.Get("Content-Type"))
+}
+
+func TestBatchTelemetryClient_flushWithHeaders(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		authorization = request.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	client := newBatchTelemetryClient(http.DefaultClient)
+	client.headers = newEndpointHeaders(types.MapNull(types.StringType), "token")
+	require.NoError(t, client.send(context.Background(), server.URL, map[string]string{"event": "create"}))
+	client.flush(context.Background())
+	assert.Equal(t, "Bearer token", authorization)
+}
@@ -0,0 +1,148 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// maxEndpointLength is the maximum length of an endpoint, anything longer is not a sane URL.
+const maxEndpointLength = 2048
+
+// endpointPolicy restricts the endpoints that are accepted from the discovery document or the configuration.
+type endpointPolicy struct {
+	schemes []string
+	// hostSuffixes are the allowed host suffixes, any host is allowed when it's empty.
+	hostSuffixes []string
+}
+
+// discoveredEndpointPolicy only accepts HTTPS endpoints hosted on Azure or Microsoft domains, so a tampered or
+// corrupted discovery document cannot redirect telemetry elsewhere.
+var discoveredEndpointPolicy = endpointPolicy{
+	schemes: []string{"https"},
+	hostSuffixes: []string{
+		".azure.com",
+		".azure.net",
+		".azure-api.net",
+		".azurecontainerapps.io",
+		".azurefd.net",
+		".azurewebsites.net",
+		".windows.net",
+		".microsoft.com",
+	},
+}
+
+// configuredEndpointPolicy accepts any HTTP or HTTPS endpoint set in the configuration or environment variables,
+// e.g. an internal collector.
+var configuredEndpointPolicy = endpointPolicy{
+	schemes: []string{"http", "https"},
+}
+
+// invalidEndpointError tells why an endpoint is rejected, reason is a stable identifier for structured logs.
+type invalidEndpointError struct {
+	reason string
+	detail string
+}
+
+func (e *invalidEndpointError) Error() string {
+	return fmt.Sprintf("invalid endpoint (%s): %s", e.reason, e.detail)
+}
+
+// logFields returns the error as structured log fields.
+func (e *invalidEndpointError) logFields() map[string]interface{} {
+	return map[string]interface{}{
+		"reason": e.reason,
+		"detail": e.detail,
+	}
+}
+
+// validate sanitizes the raw endpoint, e.g. the discovery document, and returns the endpoint it contains, or an
+// *invalidEndpointError.
+func (p endpointPolicy) validate(raw string) (string, error) {
+	endpoint := strings.TrimSpace(strings.TrimPrefix(raw, "\ufeff"))
+	if endpoint == "" {
+		return "", &invalidEndpointError{reason: "empty", detail: "the endpoint is empty"}
+	}
+	if len(endpoint) > maxEndpointLength {
+		return "", &invalidEndpointError{reason: "too_long", detail: fmt.Sprintf("%d characters, the limit is %d", len(endpoint), maxEndpointLength)}
+	}
+	if strings.ContainsFunc(endpoint, func(r rune) bool {
+		return r < 0x20 || r == 0x7f || r == ' '
+	}) {
+		return "", &invalidEndpointError{reason: "invalid_character", detail: "the endpoint contains whitespace or control characters"}
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", &invalidEndpointError{reason: "unparseable", detail: err.Error()}
+	}
+	if !slices.Contains(p.schemes, strings.ToLower(u.Scheme)) {
+		return "", &invalidEndpointError{reason: "scheme", detail: fmt.Sprintf("scheme %q is not allowed", u.Scheme)}
+	}
+	if u.User != nil {
+		return "", &invalidEndpointError{reason: "credentials", detail: "the endpoint must not carry credentials"}
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return "", &invalidEndpointError{reason: "host", detail: "the endpoint has no host"}
+	}
+	if len(p.hostSuffixes) > 0 && !slices.ContainsFunc(p.hostSuffixes, func(suffix string) bool {
+		return strings.HasSuffix(host, suffix)
+	}) {
+		return "", &invalidEndpointError{reason: "host", detail: fmt.Sprintf("host %q is not allowed", host)}
+	}
+	return endpoint, nil
+}
+
+// configuredEndpoint validates an endpoint that isn't validated by the schema, e.g. the one set by an environment
+// variable. A malformed endpoint is discarded with a log so no telemetry is sent to garbage URLs.
+func configuredEndpoint(ctx context.Context, raw, source string) string {
+	endpoint, err := configuredEndpointPolicy.validate(raw)
+	var invalidErr *invalidEndpointError
+	if errors.As(err, &invalidErr) {
+		errorLog(ctx, fmt.Sprintf("Discarded malformed endpoint from %s, no telemetry will be sent to provider's endpoint", source), invalidErr.logFields())
+		return ""
+	}
+	return endpoint
+}
+
+// isInsecureEndpoint tells if events sent to the endpoint would travel unencrypted across the network, i.e. it's an
+// `http` endpoint whose host is neither localhost nor a loopback address.
+func isInsecureEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || !strings.EqualFold(u.Scheme, "http") {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
+}
+
+// insecureEndpointDiagnostics rejects an insecure endpoint unless `allow_insecure_endpoint` is set, the error is
+// attached to p unless it's empty, e.g. for an endpoint set by an environment variable.
+func insecureEndpointDiagnostics(p path.Path, endpoint, source string, allowInsecure bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if allowInsecure || !isInsecureEndpoint(endpoint) {
+		return diags
+	}
+	summary := "Insecure endpoint"
+	detail := fmt.Sprintf("The endpoint %q from %s is not an HTTPS URL, telemetry would be sent unencrypted. Use an `https` endpoint, or set `allow_insecure_endpoint` to `true` in the provider block to allow it.", endpoint, source)
+	if p.Equal(path.Empty()) {
+		diags.AddError(summary, detail)
+		return diags
+	}
+	diags.AddAttributeError(p, summary, detail)
+	return diags
+}
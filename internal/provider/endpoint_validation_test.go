@@ -0,0 +1,77 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointPolicy_validate(t *testing.T) {
+	endpoint, err := discoveredEndpointPolicy.validate("\ufeff https://avmtftelemetrysvc.azurewebsites.net/telemetry\r\n")
+	require.NoError(t, err)
+	assert.Equal(t, "https://avmtftelemetrysvc.azurewebsites.net/telemetry", endpoint)
+}
+
+func TestEndpointPolicy_validateRejects(t *testing.T) {
+	cases := map[string]string{
+		"": "empty",
+		"https://telemetry.azurewebsites.net/a b":  "invalid_character",
+		"https://telemetry.azurewebsites.net/\x00": "invalid_character",
+		"https://%zz":                             "unparseable",
+		"ftp://telemetry.azurewebsites.net":       "scheme",
+		"telemetry.azurewebsites.net":             "scheme",
+		"https://u:p@telemetry.azurewebsites.net": "credentials",
+		"https://":                              "host",
+		"https://telemetry.contoso.com":         "host",
+		"https://azurewebsites.net.contoso.com": "host",
+	}
+	for raw, reason := range cases {
+		t.Run(raw, func(t *testing.T) {
+			_, err := discoveredEndpointPolicy.validate(raw)
+			var invalidErr *invalidEndpointError
+			require.True(t, errors.As(err, &invalidErr))
+			assert.Equal(t, reason, invalidErr.reason)
+			assert.Equal(t, reason, invalidErr.logFields()["reason"])
+		})
+	}
+}
+
+func TestConfiguredEndpoint(t *testing.T) {
+	assert.Equal(t, "http://localhost:8080", configuredEndpoint(context.Background(), " http://localhost:8080\n", "MODTM_ENDPOINT environment variable"))
+	assert.Empty(t, configuredEndpoint(context.Background(), "localhost:8080", "MODTM_ENDPOINT environment variable"))
+}
+
+func TestIsInsecureEndpoint(t *testing.T) {
+	cases := map[string]bool{
+		"":                                  false,
+		"https://telemetry.contoso.com":     false,
+		"HTTP://telemetry.contoso.com":      true,
+		"http://telemetry.contoso.com":      true,
+		"http://10.0.0.4:8080/telemetry":    true,
+		"http://localhost:8080":             false,
+		"http://collector.localhost":        false,
+		"http://127.0.0.1:8080":             false,
+		"http://[::1]:8080":                 false,
+		"http://localhost.contoso.com:8080": true,
+	}
+	for endpoint, expected := range cases {
+		t.Run(endpoint, func(t *testing.T) {
+			assert.Equal(t, expected, isInsecureEndpoint(endpoint))
+		})
+	}
+}
+
+func TestInsecureEndpointDiagnostics(t *testing.T) {
+	diags := insecureEndpointDiagnostics(path.Root("endpoint"), "http://telemetry.contoso.com", "the provider block", false)
+	require.True(t, diags.HasError())
+	assert.Contains(t, diags[0].Detail(), "allow_insecure_endpoint")
+	assert.False(t, insecureEndpointDiagnostics(path.Root("endpoint"), "http://telemetry.contoso.com", "the provider block", true).HasError())
+	assert.False(t, insecureEndpointDiagnostics(path.Empty(), "https://telemetry.contoso.com", "MODTM_ENDPOINT environment variable", false).HasError())
+}
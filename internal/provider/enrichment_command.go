@@ -0,0 +1,67 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// enrichmentCommandTimeout is how long the enrichment command could run before it's killed.
+const enrichmentCommandTimeout = 5 * time.Second
+
+// enrichmentCommandStage runs the external enrichment command with the draft payload and merges the tags
+// it returns into the event. Existing tags are never overwritten. The event is still sent when the command
+// fails. It does nothing when command is empty.
+func enrichmentCommandStage(command []string) eventStage {
+	return eventStage{
+		name: stageEnrichmentCommand,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			if len(command) == 0 {
+				return true
+			}
+			additions, err := runEnrichmentCommand(ctx, command, e.tags)
+			if err != nil {
+				errorLog(ctx, fmt.Sprintf("error on enrichment command for %s telemetry event: %+v", e.name, err))
+				return true
+			}
+			for k, v := range additions {
+				if _, ok := e.tags[k]; ok {
+					traceLog(ctx, fmt.Sprintf("enrichment command cannot overwrite existing tag %s", k))
+					continue
+				}
+				e.tags[k] = v
+			}
+			return true
+		},
+	}
+}
+
+// runEnrichmentCommand executes command, writes tags to its stdin as a JSON object and reads the additional
+// tags from its stdout, which must be a JSON object of strings.
+func runEnrichmentCommand(ctx context.Context, command []string, tags map[string]string) (map[string]string, error) {
+	input, err := json.Marshal(tags)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, enrichmentCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...) // #nosec G204
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running %s: %w, stderr: %s", command[0], err, stderr.String())
+	}
+	additions := make(map[string]string)
+	if err = json.Unmarshal(stdout.Bytes(), &additions); err != nil {
+		return nil, fmt.Errorf("parsing output of %s, a JSON object of strings is expected: %w", command[0], err)
+	}
+	return additions, nil
+}
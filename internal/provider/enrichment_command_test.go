@@ -0,0 +1,71 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEnrichmentCommandHelperProcess isn't a real test, it's the enrichment command executed by other tests.
+func TestEnrichmentCommandHelperProcess(t *testing.T) {
+	mode := os.Getenv("MODTM_TEST_ENRICHMENT_HELPER")
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+	if mode == "invalid" {
+		fmt.Print("not json")
+		return
+	}
+	var tags map[string]string
+	if err := json.NewDecoder(os.Stdin).Decode(&tags); err != nil {
+		os.Exit(1)
+	}
+	output, _ := json.Marshal(map[string]string{
+		"cost_center":   "cc-" + tags["event"],
+		"module_source": "overwritten",
+	})
+	fmt.Print(string(output))
+}
+
+func enrichmentHelperCommand() []string {
+	return []string{os.Args[0], "-test.run=TestEnrichmentCommandHelperProcess"}
+}
+
+func TestEnrichmentCommandStage(t *testing.T) {
+	t.Setenv("MODTM_TEST_ENRICHMENT_HELPER", "valid")
+	e := &telemetryEvent{name: "create", tags: map[string]string{"event": "create", "module_source": "foo"}}
+	assert.True(t, enrichmentCommandStage(enrichmentHelperCommand()).process(context.Background(), e))
+	assert.Equal(t, map[string]string{
+		"event":         "create",
+		"module_source": "foo",
+		"cost_center":   "cc-create",
+	}, e.tags)
+}
+
+func TestEnrichmentCommandStage_failedCommandShouldNotDropEvent(t *testing.T) {
+	t.Setenv("MODTM_TEST_ENRICHMENT_HELPER", "invalid")
+	logger := &stubLogger{}
+	e := &telemetryEvent{name: "create", tags: map[string]string{"event": "create"}}
+	stage := enrichmentCommandStage(enrichmentHelperCommand())
+	ctx := context.Background()
+	stub := stubLoggers(logger)
+	defer stub.Reset()
+	assert.True(t, stage.process(ctx, e))
+	assert.Equal(t, map[string]string{"event": "create"}, e.tags)
+	assert.Len(t, logger.errors, 1)
+	assert.Contains(t, logger.errors[0], "error on enrichment command for create telemetry event")
+}
+
+func TestEnrichmentCommandStage_noCommand(t *testing.T) {
+	e := &telemetryEvent{name: "create", tags: map[string]string{"event": "create"}}
+	assert.True(t, enrichmentCommandStage(nil).process(context.Background(), e))
+	assert.Equal(t, map[string]string{"event": "create"}, e.tags)
+}
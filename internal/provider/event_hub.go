@@ -0,0 +1,150 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// EventHubModel describes provider's `event_hub` block.
+type EventHubModel struct {
+	ConnectionString types.String `tfsdk:"connection_string"`
+	Namespace        types.String `tfsdk:"namespace"`
+	Name             types.String `tfsdk:"name"`
+}
+
+const (
+	// eventHubResource is the AAD resource of Azure Event Hubs data plane.
+	eventHubResource = "https://eventhubs.azure.net"
+	// eventHubSasLifetime is how long the shared access signature of an event is valid.
+	eventHubSasLifetime = time.Hour
+)
+
+// newEventHubRoute returns a route that sends all events to the event hub described by m, authenticating with the
+// shared access key of the connection string, or with an AAD token when there's no connection string.
+func newEventHubRoute(m EventHubModel, client *http.Client, crypto cryptoPolicy) (eventRoute, error) {
+	c := &eventHubTelemetryClient{client: client, crypto: crypto}
+	namespace := m.Namespace.ValueString()
+	name := ""
+	if !m.ConnectionString.IsNull() {
+		for _, part := range strings.Split(m.ConnectionString.ValueString(), ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch strings.ToLower(k) {
+			case "endpoint":
+				namespace = v
+			case "sharedaccesskeyname":
+				c.keyName = v
+			case "sharedaccesskey":
+				c.key = []byte(v)
+			case "entitypath":
+				name = v
+			}
+		}
+		if c.keyName == "" || c.key == nil {
+			return eventRoute{}, errors.New("event hub connection string must contain `Endpoint`, `SharedAccessKeyName` and `SharedAccessKey`")
+		}
+	} else {
+		token, err := newAadTokenSource(eventHubResource, client)
+		if err != nil {
+			return eventRoute{}, err
+		}
+		c.token = token
+	}
+	if !m.Name.IsNull() {
+		name = m.Name.ValueString()
+	}
+	if namespace == "" || name == "" {
+		return eventRoute{}, errors.New("the namespace and the name of the event hub must be set, either by `connection_string` or by `namespace` and `name`")
+	}
+	host := namespace
+	if u, err := url.Parse(namespace); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	if !strings.Contains(host, ".") {
+		host += ".servicebus.windows.net"
+	}
+	return eventRoute{
+		endpoint:     fmt.Sprintf("https://%s/%s/messages", host, url.PathEscape(name)),
+		samplingRate: 1,
+		client:       c,
+	}, nil
+}
+
+var _ telemetryClient = &eventHubTelemetryClient{}
+
+// eventHubTelemetryClient sends every event as a JSON message through the REST API of Event Hubs.
+// See https://learn.microsoft.com/rest/api/eventhub/send-event.
+type eventHubTelemetryClient struct {
+	client  *http.Client
+	crypto  cryptoPolicy
+	keyName string
+	key     []byte
+	// token is the source of AAD tokens when there's no shared access key.
+	token *aadTokenSource
+}
+
+func (e *eventHubTelemetryClient) discoverEndpoint(ctx context.Context) (string, error) {
+	return "", errors.New("event hubs doesn't support endpoint discovery")
+}
+
+func (e *eventHubTelemetryClient) send(ctx context.Context, endpoint string, tags map[string]string) error {
+	body, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withSendTimeout(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/atom+xml;type=entry;charset=utf-8")
+	authorization, err := e.authorization(ctx, strings.TrimSuffix(endpoint, "/messages"))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("event hub responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// authorization returns a shared access signature for resourceUri when the client has a shared access key,
+// otherwise an AAD bearer token.
+func (e *eventHubTelemetryClient) authorization(ctx context.Context, resourceUri string) (string, error) {
+	if e.key == nil {
+		token, err := e.token.get(ctx)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	}
+	encodedUri := url.QueryEscape(resourceUri)
+	expiry := strconv.FormatInt(timeNow().Add(eventHubSasLifetime).Unix(), 10)
+	mac, err := e.crypto.newHMAC("sha256", e.key)
+	if err != nil {
+		return "", err
+	}
+	_, _ = mac.Write([]byte(encodedUri + "\n" + expiry))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return fmt.Sprintf("SharedAccessSignature sr=%s&sig=%s&se=%s&skn=%s", encodedUri, url.QueryEscape(signature), expiry, e.keyName), nil
+}
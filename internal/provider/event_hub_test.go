@@ -0,0 +1,175 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEventHubRoute(t *testing.T) {
+	cases := []struct {
+		desc             string
+		model            EventHubModel
+		expectedEndpoint string
+		expectedErr      string
+	}{
+		{
+			desc: "connection string with entity path",
+			model: EventHubModel{
+				ConnectionString: types.StringValue("Endpoint=sb://contoso.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=secret;EntityPath=telemetry"),
+			},
+			expectedEndpoint: "https://contoso.servicebus.windows.net/telemetry/messages",
+		},
+		{
+			desc: "name overrides entity path",
+			model: EventHubModel{
+				ConnectionString: types.StringValue("Endpoint=sb://contoso.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=secret;EntityPath=telemetry"),
+				Name:             types.StringValue("modtm"),
+			},
+			expectedEndpoint: "https://contoso.servicebus.windows.net/modtm/messages",
+		},
+		{
+			desc: "namespace and name with aad",
+			model: EventHubModel{
+				ConnectionString: types.StringNull(),
+				Namespace:        types.StringValue("contoso"),
+				Name:             types.StringValue("telemetry"),
+			},
+			expectedEndpoint: "https://contoso.servicebus.windows.net/telemetry/messages",
+		},
+		{
+			desc: "connection string without key",
+			model: EventHubModel{
+				ConnectionString: types.StringValue("Endpoint=sb://contoso.servicebus.windows.net/;EntityPath=telemetry"),
+			},
+			expectedErr: "SharedAccessKey",
+		},
+		{
+			desc: "missing name",
+			model: EventHubModel{
+				ConnectionString: types.StringNull(),
+				Namespace:        types.StringValue("contoso.servicebus.windows.net"),
+				Name:             types.StringNull(),
+			},
+			expectedErr: "name",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			route, err := newEventHubRoute(c.model, http.DefaultClient, cryptoPolicy{})
+			if c.expectedErr != "" {
+				assert.ErrorContains(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedEndpoint, route.endpoint)
+			assert.NotNil(t, route.client)
+		})
+	}
+}
+
+func TestEventHubTelemetryClient_sendWithSharedAccessKey(t *testing.T) {
+	var body map[string]string
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		authorization = request.Header.Get("Authorization")
+		data, _ := io.ReadAll(request.Body)
+		_ = json.Unmarshal(data, &body)
+		writer.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return time.Unix(1700000000, 0)
+	})
+	defer stub.Reset()
+
+	client := &eventHubTelemetryClient{client: server.Client(), keyName: "send", key: []byte("secret")}
+	err := client.send(context.Background(), server.URL+"/telemetry/messages", map[string]string{"event": "create"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"event": "create"}, body)
+	assert.Regexp(t, `^SharedAccessSignature sr=http%3A%2F%2F127\.0\.0\.1%3A\d+%2Ftelemetry&sig=[^&]+&se=1700003600&skn=send$`, authorization)
+}
+
+func TestEventHubTelemetryClient_sendWithAadToken(t *testing.T) {
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		authorization = append(authorization, request.Header.Get("Authorization"))
+		writer.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	credential := &fakeTokenCredential{ttl: time.Hour}
+
+	client := &eventHubTelemetryClient{client: server.Client(), token: &aadTokenSource{credential: credential, scope: eventHubResource + "/.default"}}
+	require.NoError(t, client.send(context.Background(), server.URL+"/telemetry/messages", map[string]string{"event": "create"}))
+	require.NoError(t, client.send(context.Background(), server.URL+"/telemetry/messages", map[string]string{"event": "read"}))
+	assert.Equal(t, []string{"Bearer token", "Bearer token"}, authorization)
+	assert.Equal(t, [][]string{{"https://eventhubs.azure.net/.default"}}, credential.scopes)
+}
+
+func TestEventHubTelemetryClient_sendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := &eventHubTelemetryClient{client: server.Client(), keyName: "send", key: []byte("secret")}
+	assert.ErrorContains(t, client.send(context.Background(), server.URL+"/telemetry/messages", map[string]string{"event": "create"}), "401")
+}
+
+func TestAccEventHub_mockEndpoint(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var body map[string]string
+		data, _ := io.ReadAll(request.Body)
+		_ = json.Unmarshal(data, &body)
+		mu.Lock()
+		events = append(events, request.URL.Path+" "+body["event"])
+		mu.Unlock()
+		writer.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(&fakeTelemetryClient{}),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  module_source_regex  = ["foo"]
+  insecure_skip_verify = true
+  event_hub = {
+    connection_string = "Endpoint=sb://%s/;SharedAccessKeyName=send;SharedAccessKey=secret;EntityPath=telemetry"
+  }
+}
+
+resource "modtm_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+  }
+}
+`, server.Listener.Addr().String()),
+			},
+		},
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, events, "/telemetry/messages create")
+	assert.Contains(t, events, "/telemetry/messages delete")
+}
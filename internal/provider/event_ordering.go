@@ -0,0 +1,69 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	timestampFormatRFC3339 = "rfc3339"
+	timestampFormatUnix    = "unix"
+
+	timestampPrecisionSecond      = "s"
+	timestampPrecisionMillisecond = "ms"
+	timestampPrecisionMicrosecond = "us"
+	timestampPrecisionNanosecond  = "ns"
+)
+
+var timestampFormats = []string{timestampFormatRFC3339, timestampFormatUnix}
+
+var timestampPrecisions = []string{
+	timestampPrecisionSecond,
+	timestampPrecisionMillisecond,
+	timestampPrecisionMicrosecond,
+	timestampPrecisionNanosecond,
+}
+
+// timeNow is a variable so tests could stub the clock.
+var timeNow = time.Now
+
+// eventSequence hands out monotonic sequence numbers to the events of one provider instance, starting from 1.
+type eventSequence struct {
+	n atomic.Uint64
+}
+
+func (s *eventSequence) next() uint64 {
+	return s.n.Add(1)
+}
+
+// formatTimestamp formats t in UTC with the given format and precision, unknown values fall back to
+// `rfc3339` and `ms`.
+func formatTimestamp(t time.Time, format, precision string) string {
+	t = t.UTC()
+	if format == timestampFormatUnix {
+		switch precision {
+		case timestampPrecisionSecond:
+			return strconv.FormatInt(t.Unix(), 10)
+		case timestampPrecisionMicrosecond:
+			return strconv.FormatInt(t.UnixMicro(), 10)
+		case timestampPrecisionNanosecond:
+			return strconv.FormatInt(t.UnixNano(), 10)
+		default:
+			return strconv.FormatInt(t.UnixMilli(), 10)
+		}
+	}
+	switch precision {
+	case timestampPrecisionSecond:
+		return t.Format("2006-01-02T15:04:05Z07:00")
+	case timestampPrecisionMicrosecond:
+		return t.Format("2006-01-02T15:04:05.000000Z07:00")
+	case timestampPrecisionNanosecond:
+		return t.Format("2006-01-02T15:04:05.000000000Z07:00")
+	default:
+		return t.Format("2006-01-02T15:04:05.000Z07:00")
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatTimestamp(t *testing.T) {
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.FixedZone("UTC+8", 8*3600))
+	cases := []struct {
+		format    string
+		precision string
+		expected  string
+	}{
+		{format: timestampFormatRFC3339, precision: timestampPrecisionSecond, expected: "2024-05-05T23:08:09Z"},
+		{format: timestampFormatRFC3339, precision: timestampPrecisionMillisecond, expected: "2024-05-05T23:08:09.123Z"},
+		{format: timestampFormatRFC3339, precision: timestampPrecisionMicrosecond, expected: "2024-05-05T23:08:09.123456Z"},
+		{format: timestampFormatRFC3339, precision: timestampPrecisionNanosecond, expected: "2024-05-05T23:08:09.123456789Z"},
+		{format: "", precision: "", expected: "2024-05-05T23:08:09.123Z"},
+		{format: timestampFormatUnix, precision: timestampPrecisionSecond, expected: "1714950489"},
+		{format: timestampFormatUnix, precision: timestampPrecisionMillisecond, expected: "1714950489123"},
+		{format: timestampFormatUnix, precision: timestampPrecisionMicrosecond, expected: "1714950489123456"},
+		{format: timestampFormatUnix, precision: timestampPrecisionNanosecond, expected: "1714950489123456789"},
+	}
+	for _, c := range cases {
+		t.Run(c.format+"_"+c.precision, func(t *testing.T) {
+			assert.Equal(t, c.expected, formatTimestamp(ts, c.format, c.precision))
+		})
+	}
+}
+
+func TestEventSequence_isMonotonicAcrossGoroutines(t *testing.T) {
+	s := &eventSequence{}
+	seen := make([]bool, 101)
+	var mu sync.Mutex
+	wg := sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := s.next()
+			mu.Lock()
+			defer mu.Unlock()
+			seen[n] = true
+		}()
+	}
+	wg.Wait()
+	assert.NotContains(t, seen[1:], false)
+	assert.Equal(t, uint64(101), s.next())
+}
@@ -0,0 +1,198 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// telemetryEvent is a telemetry event flowing through the event pipeline, tags is the payload that will be sent.
+type telemetryEvent struct {
+	name string
+	tags map[string]string
+	// highPriority events are kept when low priority events are shed under pressure.
+	highPriority bool
+	// truncatedTags are the keys of the tags whose values have been truncated to fit the payload size limit.
+	truncatedTags []string
+}
+
+// defaultHighPriorityEvents are the lifecycle events that are high priority unless `high_priority_events` is set.
+var defaultHighPriorityEvents = []string{"create", "update", "delete"}
+
+// eventStage is a named step of the event pipeline, it could enrich, transform or filter the event.
+// process returns false to drop the event.
+type eventStage struct {
+	name    string
+	process func(ctx context.Context, e *telemetryEvent) bool
+}
+
+// eventPipeline is an ordered list of stages that every telemetry event goes through before it's sent.
+type eventPipeline []eventStage
+
+const (
+	stageTerraformTest        = "terraform_test"
+	stageModuleSourceFilter   = "module_source_filter"
+	stageReadDedupe           = "read_dedupe"
+	stageEnrichmentCommand    = "enrichment_command"
+	stageBackendId            = "backend_id"
+	stageSampling             = "sampling"
+	stageGitTimestamp         = "normalize_git_timestamp"
+	stageExecutionEnvironment = "execution_environment"
+	stageRuntimeMetadata      = "runtime_metadata"
+	stageThrottle             = "throttle"
+	stageHashTags             = "hash_tags"
+	stageRedact               = "redact"
+	stagePayloadSize          = "payload_size"
+	stageAzureEnvironment     = "azure_environment"
+	stageAzureContext         = "azure_context"
+
+	// terraformTestTag marks the events sent while the provider is launched by `terraform test`.
+	terraformTestTag = "terraform_test"
+)
+
+// eventStageNames lists the names of all stages, in the order they run.
+var eventStageNames = []string{
+	stageTerraformTest,
+	stageModuleSourceFilter,
+	stageReadDedupe,
+	stageSampling,
+	stageBackendId,
+	stageExecutionEnvironment,
+	stageRuntimeMetadata,
+	stageAzureEnvironment,
+	stageAzureContext,
+	stageGitTimestamp,
+	stageEnrichmentCommand,
+	stageRedact,
+	stageHashTags,
+	stageThrottle,
+	stagePayloadSize,
+}
+
+// requiredEventStages are the stages enforcing the module source allow and deny lists and the privacy settings,
+// they can't be disabled by `disabled_event_stages`.
+var requiredEventStages = []string{
+	stageModuleSourceFilter,
+	stageRedact,
+	stageHashTags,
+}
+
+// optionalEventStageNames lists the names of the stages that could be disabled, in the order they run.
+var optionalEventStageNames = slices.DeleteFunc(slices.Clone(eventStageNames), func(name string) bool {
+	return slices.Contains(requiredEventStages, name)
+})
+
+// disabledEventStagesDiagnostics rejects the required stages in `disabled_event_stages`.
+func disabledEventStagesDiagnostics(disabledStages []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for i, name := range disabledStages {
+		if slices.Contains(requiredEventStages, name) {
+			diags.AddAttributeError(path.Root("disabled_event_stages").AtListIndex(i), "Required event stage", fmt.Sprintf("The %q stage can't be disabled, it enforces the module source filters and the privacy settings of the provider. Required stages: %s.", name, markdownCodeList(requiredEventStages)))
+		}
+	}
+	return diags
+}
+
+// newEventPipeline builds the pipeline from provider configuration, stages whose names are in disabledStages are left
+// out, except for the required stages which always run.
+func newEventPipeline(c providerConfig, disabledStages []string) eventPipeline {
+	stages := []eventStage{
+		terraformTestStage(c.terraformTest, c.skipOnTerraformTest),
+		moduleSourceFilterStage(c.moduleSourceRegex, c.moduleSourceDenyRegex),
+		readDedupeStage(newReadDedupe()),
+		samplingStage(c.samplingRules),
+		backendIdStage(c.backendId),
+		executionEnvironmentStage(c.executionEnvironment),
+		runtimeMetadataStage(c.runtimeMetadata),
+		azureEnvironmentStage(c.includeAzureEnvironment),
+		azureContextStage(c.collectAzureContext, !c.offline, c.crypto),
+		gitTimestampStage(c.normalizeGitTimestamp),
+		enrichmentCommandStage(c.enrichmentCommand),
+		redactStage(c.redactPatterns),
+		hashTagsStage(c.hashTags, c.hashTagsSalt, c.crypto),
+		throttleStage(c.throttle),
+		payloadSizeStage(c.maxPayloadSize),
+	}
+	var pipeline eventPipeline
+	for _, stage := range stages {
+		if slices.Contains(disabledStages, stage.name) && !slices.Contains(requiredEventStages, stage.name) {
+			continue
+		}
+		pipeline = append(pipeline, stage)
+	}
+	return pipeline
+}
+
+// requiredEventPipeline builds the pipeline of the required stages only, for the events that are not sent by
+// resources, e.g. the events of provider functions.
+func requiredEventPipeline(c providerConfig) eventPipeline {
+	return eventPipeline{
+		moduleSourceFilterStage(c.moduleSourceRegex, c.moduleSourceDenyRegex),
+		redactStage(c.redactPatterns),
+		hashTagsStage(c.hashTags, c.hashTagsSalt, c.crypto),
+	}
+}
+
+// run passes the event through all stages in order, it returns false if the event has been dropped by any stage.
+func (p eventPipeline) run(ctx context.Context, e *telemetryEvent) bool {
+	for _, stage := range p {
+		if !stage.process(ctx, e) {
+			traceLog(ctx, fmt.Sprintf("%s telemetry event dropped by stage %s", e.name, stage.name))
+			return false
+		}
+	}
+	return true
+}
+
+// terraformTestStage tags the event with `terraform_test` when the provider is launched by `terraform test`,
+// or drops it when skip is true.
+func terraformTestStage(terraformTest, skip bool) eventStage {
+	return eventStage{
+		name: stageTerraformTest,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			if !terraformTest {
+				return true
+			}
+			if skip {
+				return false
+			}
+			e.tags[terraformTestTag] = "true"
+			return true
+		},
+	}
+}
+
+// moduleSourceFilterStage drops the event unless its `module_source` tag, either set explicitly or resolved from
+// `module_path`, matches one of the allow list regexes and none of the deny list regexes.
+func moduleSourceFilterStage(moduleSourceRegex, moduleSourceDenyRegex []*regexp.Regexp) eventStage {
+	return eventStage{
+		name: stageModuleSourceFilter,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			src, ok := e.tags["module_source"]
+			if !ok {
+				traceLog(ctx, fmt.Sprintf("skip %s telemetry event: no module_source tag", e.name))
+				return false
+			}
+			for _, regex := range moduleSourceDenyRegex {
+				if regex.MatchString(src) {
+					traceLog(ctx, fmt.Sprintf("skip %s telemetry event: module source %q matches module_source_deny_regex %q", e.name, src, regex.String()))
+					return false
+				}
+			}
+			for _, regex := range moduleSourceRegex {
+				if regex.MatchString(src) {
+					return true
+				}
+			}
+			traceLog(ctx, fmt.Sprintf("skip %s telemetry event: module source %q matches none of module_source_regex", e.name, src))
+			return false
+		},
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventPipeline(t *testing.T) {
+	allowFoo := []*regexp.Regexp{regexp.MustCompile("foo")}
+	cases := []struct {
+		desc           string
+		config         providerConfig
+		disabledStages []string
+		tags           map[string]string
+		expectedSent   bool
+		expectedTags   map[string]string
+	}{
+		{
+			desc:         "matched_module_source",
+			config:       providerConfig{moduleSourceRegex: allowFoo},
+			tags:         map[string]string{"module_source": "foo"},
+			expectedSent: true,
+			expectedTags: map[string]string{"module_source": "foo"},
+		},
+		{
+			desc:         "unmatched_module_source",
+			config:       providerConfig{moduleSourceRegex: allowFoo},
+			tags:         map[string]string{"module_source": "bar"},
+			expectedSent: false,
+		},
+		{
+			desc:         "missing_module_source",
+			config:       providerConfig{moduleSourceRegex: allowFoo},
+			tags:         map[string]string{},
+			expectedSent: false,
+		},
+		{
+			desc:         "denied_module_source",
+			config:       providerConfig{moduleSourceRegex: allowFoo, moduleSourceDenyRegex: []*regexp.Regexp{regexp.MustCompile("^git::ssh://internal")}},
+			tags:         map[string]string{"module_source": "git::ssh://internal/foo"},
+			expectedSent: false,
+		},
+		{
+			desc:         "not_denied_module_source",
+			config:       providerConfig{moduleSourceRegex: allowFoo, moduleSourceDenyRegex: []*regexp.Regexp{regexp.MustCompile("^git::ssh://internal")}},
+			tags:         map[string]string{"module_source": "registry.terraform.io/foo"},
+			expectedSent: true,
+			expectedTags: map[string]string{"module_source": "registry.terraform.io/foo"},
+		},
+		{
+			desc:           "module_source_filter_cannot_be_disabled",
+			config:         providerConfig{moduleSourceRegex: allowFoo},
+			disabledStages: []string{stageModuleSourceFilter},
+			tags:           map[string]string{"module_source": "bar"},
+			expectedSent:   false,
+		},
+		{
+			desc:           "redact_cannot_be_disabled",
+			config:         providerConfig{moduleSourceRegex: allowFoo, redactPatterns: []*regexp.Regexp{regexp.MustCompile("secret")}},
+			disabledStages: []string{stageRedact},
+			tags:           map[string]string{"module_source": "foo", "note": "secret"},
+			expectedSent:   true,
+			expectedTags:   map[string]string{"module_source": "foo", "note": "[REDACTED]"},
+		},
+		{
+			desc:         "terraform_test_tagged",
+			config:       providerConfig{moduleSourceRegex: allowFoo, terraformTest: true},
+			tags:         map[string]string{"module_source": "foo"},
+			expectedSent: true,
+			expectedTags: map[string]string{"module_source": "foo", "terraform_test": "true"},
+		},
+		{
+			desc:         "terraform_test_skipped",
+			config:       providerConfig{moduleSourceRegex: allowFoo, terraformTest: true, skipOnTerraformTest: true},
+			tags:         map[string]string{"module_source": "foo"},
+			expectedSent: false,
+		},
+		{
+			desc:           "disabled_terraform_test",
+			config:         providerConfig{moduleSourceRegex: allowFoo, terraformTest: true, skipOnTerraformTest: true},
+			disabledStages: []string{stageTerraformTest},
+			tags:           map[string]string{"module_source": "foo"},
+			expectedSent:   true,
+			expectedTags:   map[string]string{"module_source": "foo"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			pipeline := newEventPipeline(c.config, c.disabledStages)
+			e := &telemetryEvent{name: "create", tags: c.tags}
+			sent := pipeline.run(context.Background(), e)
+			assert.Equal(t, c.expectedSent, sent)
+			if c.expectedSent {
+				assert.Equal(t, c.expectedTags, e.tags)
+			}
+		})
+	}
+}
+
+func TestDisabledEventStagesDiagnostics(t *testing.T) {
+	diags := disabledEventStagesDiagnostics([]string{stageReadDedupe, stageModuleSourceFilter, stageHashTags})
+	assert.Equal(t, 2, diags.ErrorsCount())
+	assert.Contains(t, diags[0].Detail(), stageModuleSourceFilter)
+	assert.Contains(t, diags[1].Detail(), stageHashTags)
+	assert.False(t, disabledEventStagesDiagnostics(optionalEventStageNames).HasError())
+}
+
+func TestNewEventPipeline_stagesRunInDeclaredOrder(t *testing.T) {
+	pipeline := newEventPipeline(providerConfig{}, nil)
+	var names []string
+	for _, stage := range pipeline {
+		names = append(names, stage.name)
+	}
+	assert.Equal(t, eventStageNames, names)
+}
@@ -0,0 +1,59 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+const (
+	executionEnvironmentCloudShell            = "cloud_shell"
+	executionEnvironmentAzureDevOpsHosted     = "azure_devops_hosted"
+	executionEnvironmentAzureDevOpsSelfHosted = "azure_devops_self_hosted"
+	executionEnvironmentGitHubHosted          = "github_hosted"
+	executionEnvironmentGitHubSelfHosted      = "github_self_hosted"
+	// executionEnvironmentCI is any other CI system that follows the `CI=true` convention.
+	executionEnvironmentCI = "ci"
+	// executionEnvironmentOther is usually a developer workstation.
+	executionEnvironmentOther = "other"
+)
+
+// detectExecutionEnvironment tells where the provider runs from the well-known environment variables set by Azure
+// Cloud Shell, Azure Pipelines agents and GitHub Actions runners.
+var detectExecutionEnvironment = func() string {
+	switch {
+	case os.Getenv("ACC_CLOUD") != "" || strings.HasPrefix(strings.ToLower(os.Getenv("AZUREPS_HOST_ENVIRONMENT")), "cloud-shell"):
+		return executionEnvironmentCloudShell
+	case strings.EqualFold(os.Getenv("TF_BUILD"), "true"):
+		// AGENT_ISSELFHOSTED is `0` on Microsoft-hosted agents and `1` on self-hosted ones.
+		if os.Getenv("AGENT_ISSELFHOSTED") == "0" {
+			return executionEnvironmentAzureDevOpsHosted
+		}
+		return executionEnvironmentAzureDevOpsSelfHosted
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		if os.Getenv("RUNNER_ENVIRONMENT") == "github-hosted" {
+			return executionEnvironmentGitHubHosted
+		}
+		return executionEnvironmentGitHubSelfHosted
+	case strings.EqualFold(os.Getenv("CI"), "true"):
+		return executionEnvironmentCI
+	default:
+		return executionEnvironmentOther
+	}
+}
+
+// executionEnvironmentStage tags the event with `execution_environment`, unless the tag is already set.
+func executionEnvironmentStage(environment string) eventStage {
+	return eventStage{
+		name: stageExecutionEnvironment,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			if _, ok := e.tags["execution_environment"]; !ok && environment != "" {
+				e.tags["execution_environment"] = environment
+			}
+			return true
+		},
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectExecutionEnvironment(t *testing.T) {
+	cases := []struct {
+		desc     string
+		env      map[string]string
+		expected string
+	}{
+		{desc: "cloud shell", env: map[string]string{"ACC_CLOUD": "PROD"}, expected: executionEnvironmentCloudShell},
+		{desc: "cloud shell by host environment", env: map[string]string{"AZUREPS_HOST_ENVIRONMENT": "cloud-shell/1.0"}, expected: executionEnvironmentCloudShell},
+		{desc: "azure devops hosted", env: map[string]string{"TF_BUILD": "True", "AGENT_ISSELFHOSTED": "0"}, expected: executionEnvironmentAzureDevOpsHosted},
+		{desc: "azure devops self hosted", env: map[string]string{"TF_BUILD": "True", "AGENT_ISSELFHOSTED": "1"}, expected: executionEnvironmentAzureDevOpsSelfHosted},
+		{desc: "github hosted", env: map[string]string{"GITHUB_ACTIONS": "true", "RUNNER_ENVIRONMENT": "github-hosted"}, expected: executionEnvironmentGitHubHosted},
+		{desc: "github self hosted", env: map[string]string{"GITHUB_ACTIONS": "true", "RUNNER_ENVIRONMENT": "self-hosted"}, expected: executionEnvironmentGitHubSelfHosted},
+		{desc: "other ci", env: map[string]string{"CI": "true"}, expected: executionEnvironmentCI},
+		{desc: "workstation", expected: executionEnvironmentOther},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			for _, name := range []string{"ACC_CLOUD", "AZUREPS_HOST_ENVIRONMENT", "TF_BUILD", "AGENT_ISSELFHOSTED", "GITHUB_ACTIONS", "RUNNER_ENVIRONMENT", "CI"} {
+				t.Setenv(name, c.env[name])
+			}
+			assert.Equal(t, c.expected, detectExecutionEnvironment())
+		})
+	}
+}
+
+func TestExecutionEnvironmentStage(t *testing.T) {
+	e := &telemetryEvent{tags: map[string]string{}}
+	assert.True(t, executionEnvironmentStage(executionEnvironmentGitHubHosted).process(context.Background(), e))
+	assert.Equal(t, executionEnvironmentGitHubHosted, e.tags["execution_environment"])
+
+	e = &telemetryEvent{tags: map[string]string{"execution_environment": "custom"}}
+	assert.True(t, executionEnvironmentStage(executionEnvironmentGitHubHosted).process(context.Background(), e))
+	assert.Equal(t, "custom", e.tags["execution_environment"])
+}
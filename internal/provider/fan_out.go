@@ -0,0 +1,129 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// fanOutEndpoints returns the distinct non-empty endpoints of the lists, in order.
+func fanOutEndpoints(lists ...[]string) []string {
+	var endpoints []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, endpoint := range list {
+			if endpoint == "" || seen[endpoint] {
+				continue
+			}
+			seen[endpoint] = true
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// deliveryTarget is an endpoint that an event is sent to, and the endpoints that the event is retried against in
+// order when the endpoint cannot be reached.
+type deliveryTarget struct {
+	endpoint  string
+	fallbacks []string
+}
+
+// deliveryTargets returns the targets of the endpoints, the fallbacks only apply to the first endpoint.
+func deliveryTargets(endpoints, fallbacks []string) []deliveryTarget {
+	targets := make([]deliveryTarget, 0, len(endpoints))
+	for i, endpoint := range endpoints {
+		target := deliveryTarget{endpoint: endpoint}
+		if i == 0 {
+			target.fallbacks = fallbacks
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// fanOut sends the event to all targets in parallel. Every send waits for the send limiter and is limited by its own
+// request timeout, so a slow endpoint doesn't hold up the others. A failed delete event is spooled for the endpoint
+// it failed to reach. It returns the outcome of the delivery to all targets, or nil if the event hasn't been sent to
+// any target.
+func (res *TelemetryResource) fanOut(ctx context.Context, e *telemetryEvent, targets []deliveryTarget, timeout time.Duration) *deliveryAttempt {
+	attempts := make([]*deliveryAttempt, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempts[i] = res.sendTo(ctx, e, target, timeout)
+		}()
+	}
+	wg.Wait()
+	var result *deliveryAttempt
+	var errs []error
+	for _, attempt := range attempts {
+		if attempt == nil {
+			continue
+		}
+		if result == nil {
+			result = &deliveryAttempt{truncatedTags: e.truncatedTags}
+		}
+		if attempt.err != nil {
+			errs = append(errs, attempt.err)
+		}
+		result.queued = result.queued || attempt.queued
+	}
+	if result != nil {
+		result.err = errors.Join(errs...)
+	}
+	return result
+}
+
+// sendTo sends the event to the target's endpoint, then to its fallbacks in order until one of them accepts the
+// event, every endpoint gets its own request timeout. When the client queues events, the event is only queued for the
+// target's endpoint, and the fallbacks and the spool apply once its delivery has failed in the background. It returns
+// nil if the event is skipped by the send limiter.
+func (res *TelemetryResource) sendTo(ctx context.Context, e *telemetryEvent, target deliveryTarget, timeout time.Duration) *deliveryAttempt {
+	if err := res.sendLimiter.acquire(ctx, e.highPriority); err != nil {
+		traceLog(ctx, fmt.Sprintf("skip %s telemetry event to %s: %s", e.name, target.endpoint, err.Error()))
+		return nil
+	}
+	defer res.sendLimiter.release()
+	if q, ok := res.client.(queuingClient); ok {
+		tags := maps.Clone(e.tags)
+		sendCtx, cancel := withRequestTimeout(ctx, timeout)
+		err := q.enqueue(sendCtx, target.endpoint, tags, func(ctx context.Context, client telemetryClient, _ error) {
+			_ = res.deliver(ctx, client, &telemetryEvent{name: e.name, tags: tags}, target.endpoint, target.fallbacks, timeout)
+		})
+		cancel()
+		return &deliveryAttempt{err: err, queued: err == nil}
+	}
+	return &deliveryAttempt{err: res.deliver(ctx, res.client, e, target.endpoint, append([]string{target.endpoint}, target.fallbacks...), timeout)}
+}
+
+// deliver sends the event to the endpoints in order until one of them accepts it, every endpoint gets its own request
+// timeout. A delete event that no endpoint accepts is spooled for spoolEndpoint. It returns the last send error.
+func (res *TelemetryResource) deliver(ctx context.Context, client telemetryClient, e *telemetryEvent, spoolEndpoint string, endpoints []string, timeout time.Duration) error {
+	var err error
+	for _, endpoint := range endpoints {
+		sendCtx, cancel := withRequestTimeout(ctx, timeout)
+		err = client.send(sendCtx, endpoint, e.tags)
+		cancel()
+		if err == nil {
+			traceLog(ctx, fmt.Sprintf("sent %s telemetry event to %s", e.name, endpoint))
+			return nil
+		}
+		errorLog(ctx, fmt.Sprintf("error on sending %s telemetry event to %s: %+v", e.name, endpoint, err))
+	}
+	if e.name == "delete" {
+		// The delete event is the last chance to hear from the resource, keep it for the next run.
+		if spoolErr := res.spool.write(spoolEndpoint, e.tags); spoolErr != nil {
+			errorLog(ctx, fmt.Sprintf("error on spooling %s telemetry event: %+v", e.name, spoolErr))
+		}
+	}
+	return err
+}
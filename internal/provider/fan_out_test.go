@@ -0,0 +1,220 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingEndpointClient never responds to the events sent to its endpoint.
+type hangingEndpointClient struct {
+	fakeTelemetryClient
+	hanging string
+}
+
+func (h *hangingEndpointClient) send(ctx context.Context, endpoint string, tags map[string]string) error {
+	if endpoint == h.hanging {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return h.fakeTelemetryClient.send(ctx, endpoint, tags)
+}
+
+func TestFanOutEndpoints(t *testing.T) {
+	assert.Equal(t, []string{"https://a.contoso.com", "https://b.contoso.com", "https://c.contoso.com"},
+		fanOutEndpoints([]string{"https://a.contoso.com"}, []string{"", "https://b.contoso.com", "https://a.contoso.com"}, []string{"https://c.contoso.com"}))
+	assert.Empty(t, fanOutEndpoints([]string{""}, nil))
+}
+
+func TestSendEvent_fanOutToProviderEndpoints(t *testing.T) {
+	client := &fakeTelemetryClient{}
+	res := &TelemetryResource{
+		providerEndpointFunc: func() string {
+			return "https://provider.contoso.com"
+		},
+		enabled:   true,
+		endpoints: []string{"https://internal.contoso.com"},
+		pipeline:  eventPipeline{moduleSourceFilterStage([]*regexp.Regexp{regexp.MustCompile("foo")}, nil)},
+		sequence:  &eventSequence{},
+		client:    client,
+	}
+	attempt := res.sendEvent(context.Background(), "create", "00000000-0000-0000-0000-000000000000", map[string]string{"module_source": "foo"}, "", "")
+	require.NotNil(t, attempt)
+	assert.NoError(t, attempt.err)
+	var endpoints []string
+	for _, e := range client.sentEvents() {
+		endpoints = append(endpoints, e.endpoint)
+	}
+	assert.ElementsMatch(t, []string{"https://provider.contoso.com", "https://internal.contoso.com"}, endpoints)
+}
+
+func TestSendTags_resourceEndpointsAreIgnoredWhenProviderEndpointIsExplicit(t *testing.T) {
+	for _, defaultEndpoint := range []bool{true, false} {
+		client := &fakeTelemetryClient{}
+		res := &TelemetryResource{
+			providerEndpointFunc: func() string {
+				return "https://provider.contoso.com"
+			},
+			enabled:                        true,
+			defaultEndpointOnProviderBlock: defaultEndpoint,
+			pipeline:                       eventPipeline{moduleSourceFilterStage([]*regexp.Regexp{regexp.MustCompile("foo")}, nil)},
+			sequence:                       &eventSequence{},
+			client:                         client,
+		}
+		model := &TelemetryResourceModel{
+			Id:        types.StringValue("00000000-0000-0000-0000-000000000000"),
+			Tags:      types.MapValueMust(types.StringType, map[string]attr.Value{"module_source": types.StringValue("foo")}),
+			Endpoints: types.ListValueMust(types.StringType, []attr.Value{types.StringValue("https://internal.contoso.com")}),
+		}
+		require.NotNil(t, model.sendTags(context.Background(), res, "create", nil))
+		var endpoints []string
+		for _, e := range client.sentEvents() {
+			endpoints = append(endpoints, e.endpoint)
+		}
+		if defaultEndpoint {
+			assert.ElementsMatch(t, []string{"https://provider.contoso.com", "https://internal.contoso.com"}, endpoints)
+		} else {
+			assert.Equal(t, []string{"https://provider.contoso.com"}, endpoints)
+		}
+	}
+}
+
+func TestFanOut_slowEndpointDoesNotHoldUpOthers(t *testing.T) {
+	client := &hangingEndpointClient{hanging: "https://hanging.contoso.com"}
+	res := &TelemetryResource{client: client}
+	e := &telemetryEvent{name: "create", tags: map[string]string{"event": "create"}}
+	started := time.Now()
+	attempt := res.fanOut(context.Background(), e, deliveryTargets([]string{"https://hanging.contoso.com", "https://a.contoso.com", "https://b.contoso.com"}, nil), 100*time.Millisecond)
+	assert.Less(t, time.Since(started), time.Second)
+	require.NotNil(t, attempt)
+	assert.ErrorIs(t, attempt.err, context.DeadlineExceeded)
+	assert.Len(t, client.sentEvents(), 2)
+}
+
+func TestFanOut_noEndpoint(t *testing.T) {
+	res := &TelemetryResource{client: &fakeTelemetryClient{}}
+	assert.Nil(t, res.fanOut(context.Background(), &telemetryEvent{name: "create", tags: map[string]string{}}, nil, time.Second))
+}
+
+func TestFanOut_failsOverToFallbackEndpoints(t *testing.T) {
+	client := &hangingEndpointClient{hanging: "https://primary.contoso.com"}
+	res := &TelemetryResource{client: client}
+	e := &telemetryEvent{name: "create", tags: map[string]string{"event": "create"}}
+	attempt := res.fanOut(context.Background(), e, deliveryTargets([]string{"https://primary.contoso.com"}, []string{"https://secondary.contoso.com", "https://tertiary.contoso.com"}), 50*time.Millisecond)
+	require.NotNil(t, attempt)
+	assert.NoError(t, attempt.err)
+	sent := client.sentEvents()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "https://secondary.contoso.com", sent[0].endpoint)
+}
+
+func TestFanOut_spoolsDeleteEventForPrimaryEndpointWhenAllFallbacksFail(t *testing.T) {
+	client := &fakeTelemetryClient{sendErr: errors.New("unavailable")}
+	spoolDir := t.TempDir()
+	res := &TelemetryResource{client: client, spool: newEventSpool(spoolDir)}
+	e := &telemetryEvent{name: "delete", tags: map[string]string{"event": "delete"}}
+	attempt := res.fanOut(context.Background(), e, deliveryTargets([]string{"https://primary.contoso.com"}, []string{"https://secondary.contoso.com"}), time.Second)
+	require.NotNil(t, attempt)
+	assert.Error(t, attempt.err)
+	assert.Len(t, client.sentEvents(), 2)
+	entries, err := os.ReadDir(spoolDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFanOut_queuingClientAppliesFallbacksAndSpoolToDeliveryOutcome(t *testing.T) {
+	next := &hangingEndpointClient{hanging: "https://primary.contoso.com"}
+	client := newAsyncTelemetryClient(next, asyncQueueSize, 0)
+	spoolDir := t.TempDir()
+	res := &TelemetryResource{client: client, spool: newEventSpool(spoolDir)}
+	e := &telemetryEvent{name: "delete", tags: map[string]string{"event": "delete"}}
+	attempt := res.fanOut(context.Background(), e, deliveryTargets([]string{"https://primary.contoso.com"}, []string{"https://secondary.contoso.com"}), 50*time.Millisecond)
+	require.NotNil(t, attempt)
+	assert.NoError(t, attempt.err)
+	assert.True(t, attempt.queued)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.flush(ctx)
+	sent := next.sentEvents()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "https://secondary.contoso.com", sent[0].endpoint)
+	entries, err := os.ReadDir(spoolDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFanOut_queuingClientSpoolsDeleteEventWhenDeliveryFails(t *testing.T) {
+	next := &fakeTelemetryClient{sendErr: errors.New("unavailable")}
+	client := newAsyncTelemetryClient(next, asyncQueueSize, 0)
+	spoolDir := t.TempDir()
+	res := &TelemetryResource{client: client, spool: newEventSpool(spoolDir)}
+	e := &telemetryEvent{name: "delete", tags: map[string]string{"event": "delete"}}
+	attempt := res.fanOut(context.Background(), e, deliveryTargets([]string{"https://primary.contoso.com"}, []string{"https://secondary.contoso.com"}), time.Second)
+	require.NotNil(t, attempt)
+	assert.True(t, attempt.queued)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.flush(ctx)
+	sent := next.sentEvents()
+	require.Len(t, sent, 2)
+	assert.Equal(t, "https://secondary.contoso.com", sent[1].endpoint)
+	entries, err := os.ReadDir(spoolDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestSendEvent_fallbackEndpointsOnlyApplyToProviderEndpoint(t *testing.T) {
+	cases := map[string]struct {
+		providerEndpoint string
+		resourceEndpoint string
+		expected         []string
+	}{
+		"provider endpoint fails over": {
+			providerEndpoint: "https://provider.contoso.com",
+			expected:         []string{"https://provider.contoso.com", "https://fallback.contoso.com"},
+		},
+		"fallback replaces missing provider endpoint": {
+			expected: []string{"https://fallback.contoso.com"},
+		},
+		"resource endpoint doesn't fail over": {
+			providerEndpoint: "https://provider.contoso.com",
+			resourceEndpoint: "https://resource.contoso.com",
+			expected:         []string{"https://resource.contoso.com"},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			client := &fakeTelemetryClient{sendErr: errors.New("unavailable")}
+			res := &TelemetryResource{
+				providerEndpointFunc: func() string {
+					return c.providerEndpoint
+				},
+				enabled:                        true,
+				defaultEndpointOnProviderBlock: true,
+				fallbackEndpoints:              []string{"https://fallback.contoso.com"},
+				pipeline:                       eventPipeline{moduleSourceFilterStage([]*regexp.Regexp{regexp.MustCompile("foo")}, nil)},
+				sequence:                       &eventSequence{},
+				client:                         client,
+			}
+			res.sendEvent(context.Background(), "create", "00000000-0000-0000-0000-000000000000", map[string]string{"module_source": "foo"}, c.resourceEndpoint, "")
+			var endpoints []string
+			for _, e := range client.sentEvents() {
+				endpoints = append(endpoints, e.endpoint)
+			}
+			assert.Equal(t, c.expected, endpoints)
+		})
+	}
+}
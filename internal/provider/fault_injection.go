@@ -0,0 +1,81 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build faultinjection
+
+package provider
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// faultEnv makes the provider simulate telemetry delivery failures, so module authors could test that their
+// modules work when the telemetry endpoint misbehaves. It's only read by binaries built with the faultinjection tag.
+const faultEnv = "MODTM_FAULT"
+
+const (
+	// faultTimeout makes delivery requests hang until the provider gives up.
+	faultTimeout = "timeout"
+	// faultReset makes delivery requests fail as if the connection was reset by the endpoint.
+	faultReset = "reset"
+	// faultServerError makes the endpoint respond 500 Internal Server Error.
+	faultServerError = "500"
+)
+
+// faultTimeoutDelay is how long a request hangs with the `timeout` fault, longer than the default send timeout.
+var faultTimeoutDelay = sendTimeout + time.Second
+
+// faultRoundTripper injects the fault into delivery requests, i.e. POST requests. Other requests like the endpoint
+// discovery are passed to next untouched.
+type faultRoundTripper struct {
+	fault string
+	next  http.RoundTripper
+}
+
+// withFaultInjection wraps next with the fault set by MODTM_FAULT, next is returned as is when no known fault is set.
+func withFaultInjection(next http.RoundTripper) http.RoundTripper {
+	fault := strings.ToLower(strings.TrimSpace(os.Getenv(faultEnv)))
+	switch fault {
+	case faultTimeout, faultReset, faultServerError:
+		return &faultRoundTripper{fault: fault, next: next}
+	default:
+		return next
+	}
+}
+
+func (f *faultRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost {
+		return f.next.RoundTrip(req)
+	}
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	switch f.fault {
+	case faultTimeout:
+		select {
+		case <-time.After(faultTimeoutDelay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		return nil, fmt.Errorf("simulated timeout by %s: %w", faultEnv, os.ErrDeadlineExceeded)
+	case faultReset:
+		return nil, fmt.Errorf("simulated connection reset by %s: %w", faultEnv, syscall.ECONNRESET)
+	default:
+		return &http.Response{
+			Status:     "500 Internal Server Error",
+			StatusCode: http.StatusInternalServerError,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !faultinjection
+
+package provider
+
+import "net/http"
+
+// withFaultInjection returns next as is, delivery failures are only simulated by binaries built with the
+// faultinjection tag, so MODTM_FAULT cannot make the sends of the released provider fail.
+func withFaultInjection(next http.RoundTripper) http.RoundTripper {
+	return next
+}
@@ -0,0 +1,19 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !faultinjection
+
+package provider
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultInjection_ignoredWithoutBuildTag(t *testing.T) {
+	t.Setenv("MODTM_FAULT", "reset")
+	_, ok := newHTTPClient(cryptoPolicy{}, proxySettings{}, tlsSettings{}).Transport.(*http.Transport)
+	assert.True(t, ok)
+}
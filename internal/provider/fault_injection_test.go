@@ -0,0 +1,90 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build faultinjection
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultInjection(t *testing.T) {
+	stub := gostub.Stub(&faultTimeoutDelay, 10*time.Millisecond)
+	defer stub.Reset()
+	cases := []struct {
+		fault string
+		check func(t *testing.T, err error)
+	}{
+		{fault: faultTimeout, check: func(t *testing.T, err error) {
+			assert.ErrorContains(t, err, "simulated timeout")
+		}},
+		{fault: faultReset, check: func(t *testing.T, err error) {
+			assert.ErrorIs(t, err, syscall.ECONNRESET)
+		}},
+		{fault: faultServerError, check: func(t *testing.T, err error) {
+			assert.ErrorContains(t, err, "500")
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.fault, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				requests.Add(1)
+				_, _ = writer.Write([]byte("https://telemetry.azurewebsites.net"))
+			}))
+			defer server.Close()
+			t.Setenv(faultEnv, c.fault)
+			client := newHttpTelemetryClient(newHTTPClient(cryptoPolicy{}, proxySettings{}, tlsSettings{}), payloadEncodingJSON)
+
+			c.check(t, client.send(context.Background(), server.URL, map[string]string{"event": "create"}))
+			assert.Equal(t, int32(0), requests.Load())
+
+			// Only delivery is affected, the endpoint discovery still works.
+			blobStub := gostub.Stub(&endpointBlobUrl, server.URL)
+			defer blobStub.Reset()
+			endpoint, err := client.discoverEndpoint(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, "https://telemetry.azurewebsites.net", endpoint)
+		})
+	}
+}
+
+func TestFaultInjection_unknownFaultIsIgnored(t *testing.T) {
+	t.Setenv(faultEnv, "meteor")
+	_, ok := newHTTPClient(cryptoPolicy{}, proxySettings{}, tlsSettings{}).Transport.(*http.Transport)
+	assert.True(t, ok)
+}
+
+func TestAccTelemetryResource_faultInjectionDoesNotBreakApply(t *testing.T) {
+	ms := newMockServer()
+	defer ms.close()
+	t.Setenv(faultEnv, faultReset)
+	tags := map[string]string{
+		"module_source": "foo",
+	}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccTelemetryResourceConfig(ms.serverUrl(), true, tags),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testChecksForTags(tags, resourceIdIsUuidCheck())...,
+				),
+			},
+		},
+	})
+	assert.Empty(t, ms.tags)
+}
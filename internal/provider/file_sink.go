@@ -0,0 +1,48 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+var _ eventSink = &fileSink{}
+
+// fileSink appends telemetry events to a local file as JSON lines. A nil *fileSink doesn't write anything.
+type fileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newFileSink(path string) *fileSink {
+	if path == "" {
+		return nil
+	}
+	return &fileSink{path: path}
+}
+
+// write appends the tags as one line, the file is created if it doesn't exist.
+func (s *fileSink) write(tags map[string]string) error {
+	if s == nil {
+		return nil
+	}
+	line, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	// A single write with O_APPEND keeps lines from concurrent provider processes from interleaving.
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
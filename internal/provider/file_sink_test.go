@@ -0,0 +1,29 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink_appendsJsonLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	s := newFileSink(path)
+	require.NoError(t, s.write(map[string]string{"event": "create"}))
+	require.NoError(t, s.write(map[string]string{"event": "delete"}))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"event\":\"create\"}\n{\"event\":\"delete\"}\n", string(content))
+}
+
+func TestFileSink_nilSinkWritesNothing(t *testing.T) {
+	s := newFileSink("")
+	assert.Nil(t, s)
+	assert.NoError(t, s.write(map[string]string{"event": "create"}))
+}
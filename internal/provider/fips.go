@@ -0,0 +1,76 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"hash"
+	"slices"
+)
+
+// hashAlgorithms are the hash algorithms used by hashing or signing features.
+var hashAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+}
+
+// fipsHashAlgorithms are the hash algorithms that are FIPS 140 approved for hashing and signing.
+var fipsHashAlgorithms = []string{"sha256"}
+
+// cryptoPolicy decides which cryptographic primitives the provider is allowed to use. In FIPS mode
+// only FIPS approved algorithms, TLS versions and cipher suites are allowed.
+type cryptoPolicy struct {
+	fipsMode bool
+}
+
+// newHash returns a new hash.Hash computing the given algorithm, or an error if the algorithm is unknown
+// or not allowed by the policy.
+func (p cryptoPolicy) newHash(algorithm string) (hash.Hash, error) {
+	f, err := p.hashFunc(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return f(), nil
+}
+
+// newHMAC returns a new HMAC using the given hash algorithm and key, or an error if the algorithm is unknown
+// or not allowed by the policy.
+func (p cryptoPolicy) newHMAC(algorithm string, key []byte) (hash.Hash, error) {
+	f, err := p.hashFunc(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return hmac.New(f, key), nil
+}
+
+func (p cryptoPolicy) hashFunc(algorithm string) (func() hash.Hash, error) {
+	f, ok := hashAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash algorithm %q", algorithm)
+	}
+	if p.fipsMode && !slices.Contains(fipsHashAlgorithms, algorithm) {
+		return nil, fmt.Errorf("hash algorithm %q is not allowed in FIPS mode", algorithm)
+	}
+	return f, nil
+}
+
+// tlsConfig returns the TLS configuration for outgoing requests, nil means Go's defaults.
+func (p cryptoPolicy) tlsConfig() *tls.Config {
+	if !p.fipsMode {
+		return nil
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// TLS 1.3 cipher suites are not configurable, Go only selects AES-GCM ones when it's built with boringcrypto.
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		},
+		CurvePreferences: []tls.CurveID{tls.CurveP256, tls.CurveP384},
+	}
+}
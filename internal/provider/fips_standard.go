@@ -0,0 +1,10 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !boringcrypto
+
+package provider
+
+// boringCrypto is true when the provider is built with GOEXPERIMENT=boringcrypto, so the cryptographic
+// primitives come from the FIPS 140 validated BoringCrypto module.
+const boringCrypto = false
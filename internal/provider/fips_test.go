@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoPolicy_newHash(t *testing.T) {
+	cases := []struct {
+		algorithm   string
+		fipsMode    bool
+		expectedErr string
+	}{
+		{algorithm: "sha256", fipsMode: false},
+		{algorithm: "sha256", fipsMode: true},
+		{algorithm: "sha1", fipsMode: false, expectedErr: `unknown hash algorithm "sha1"`},
+		{algorithm: "md4", fipsMode: false, expectedErr: `unknown hash algorithm "md4"`},
+	}
+	for _, c := range cases {
+		t.Run(c.algorithm, func(t *testing.T) {
+			p := cryptoPolicy{fipsMode: c.fipsMode}
+			h, err := p.newHash(c.algorithm)
+			if c.expectedErr != "" {
+				assert.EqualError(t, err, c.expectedErr)
+				_, err = p.newHMAC(c.algorithm, []byte("key"))
+				assert.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, h)
+		})
+	}
+}
+
+func TestCryptoPolicy_newHMAC(t *testing.T) {
+	h, err := cryptoPolicy{fipsMode: true}.newHMAC("sha256", []byte("key"))
+	require.NoError(t, err)
+	_, _ = h.Write([]byte("The quick brown fox jumps over the lazy dog"))
+	assert.Equal(t, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", hex.EncodeToString(h.Sum(nil)))
+}
+
+func TestNewHTTPClient_fipsModeRestrictsTls(t *testing.T) {
+	client := newHTTPClient(cryptoPolicy{fipsMode: true}, proxySettings{}, tlsSettings{})
+	config := client.Transport.(*http.Transport).TLSClientConfig
+	require.NotNil(t, config)
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	for _, suite := range config.CipherSuites {
+		assert.NotContains(t, tls.CipherSuiteName(suite), "CBC")
+	}
+
+	client = newHTTPClient(cryptoPolicy{}, proxySettings{}, tlsSettings{})
+	assert.Nil(t, client.Transport.(*http.Transport).TLSClientConfig)
+}
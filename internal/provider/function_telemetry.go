@@ -0,0 +1,157 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// functionTelemetryEnv turns function telemetry on for provider instances that are not configured. Its value is
+// a regex matching the module sources to report, since there's no `module_source_regex` to rely on.
+const functionTelemetryEnv = "MODTM_FUNCTION_TELEMETRY"
+
+// functionEvent is the event sent when a module function resolves a module source.
+const functionEvent = "function"
+
+// functionTelemetry sends a lightweight `function` event the first time a module function resolves a module
+// source in the provider process. A nil *functionTelemetry does nothing.
+type functionTelemetry struct {
+	client       telemetryClient
+	endpointFunc func() string
+	// pipeline holds the required stages of the event pipeline, so the module source filters and the privacy
+	// settings apply to function events too.
+	pipeline    eventPipeline
+	sink        eventSink
+	rateLimiter *rateLimiter
+	sendLimiter *sendLimiter
+	sent        sync.Map
+	wg          sync.WaitGroup
+}
+
+// newFunctionTelemetry builds the function telemetry of the provider configuration c.
+func newFunctionTelemetry(c providerConfig) *functionTelemetry {
+	t := &functionTelemetry{
+		client:       c.client,
+		endpointFunc: c.endpointFunc,
+		pipeline:     requiredEventPipeline(c),
+		sink:         c.sink,
+		rateLimiter:  c.rateLimiter,
+		sendLimiter:  c.sendLimiter,
+	}
+	registerShutdownHook(t.wait)
+	return t
+}
+
+// newFunctionTelemetryFromEnv builds the function telemetry of an unconfigured provider instance, Terraform calls
+// provider functions on such instances so the provider block is never seen. It returns nil unless
+// MODTM_FUNCTION_TELEMETRY is set to a valid regex, or when telemetry is opted out, MODTM_SINK keeps events off the
+// network, or MODTM_ENDPOINT is malformed or insecure.
+func newFunctionTelemetryFromEnv(client telemetryClient) *functionTelemetry {
+	pattern := os.Getenv(functionTelemetryEnv)
+	if pattern == "" || telemetryOptOut() != "" {
+		return nil
+	}
+	if sink := os.Getenv(sinkEnv); sink != "" && sink != sinkHttp {
+		return nil
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+	endpointFunc := sync.OnceValue(func() string {
+		endpoint, err := client.discoverEndpoint(context.Background())
+		if err != nil {
+			return ""
+		}
+		return endpoint
+	})
+	if raw := os.Getenv("MODTM_ENDPOINT"); raw != "" {
+		ctx := context.Background()
+		endpoint := configuredEndpoint(ctx, raw, "MODTM_ENDPOINT environment variable")
+		if endpoint == "" {
+			return nil
+		}
+		if diags := insecureEndpointDiagnostics(path.Empty(), endpoint, "MODTM_ENDPOINT environment variable", false); diags.HasError() {
+			errorLog(ctx, diags[0].Detail())
+			return nil
+		}
+		endpointFunc = func() string {
+			return endpoint
+		}
+	}
+	return newFunctionTelemetry(providerConfig{client: client, endpointFunc: endpointFunc, moduleSourceRegex: []*regexp.Regexp{regex}})
+}
+
+// send reports that function has resolved the module source and version. The event is sent in the background
+// so the function call isn't slowed down, and only once per function, source and version. Like resources' events, it
+// goes through the required stages of the event pipeline, the sink and the limits of the provider.
+func (t *functionTelemetry) send(ctx context.Context, function, moduleSource, moduleVersion string) {
+	if t == nil || moduleSource == "" {
+		return
+	}
+	key := strings.Join([]string{function, moduleSource, moduleVersion}, "\x00")
+	if _, loaded := t.sent.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	e := &telemetryEvent{
+		name: functionEvent,
+		tags: map[string]string{
+			eventTag:         functionEvent,
+			"function":       function,
+			"module_source":  moduleSource,
+			"module_version": moduleVersion,
+			timestampTag:     formatTimestamp(timeNow(), "", ""),
+		},
+	}
+	if !t.pipeline.run(ctx, e) {
+		return
+	}
+	if t.sink != nil {
+		if err := t.sink.write(e.tags); err != nil {
+			errorLog(ctx, fmt.Sprintf("error on writing %s function telemetry event to sink: %+v", function, err))
+		}
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		endpoint := t.endpointFunc()
+		if endpoint == "" {
+			return
+		}
+		sendCtx, cancel := withSendTimeout(context.Background())
+		defer cancel()
+		if err := t.rateLimiter.wait(sendCtx); err != nil {
+			traceLog(ctx, fmt.Sprintf("skip %s function telemetry event: %s", function, err.Error()))
+			return
+		}
+		if err := t.sendLimiter.acquire(sendCtx, e.highPriority); err != nil {
+			traceLog(ctx, fmt.Sprintf("skip %s function telemetry event: %s", function, err.Error()))
+			return
+		}
+		defer t.sendLimiter.release()
+		if err := t.client.send(sendCtx, endpoint, e.tags); err != nil {
+			traceLog(ctx, fmt.Sprintf("error on sending %s function telemetry event: %+v", function, err))
+		}
+	}()
+}
+
+// wait waits for the events in flight to be sent, or ctx to be done.
+func (t *functionTelemetry) wait(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
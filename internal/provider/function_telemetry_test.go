@@ -0,0 +1,139 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctionTelemetry_send(t *testing.T) {
+	client := &fakeTelemetryClient{}
+	ft := newFunctionTelemetry(providerConfig{
+		client: client,
+		endpointFunc: func() string {
+			return "https://telemetry.contoso.com"
+		},
+		moduleSourceRegex:     []*regexp.Regexp{regexp.MustCompile("^registry.terraform.io/Azure/")},
+		moduleSourceDenyRegex: []*regexp.Regexp{regexp.MustCompile("legacy")},
+	})
+
+	ft.send(context.Background(), "module_source", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm", "0.5.1")
+	ft.send(context.Background(), "module_source", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm", "0.5.1")
+	ft.send(context.Background(), "module_version", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm", "0.5.1")
+	ft.send(context.Background(), "module_source", "registry.terraform.io/Azure/legacy-module/azurerm", "1.0.0")
+	ft.send(context.Background(), "module_source", "./modules/key", "")
+	ft.send(context.Background(), "module_source", "", "")
+	ft.wait(context.Background())
+
+	sent := client.sentEvents()
+	require.Len(t, sent, 2)
+	var functions []string
+	for _, e := range sent {
+		assert.Equal(t, "https://telemetry.contoso.com", e.endpoint)
+		assert.Equal(t, "function", e.tags["event"])
+		assert.Equal(t, "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm", e.tags["module_source"])
+		assert.Equal(t, "0.5.1", e.tags["module_version"])
+		functions = append(functions, e.tags["function"])
+	}
+	assert.ElementsMatch(t, []string{"module_source", "module_version"}, functions)
+}
+
+func TestFunctionTelemetry_sendAppliesPrivacySettings(t *testing.T) {
+	client := &fakeTelemetryClient{}
+	ft := newFunctionTelemetry(providerConfig{
+		client: client,
+		endpointFunc: func() string {
+			return "https://telemetry.contoso.com"
+		},
+		moduleSourceRegex: []*regexp.Regexp{regexp.MustCompile("^registry.terraform.io/Azure/")},
+		hashTags:          []string{"module_source"},
+		redactPatterns:    []*regexp.Regexp{regexp.MustCompile(`\d+\.\d+\.\d+`)},
+	})
+
+	ft.send(context.Background(), "module_source", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm", "0.5.1")
+	ft.wait(context.Background())
+
+	sent := client.sentEvents()
+	require.Len(t, sent, 1)
+	assert.NotEmpty(t, sent[0].tags["module_source"])
+	assert.NotContains(t, sent[0].tags["module_source"], "avm-res-keyvault-vault")
+	assert.Equal(t, "[REDACTED]", sent[0].tags["module_version"])
+}
+
+func TestFunctionTelemetry_nilDoesNothing(t *testing.T) {
+	var ft *functionTelemetry
+	ft.send(context.Background(), "module_source", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm", "0.5.1")
+}
+
+func TestNewFunctionTelemetryFromEnv(t *testing.T) {
+	t.Setenv(functionTelemetryEnv, "")
+	assert.Nil(t, newFunctionTelemetryFromEnv(&fakeTelemetryClient{}))
+	t.Setenv(functionTelemetryEnv, "[")
+	assert.Nil(t, newFunctionTelemetryFromEnv(&fakeTelemetryClient{}))
+
+	t.Setenv(functionTelemetryEnv, "Azure/")
+	t.Setenv("MODTM_ENDPOINT", "")
+	client := &fakeTelemetryClient{endpoint: "https://discovered.contoso.com"}
+	ft := newFunctionTelemetryFromEnv(client)
+	require.NotNil(t, ft)
+	assert.Equal(t, "https://discovered.contoso.com", ft.endpointFunc())
+	assert.Equal(t, "https://discovered.contoso.com", ft.endpointFunc())
+	assert.Equal(t, 1, client.discoverCalls)
+}
+
+func TestNewFunctionTelemetryFromEnv_respectsSinkAndEndpoint(t *testing.T) {
+	t.Setenv(functionTelemetryEnv, "Azure/")
+	t.Setenv(sinkEnv, sinkStdout)
+	assert.Nil(t, newFunctionTelemetryFromEnv(&fakeTelemetryClient{}))
+
+	t.Setenv(sinkEnv, sinkHttp)
+	for _, endpoint := range []string{"http://telemetry.contoso.com", "ftp://telemetry.contoso.com"} {
+		t.Setenv("MODTM_ENDPOINT", endpoint)
+		assert.Nil(t, newFunctionTelemetryFromEnv(&fakeTelemetryClient{}), endpoint)
+	}
+
+	t.Setenv("MODTM_ENDPOINT", "https://telemetry.contoso.com")
+	client := &fakeTelemetryClient{}
+	ft := newFunctionTelemetryFromEnv(client)
+	require.NotNil(t, ft)
+	assert.Equal(t, "https://telemetry.contoso.com", ft.endpointFunc())
+	assert.Zero(t, client.discoverCalls)
+}
+
+func TestAccModuleSourceFunction_functionTelemetryFromEnv(t *testing.T) {
+	require.NoError(t, createModulesJson())
+	t.Setenv(functionTelemetryEnv, "Azure/avm-")
+	t.Setenv("MODTM_ENDPOINT", "https://telemetry.contoso.com")
+	client := &fakeTelemetryClient{}
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccModuleSourceFunctionConfig(".terraform/modules/keys/modules/key"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm//modules/key"),
+				),
+			},
+		},
+	})
+	assert.Eventually(t, func() bool {
+		return len(client.sentEvents()) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	e := client.sentEvents()[0]
+	assert.Equal(t, "https://telemetry.contoso.com", e.endpoint)
+	assert.Equal(t, "module_source", e.tags["function"])
+	assert.Equal(t, "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm//modules/key", e.tags["module_source"])
+}
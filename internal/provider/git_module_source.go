@@ -0,0 +1,76 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// gitCommandTimeout is how long a git command could run before it's killed.
+const gitCommandTimeout = 5 * time.Second
+
+// gitSourcePrefix marks a module source that was resolved from git metadata instead of `modules.json`, it's the
+// forced source type prefix of Terraform's git module sources.
+const gitSourcePrefix = "git::"
+
+// gitModuleInfo is the git metadata of a module directory.
+type gitModuleInfo struct {
+	Remote string
+	Commit string
+	Dirty  bool
+}
+
+// source returns the module source of the git remote, e.g. `git::https://github.com/Azure/repo.git`.
+func (g gitModuleInfo) source() string {
+	return gitSourcePrefix + g.Remote
+}
+
+// version returns the short commit, suffixed by `-dirty` when the working tree has uncommitted changes.
+func (g gitModuleInfo) version() string {
+	if g.Dirty {
+		return g.Commit + "-dirty"
+	}
+	return g.Commit
+}
+
+// readGitModuleInfo reads the `origin` remote URL, the short commit of `HEAD` and whether tracked files are modified
+// from the git repository that contains dir. It requires the git CLI.
+func readGitModuleInfo(ctx context.Context, dir string) (gitModuleInfo, error) {
+	remote, err := runGit(ctx, dir, "config", "--get", "remote.origin.url")
+	if err != nil {
+		return gitModuleInfo{}, err
+	}
+	commit, err := runGit(ctx, dir, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return gitModuleInfo{}, err
+	}
+	status, err := runGit(ctx, dir, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return gitModuleInfo{}, err
+	}
+	return gitModuleInfo{
+		Remote: remote,
+		Commit: commit,
+		Dirty:  status != "",
+	}, nil
+}
+
+// runGit runs the git command in dir and returns its trimmed stdout.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...) // #nosec G204
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running git %s: %w, stderr: %s", strings.Join(args, " "), err, stderr.String())
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initGitModule creates a git repository with one commit and an `origin` remote, the module lives in `modules/key`.
+func initGitModule(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	moduleDir := filepath.Join(dir, "modules", "key")
+	require.NoError(t, os.MkdirAll(moduleDir, 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, "main.tf"), []byte("# key\n"), 0600))
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"remote", "add", "origin", "https://github.com/Azure/terraform-azurerm-avm-res-keyvault-vault.git"},
+		{"add", "."},
+		{"-c", "user.name=modtm", "-c", "user.email=modtm@contoso.com", "commit", "-q", "-m", "init"},
+	} {
+		_, err := runGit(context.Background(), dir, args...)
+		require.NoError(t, err)
+	}
+	return moduleDir
+}
+
+func TestReadGitModuleInfo(t *testing.T) {
+	moduleDir := initGitModule(t)
+	info, err := readGitModuleInfo(context.Background(), moduleDir)
+	require.NoError(t, err)
+	assert.Equal(t, "git::https://github.com/Azure/terraform-azurerm-avm-res-keyvault-vault.git", info.source())
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{7,}$`), info.version())
+	assert.False(t, info.Dirty)
+
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, "main.tf"), []byte("# changed\n"), 0600))
+	info, err = readGitModuleInfo(context.Background(), moduleDir)
+	require.NoError(t, err)
+	assert.True(t, info.Dirty)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{7,}-dirty$`), info.version())
+}
+
+func TestReadGitModuleInfo_notRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	_, err := readGitModuleInfo(context.Background(), t.TempDir())
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// gitRemote is a git remote URL split into the host, the organization and the repository.
+type gitRemote struct {
+	Host string `tfsdk:"host"`
+	Org  string `tfsdk:"org"`
+	Repo string `tfsdk:"repo"`
+}
+
+// scpLikeGitRemote matches the scp-like syntax of SSH remotes, e.g. `git@github.com:Azure/repo.git`.
+var scpLikeGitRemote = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// parseGitRemote parses HTTPS, SSH and scp-like git remote URLs. The repository is the last segment of the path,
+// the organization is everything before it, so GitLab subgroups and Azure DevOps projects are kept, e.g.
+// `group/subgroup` or `org/project`.
+func parseGitRemote(remote string) (gitRemote, error) {
+	remote = strings.TrimSpace(remote)
+	var host, path string
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return gitRemote{}, fmt.Errorf("invalid git remote %q: %w", remote, err)
+		}
+		host, path = u.Hostname(), u.Path
+	} else if m := scpLikeGitRemote.FindStringSubmatch(remote); m != nil {
+		host, path = m[1], m[2]
+	} else {
+		return gitRemote{}, fmt.Errorf("invalid git remote %q: not a URL or scp-like SSH remote", remote)
+	}
+	var segments []string
+	for _, segment := range strings.Split(strings.TrimSuffix(strings.Trim(path, "/"), ".git"), "/") {
+		// Azure DevOps puts `_git` before the repository in HTTPS remotes, and `v3` before the organization in SSH remotes.
+		if segment == "" || segment == "_git" || (segment == "v3" && len(segments) == 0 && strings.HasSuffix(host, "dev.azure.com")) {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	if host == "" || len(segments) < 2 {
+		return gitRemote{}, fmt.Errorf("invalid git remote %q: expect host, organization and repository", remote)
+	}
+	return gitRemote{
+		Host: host,
+		Org:  strings.Join(segments[:len(segments)-1], "/"),
+		Repo: segments[len(segments)-1],
+	}, nil
+}
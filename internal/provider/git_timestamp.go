@@ -0,0 +1,71 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitTimestampTag is the tag that carries the last modified time of the module's source file, as reported by git.
+const gitTimestampTag = "avm_git_last_modified_at"
+
+// gitTimestampLayouts are the date formats git and the tooling around it produce, e.g. `git log --date=iso`,
+// `--date=iso-strict`, `--date=rfc` and the default format. Layouts without a zone are read as UTC.
+var gitTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon Jan 2 15:04:05 2006 -0700",
+	"Mon Jan 2 15:04:05 2006",
+}
+
+// gitRawTimestamp matches git's raw date format, seconds since epoch followed by an optional zone offset.
+var gitRawTimestamp = regexp.MustCompile(`^(\d+)(\s+[+-]\d{4})?$`)
+
+// normalizeGitTimestamp parses value in any of the common git date formats and returns it in UTC as RFC3339.
+func normalizeGitTimestamp(value string) (string, error) {
+	value = strings.Join(strings.Fields(value), " ")
+	if m := gitRawTimestamp.FindStringSubmatch(value); m != nil {
+		seconds, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return "", err
+		}
+		return time.Unix(seconds, 0).UTC().Format(time.RFC3339), nil
+	}
+	for _, layout := range gitTimestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC().Format(time.RFC3339), nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized git timestamp %q", value)
+}
+
+// gitTimestampStage rewrites the `avm_git_last_modified_at` tag to UTC RFC3339 when enabled, values that
+// cannot be parsed are sent as they are.
+func gitTimestampStage(enabled bool) eventStage {
+	return eventStage{
+		name: stageGitTimestamp,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			value, ok := e.tags[gitTimestampTag]
+			if !enabled || !ok {
+				return true
+			}
+			normalized, err := normalizeGitTimestamp(value)
+			if err != nil {
+				traceLog(ctx, fmt.Sprintf("keep %s tag as it is: %s", gitTimestampTag, err.Error()))
+				return true
+			}
+			e.tags[gitTimestampTag] = normalized
+			return true
+		},
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeGitTimestamp(t *testing.T) {
+	cases := []struct {
+		desc  string
+		value string
+	}{
+		{desc: "rfc3339", value: "2023-05-04T07:02:32+02:00"},
+		{desc: "rfc3339 utc", value: "2023-05-04T05:02:32Z"},
+		{desc: "iso", value: "2023-05-04 07:02:32 +0200"},
+		{desc: "no zone", value: "2023-05-04 05:02:32"},
+		{desc: "rfc2822", value: "Thu, 4 May 2023 07:02:32 +0200"},
+		{desc: "git default", value: "Thu May 4 07:02:32 2023 +0200"},
+		{desc: "raw", value: "1683176552 +0200"},
+		{desc: "unix", value: "1683176552"},
+		{desc: "extra spaces", value: " 2023-05-04  05:02:32 "},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			normalized, err := normalizeGitTimestamp(c.value)
+			require.NoError(t, err)
+			assert.Equal(t, "2023-05-04T05:02:32Z", normalized)
+		})
+	}
+}
+
+func TestNormalizeGitTimestamp_invalid(t *testing.T) {
+	_, err := normalizeGitTimestamp("yesterday")
+	assert.Error(t, err)
+}
+
+func TestGitTimestampStage(t *testing.T) {
+	e := &telemetryEvent{tags: map[string]string{gitTimestampTag: "2023-05-04 07:02:32 +0200"}}
+	assert.True(t, gitTimestampStage(false).process(context.Background(), e))
+	assert.Equal(t, "2023-05-04 07:02:32 +0200", e.tags[gitTimestampTag])
+
+	assert.True(t, gitTimestampStage(true).process(context.Background(), e))
+	assert.Equal(t, "2023-05-04T05:02:32Z", e.tags[gitTimestampTag])
+
+	e = &telemetryEvent{tags: map[string]string{gitTimestampTag: "yesterday"}}
+	assert.True(t, gitTimestampStage(true).process(context.Background(), e))
+	assert.Equal(t, "yesterday", e.tags[gitTimestampTag])
+}
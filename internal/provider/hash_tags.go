@@ -0,0 +1,50 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+)
+
+// hashTagValue returns the hex encoded SHA-256 hash of value, or its HMAC-SHA-256 keyed by salt if salt is set.
+func hashTagValue(policy cryptoPolicy, value, salt string) (string, error) {
+	var h hash.Hash
+	var err error
+	if salt == "" {
+		h, err = policy.newHash("sha256")
+	} else {
+		h, err = policy.newHMAC("sha256", []byte(salt))
+	}
+	if err != nil {
+		return "", err
+	}
+	_, _ = h.Write([]byte(value))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashTagsStage replaces the values of the tags in keys with their hashes. The event is dropped if a value cannot
+// be hashed, so the raw value never leaks.
+func hashTagsStage(keys []string, salt string, policy cryptoPolicy) eventStage {
+	return eventStage{
+		name: stageHashTags,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			for _, k := range keys {
+				v, ok := e.tags[k]
+				if !ok {
+					continue
+				}
+				hashed, err := hashTagValue(policy, v, salt)
+				if err != nil {
+					errorLog(ctx, fmt.Sprintf("error on hashing %s tag: %+v", k, err))
+					return false
+				}
+				e.tags[k] = hashed
+			}
+			return true
+		},
+	}
+}
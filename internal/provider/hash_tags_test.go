@@ -0,0 +1,31 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashTagValue(t *testing.T) {
+	hashed, err := hashTagValue(cryptoPolicy{}, "terraform-azurerm-aks", "")
+	require.NoError(t, err)
+	// echo -n terraform-azurerm-aks | sha256sum
+	assert.Equal(t, "d654c5be315256bf7c875b90f3b13bf05ebd40e77eeaf5fb2c035c17c232f792", hashed)
+	salted, err := hashTagValue(cryptoPolicy{}, "terraform-azurerm-aks", "secret")
+	require.NoError(t, err)
+	assert.Len(t, salted, 64)
+	assert.NotEqual(t, hashed, salted)
+}
+
+func TestHashTagsStage(t *testing.T) {
+	e := &telemetryEvent{tags: map[string]string{"avm_git_org": "Azure", "avm_git_file": "main.tf"}}
+	assert.True(t, hashTagsStage([]string{"avm_git_org", "avm_git_repo"}, "", cryptoPolicy{}).process(context.Background(), e))
+	expected, err := hashTagValue(cryptoPolicy{}, "Azure", "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"avm_git_org": expected, "avm_git_file": "main.tf"}, e.tags)
+}
@@ -0,0 +1,42 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxIdleConnsPerHost keeps enough idle connections to the endpoint for the events of a parallel apply, Terraform
+	// runs 10 operations at the same time by default.
+	maxIdleConnsPerHost = 16
+	// idleConnTimeout closes the idle connections before the idle timeout of most load balancers.
+	idleConnTimeout = 60 * time.Second
+	// maxDrainedBodySize is how much of a response body is read before it's closed so the connection could be reused,
+	// the connection is given up for larger bodies.
+	maxDrainedBodySize = 64 << 10
+)
+
+// newHTTPClient returns the client used for all outgoing requests of the provider. It's created once in Configure
+// and shared by all resources, data sources and the endpoint discovery, so connections are kept alive and reused
+// across events. Delivery failures are simulated when MODTM_FAULT is set and the provider is built with the
+// faultinjection tag.
+func newHTTPClient(policy cryptoPolicy, proxy proxySettings, customTLS tlsSettings) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = customTLS.apply(policy.tlsConfig())
+	transport.Proxy = newProxyFunc(proxy)
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	transport.IdleConnTimeout = idleConnTimeout
+	// A custom TLS config turns off HTTP/2 unless it's forced.
+	transport.ForceAttemptHTTP2 = true
+	return &http.Client{Transport: withFaultInjection(transport)}
+}
+
+// closeBody drains and closes a response body, the connection is only returned to the pool once the body is fully read.
+func closeBody(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBodySize))
+	_ = resp.Body.Close()
+}
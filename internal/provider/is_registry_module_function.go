@@ -0,0 +1,52 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &IsRegistryModuleFunction{}
+
+func NewIsRegistryModuleFunction(modulesJson *modulesJsonCache) function.Function {
+	return &IsRegistryModuleFunction{modulesJson: modulesJson}
+}
+
+type IsRegistryModuleFunction struct {
+	modulesJson *modulesJsonCache
+}
+
+func (m *IsRegistryModuleFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "is_registry_module"
+}
+
+func (m *IsRegistryModuleFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "`is_registry_module` function",
+		MarkdownDescription: "This function takes in `${path.module}` and returns `true` when the corresponding item's `Source` in `modules.json` file in the current root module's `.terraform/module` folder is a public or private registry address, e.g. `registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm`, so a module could enable telemetry only when it's distributed via a registry. " +
+			"Returns `false` for local paths, git, HTTP and other sources, and when the module cannot be found in `modules.json`.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "module_path",
+				MarkdownDescription: "`${path.module}`",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (m *IsRegistryModuleFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var modulePath string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &modulePath))
+	if resp.Error != nil {
+		return
+	}
+	isRegistry := false
+	if module, err := m.modulesJson.parseModulesJson("", modulePath); err == nil {
+		_, isRegistry = parseRegistrySource(module.Source)
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, isRegistry))
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccIsRegistryModuleFunction(t *testing.T) {
+	require.NoError(t, createModulesJson())
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccIsRegistryModuleFunctionConfig(".terraform/modules/keys/modules/key"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "true"),
+				),
+			},
+			{
+				Config: testAccIsRegistryModuleFunctionConfig(".terraform/modules/kv/modules/key"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "false"),
+				),
+			},
+			{
+				Config: testAccIsRegistryModuleFunctionConfig(".terraform/modules/unknown"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "false"),
+				),
+			},
+		},
+	})
+}
+
+func testAccIsRegistryModuleFunctionConfig(modulePath string) string {
+	return fmt.Sprintf(`
+output "test" {
+  value = provider::modtm::is_registry_module("%s")
+}
+`, modulePath)
+}
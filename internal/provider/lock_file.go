@@ -0,0 +1,54 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// defaultLockFilePath is the dependency lock file of the root module, relative to the root module's directory which is
+// the working directory of the provider.
+const defaultLockFilePath = ".terraform.lock.hcl"
+
+// lockFile is the structure of the dependency lock file, the unknown blocks and attributes are ignored so lock files
+// written by newer Terraform versions could still be read.
+type lockFile struct {
+	Providers []lockedProvider `hcl:"provider,block"`
+	Remain    hcl.Body         `hcl:",remain"`
+}
+
+// lockedProvider is a `provider` block of the dependency lock file.
+type lockedProvider struct {
+	Address     string   `hcl:"address,label"`
+	Version     string   `hcl:"version"`
+	Constraints *string  `hcl:"constraints,optional"`
+	Hashes      []string `hcl:"hashes,optional"`
+	Remain      hcl.Body `hcl:",remain"`
+}
+
+// readLockFile parses the dependency lock file and returns the locked providers ordered by address.
+func readLockFile(path string) ([]lockedProvider, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, diags := hclsyntax.ParseConfig(content, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("readLockFile: %s", diags.Error())
+	}
+	lock := lockFile{}
+	if diags = gohcl.DecodeBody(file.Body, nil, &lock); diags.HasErrors() {
+		return nil, fmt.Errorf("readLockFile: %s", diags.Error())
+	}
+	sort.Slice(lock.Providers, func(i, j int) bool {
+		return lock.Providers[i].Address < lock.Providers[j].Address
+	})
+	return lock.Providers, nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLockFile = `# This file is maintained automatically by "terraform init".
+# Manual edits may be lost in future updates.
+
+provider "registry.terraform.io/hashicorp/azurerm" {
+  version     = "3.116.0"
+  constraints = ">= 3.71.0, < 4.0.0"
+  hashes = [
+    "h1:BCR3NIorFSvGG3v/+JOiiw3VM4PkChLO4m84wzD9NDo=",
+  ]
+}
+
+provider "registry.terraform.io/azure/modtm" {
+  version = "0.3.2"
+}
+`
+
+func TestReadLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".terraform.lock.hcl")
+	require.NoError(t, os.WriteFile(path, []byte(testLockFile), 0600))
+	providers, err := readLockFile(path)
+	require.NoError(t, err)
+	require.Len(t, providers, 2)
+	assert.Equal(t, "registry.terraform.io/azure/modtm", providers[0].Address)
+	assert.Equal(t, "0.3.2", providers[0].Version)
+	assert.Nil(t, providers[0].Constraints)
+	assert.Equal(t, "registry.terraform.io/hashicorp/azurerm", providers[1].Address)
+	assert.Equal(t, "3.116.0", providers[1].Version)
+	require.NotNil(t, providers[1].Constraints)
+	assert.Equal(t, ">= 3.71.0, < 4.0.0", *providers[1].Constraints)
+}
+
+func TestReadLockFile_invalid(t *testing.T) {
+	_, err := readLockFile(filepath.Join(t.TempDir(), ".terraform.lock.hcl"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	path := filepath.Join(t.TempDir(), ".terraform.lock.hcl")
+	require.NoError(t, os.WriteFile(path, []byte(`provider "registry.terraform.io/hashicorp/azurerm" {`), 0600))
+	_, err = readLockFile(path)
+	assert.Error(t, err)
+}
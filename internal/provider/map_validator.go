@@ -6,29 +6,127 @@ package provider
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"slices"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var _ validator.Map = mapValidator{}
 
+// reservedTags are the tags set by the provider, overwriting the tags with the same keys, so they cannot be set by
+// users.
+var reservedTags = []string{
+	eventTag,
+	resourceIdTag,
+	sequenceTag,
+	timestampTag,
+	instanceKeyTag,
+	tagChangesTag,
+	sampleRateTag,
+	terraformTestTag,
+	sessionDurationTag,
+	replayedTag,
+}
+
 type mapValidator struct{}
 
 func (m mapValidator) Description(ctx context.Context) string {
-	return "`tags` could not contains key `event`."
+	return fmt.Sprintf("`tags` could not contains keys %s.", strings.Join(reservedTags, ", "))
 }
 
 func (m mapValidator) MarkdownDescription(ctx context.Context) string {
-	return "`tags` could not contains key `event`."
+	return fmt.Sprintf("`tags` could not contains keys `%s`.", strings.Join(reservedTags, "`, `"))
 }
 
 func (m mapValidator) ValidateMap(ctx context.Context, request validator.MapRequest, response *validator.MapResponse) {
-	reservedKeys := []string{"event"}
+	keys := make([]string, 0, len(request.ConfigValue.Elements()))
 	for k := range request.ConfigValue.Elements() {
-		if slices.Contains(reservedKeys, k) {
-			errStr := fmt.Sprintf("`tags` must not contains keys %v.", reservedKeys)
-			response.Diagnostics.AddError(errStr, "")
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		if slices.Contains(reservedTags, k) {
+			response.Diagnostics.AddAttributeError(request.Path.AtMapKey(k), "Reserved tag key",
+				fmt.Sprintf("Tag %q is set by the provider and would be overwritten, `tags` must not contains keys %v.", k, reservedTags))
+		}
+	}
+}
+
+// TagLimitsModel describes the `tag_limits` block of the provider.
+type TagLimitsModel struct {
+	MaxTags        types.Int64  `tfsdk:"max_tags"`
+	MaxKeyLength   types.Int64  `tfsdk:"max_key_length"`
+	MaxValueLength types.Int64  `tfsdk:"max_value_length"`
+	KeyPattern     types.String `tfsdk:"key_pattern"`
+}
+
+// tagLimits are the limits of the tags set by users, configured on the provider so collectors with strict schemas
+// could be protected. Zero values mean no limit. The limits are only known once the provider is configured, so
+// unlike mapValidator they're checked when the plan is made rather than when the configuration is validated.
+type tagLimits struct {
+	maxTags        int
+	maxKeyLength   int
+	maxValueLength int
+	keyPattern     *regexp.Regexp
+}
+
+func newTagLimits(m *TagLimitsModel) tagLimits {
+	if m == nil {
+		return tagLimits{}
+	}
+	l := tagLimits{
+		maxTags:        int(m.MaxTags.ValueInt64()),
+		maxKeyLength:   int(m.MaxKeyLength.ValueInt64()),
+		maxValueLength: int(m.MaxValueLength.ValueInt64()),
+	}
+	if !m.KeyPattern.IsNull() {
+		l.keyPattern = regexp.MustCompile(m.KeyPattern.ValueString())
+	}
+	return l
+}
+
+// validate checks the tag maps of attribute p against the limits, the keys of all maps count towards `max_tags`
+// together. Unknown maps and values are skipped, they're checked again once they're known.
+func (l tagLimits) validate(p path.Path, tagMaps ...types.Map) diag.Diagnostics {
+	var diags diag.Diagnostics
+	values := make(map[string]attr.Value)
+	for _, m := range tagMaps {
+		if m.IsNull() || m.IsUnknown() {
+			continue
+		}
+		for k, v := range m.Elements() {
+			values[k] = v
+		}
+	}
+	if l.maxTags > 0 && len(values) > l.maxTags {
+		diags.AddAttributeError(p, "Too many tags", fmt.Sprintf("%d tags are set, no more than %d tags are allowed by `tag_limits` of the provider.", len(values), l.maxTags))
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		if l.maxKeyLength > 0 && utf8.RuneCountInString(k) > l.maxKeyLength {
+			diags.AddAttributeError(p, "Tag key too long", fmt.Sprintf("Key %q is longer than %d characters allowed by `tag_limits` of the provider.", k, l.maxKeyLength))
+		}
+		if l.keyPattern != nil && !l.keyPattern.MatchString(k) {
+			diags.AddAttributeError(p, "Invalid tag key", fmt.Sprintf("Key %q doesn't match `%s` of `tag_limits` of the provider.", k, l.keyPattern.String()))
+		}
+		s, ok := values[k].(types.String)
+		if !ok || s.IsNull() || s.IsUnknown() {
+			continue
+		}
+		if l.maxValueLength > 0 && utf8.RuneCountInString(s.ValueString()) > l.maxValueLength {
+			diags.AddAttributeError(p, "Tag value too long", fmt.Sprintf("The value of %q is longer than %d characters allowed by `tag_limits` of the provider.", k, l.maxValueLength))
 		}
 	}
+	return diags
 }
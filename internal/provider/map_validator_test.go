@@ -0,0 +1,96 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapValidator_reservedTags(t *testing.T) {
+	req := validator.MapRequest{
+		Path: path.Root("tags"),
+		ConfigValue: types.MapValueMust(types.StringType, map[string]attr.Value{
+			"module_source": types.StringValue("foo"),
+			"resource_id":   types.StringValue("bar"),
+			"timestamp":     types.StringValue("baz"),
+		}),
+	}
+	resp := &validator.MapResponse{}
+	mapValidator{}.ValidateMap(context.Background(), req, resp)
+	var paths []string
+	for _, d := range resp.Diagnostics.Errors() {
+		assert.Equal(t, "Reserved tag key", d.Summary())
+		paths = append(paths, d.(diag.DiagnosticWithPath).Path().String())
+	}
+	assert.Equal(t, []string{`tags["resource_id"]`, `tags["timestamp"]`}, paths)
+}
+
+func TestMapValidator_tagsOverwrittenByResourceAreReserved(t *testing.T) {
+	for _, k := range []string{"instance_key", "tag_changes", "terraform_test", "replayed"} {
+		t.Run(k, func(t *testing.T) {
+			req := validator.MapRequest{
+				Path:        path.Root("tags"),
+				ConfigValue: types.MapValueMust(types.StringType, map[string]attr.Value{k: types.StringValue("foo")}),
+			}
+			resp := &validator.MapResponse{}
+			mapValidator{}.ValidateMap(context.Background(), req, resp)
+			assert.True(t, resp.Diagnostics.HasError())
+		})
+	}
+}
+
+func TestTagLimits_validate(t *testing.T) {
+	tags := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"module_source": types.StringValue("foo"),
+		"Owner":         types.StringValue("platform-team"),
+	})
+	additionalTags := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"wrapper": types.StringUnknown(),
+	})
+	cases := []struct {
+		desc           string
+		limits         tagLimits
+		expectedErrors []string
+	}{
+		{desc: "no_limits", limits: tagLimits{}},
+		{desc: "max_tags", limits: tagLimits{maxTags: 2}, expectedErrors: []string{"Too many tags"}},
+		{desc: "max_key_length", limits: tagLimits{maxKeyLength: 10}, expectedErrors: []string{"Tag key too long"}},
+		{desc: "max_value_length", limits: tagLimits{maxValueLength: 5}, expectedErrors: []string{"Tag value too long"}},
+		{desc: "key_pattern", limits: tagLimits{keyPattern: regexp.MustCompile(`^[a-z][a-z0-9_]*$`)}, expectedErrors: []string{"Invalid tag key"}},
+		{desc: "within_limits", limits: tagLimits{maxTags: 3, maxKeyLength: 13, maxValueLength: 13}},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			diags := c.limits.validate(path.Root("tags"), tags, additionalTags, types.MapUnknown(types.StringType))
+			var summaries []string
+			for _, d := range diags.Errors() {
+				summaries = append(summaries, d.Summary())
+			}
+			assert.Equal(t, c.expectedErrors, summaries)
+		})
+	}
+}
+
+func TestNewTagLimits(t *testing.T) {
+	assert.Equal(t, tagLimits{}, newTagLimits(nil))
+	l := newTagLimits(&TagLimitsModel{
+		MaxTags:        types.Int64Value(10),
+		MaxKeyLength:   types.Int64Null(),
+		MaxValueLength: types.Int64Value(256),
+		KeyPattern:     types.StringValue(`^[a-z_]+$`),
+	})
+	assert.Equal(t, 10, l.maxTags)
+	assert.Equal(t, 0, l.maxKeyLength)
+	assert.Equal(t, 256, l.maxValueLength)
+	assert.Equal(t, `^[a-z_]+$`, l.keyPattern.String())
+}
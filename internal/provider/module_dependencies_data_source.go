@@ -0,0 +1,126 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &ModuleDependenciesDataSource{}
+var _ datasource.DataSourceWithConfigure = &ModuleDependenciesDataSource{}
+
+type ModuleDependenciesDataSource struct {
+	modulesJsonPath string
+	modulesJson     *modulesJsonCache
+}
+
+func NewModuleDependenciesDataSource() datasource.DataSource {
+	return &ModuleDependenciesDataSource{}
+}
+
+type ModuleDependenciesDataSourceModel struct {
+	ModuleKey    types.String        `tfsdk:"module_key"`
+	ModulePath   types.String        `tfsdk:"module_path"`
+	Dependencies []ModulesEntryModel `tfsdk:"dependencies"`
+}
+
+func (m *ModuleDependenciesDataSource) Metadata(ctx context.Context, request datasource.MetadataRequest, response *datasource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_module_dependencies"
+}
+
+func (m *ModuleDependenciesDataSource) Schema(ctx context.Context, request datasource.SchemaRequest, response *datasource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		MarkdownDescription: "`modtm_module_dependencies` data source returns the modules called directly or transitively by a module, read from `modules.json` file in `.terraform/modules` folder, so a composition could report the full dependency tree of a module with a single data source. The descendants are found by the `Key` of the module's entry in `modules.json`, e.g. `kv.keys` and `kv.secrets` for `kv`.",
+		Attributes: map[string]schema.Attribute{
+			"module_key": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "The `Key` of the module in `modules.json`, e.g. `kv` for `module.kv`. Exactly one of `module_key` and `module_path` must be set.",
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("module_path")),
+				},
+			},
+			"module_path": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "The path of the module, usually `${path.module}`, it's resolved to the module's `Key` in `modules.json`.",
+			},
+			"dependencies": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "The descendant modules, ordered by `key`. Empty when the module has no child modules or cannot be found in `modules.json`.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"key": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Key` of the module in `modules.json`",
+						},
+						"source": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Source` of the module",
+						},
+						"version": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Version` of the module, empty for modules that are not installed from a registry",
+						},
+						"dir": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Dir` of the module",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (m *ModuleDependenciesDataSource) Configure(ctx context.Context, request datasource.ConfigureRequest, response *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if request.ProviderData == nil {
+		return
+	}
+
+	c, ok := request.ProviderData.(providerConfig)
+
+	if !ok {
+		response.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected providerConfig, got: %T. Please report this issue to the provider developers.", request.ProviderData),
+		)
+
+		return
+	}
+
+	m.modulesJsonPath = c.modulesJsonPath
+	m.modulesJson = c.modulesJson
+}
+
+func (m *ModuleDependenciesDataSource) Read(ctx context.Context, request datasource.ReadRequest, response *datasource.ReadResponse) {
+	data := &ModuleDependenciesDataSourceModel{}
+	response.Diagnostics.Append(request.Config.Get(ctx, data)...)
+
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	data.Dependencies = make([]ModulesEntryModel, 0)
+	if modules, err := m.modulesJson.readModulesJson(m.modulesJsonPath); err == nil {
+		var module *modulesJsonModulesModel
+		if data.ModulePath.IsNull() {
+			module = modules.findByKey(data.ModuleKey.ValueString())
+		} else {
+			module = modules.findByDir(data.ModulePath.ValueString())
+		}
+		if module != nil {
+			data.Dependencies = newModulesEntryModels(modules.descendants(module.Key))
+		}
+	}
+	traceLog(ctx, fmt.Sprintf("read module dependencies for key %s and path %s, found %d modules", data.ModuleKey.String(), data.ModulePath.String(), len(data.Dependencies)))
+	response.Diagnostics.Append(response.State.Set(ctx, data)...)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccModuleDependenciesDataSource(t *testing.T) {
+	require.NoError(t, createModulesJson())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccModuleDependenciesDataSourceConfig(`module_key = "kv"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_module_dependencies.test", "dependencies.#", "2"),
+					resource.TestCheckResourceAttr("data.modtm_module_dependencies.test", "dependencies.0.key", "kv.keys"),
+					resource.TestCheckResourceAttr("data.modtm_module_dependencies.test", "dependencies.0.source", "./modules/key"),
+					resource.TestCheckResourceAttr("data.modtm_module_dependencies.test", "dependencies.1.key", "kv.secrets"),
+				),
+			},
+			{
+				Config: testAccModuleDependenciesDataSourceConfig(`module_path = ".terraform/modules/kv"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_module_dependencies.test", "dependencies.#", "2"),
+				),
+			},
+			{
+				Config: testAccModuleDependenciesDataSourceConfig(`module_key = "keys"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_module_dependencies.test", "dependencies.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestModulesJsonDescendants(t *testing.T) {
+	modules := &modulesJsonModel{
+		Modules: []modulesJsonModulesModel{
+			{Key: "", Dir: "."},
+			{Key: "a.b.c", Source: "./modules/c", Dir: ".terraform/modules/a/modules/b/modules/c"},
+			{Key: "a", Source: "registry.terraform.io/foo/a/azurerm", Version: "1.0.0", Dir: ".terraform/modules/a"},
+			{Key: "a.b", Source: "./modules/b", Dir: ".terraform/modules/a/modules/b"},
+			{Key: "ab", Source: "./modules/ab", Dir: "modules/ab"},
+		},
+	}
+	var keys []string
+	for _, m := range modules.descendants("a") {
+		keys = append(keys, m.Key)
+	}
+	require.Equal(t, []string{"a.b", "a.b.c"}, keys)
+	require.Empty(t, modules.descendants("a.b.c"))
+	require.Len(t, modules.descendants(""), 4)
+}
+
+func testAccModuleDependenciesDataSourceConfig(argument string) string {
+	return `
+provider "modtm" {
+  enabled = false
+  module_source_regex = ["foo"]
+}
+
+data "modtm_module_dependencies" "test" {
+  ` + argument + `
+}
+`
+}
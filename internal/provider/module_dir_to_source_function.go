@@ -0,0 +1,53 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &ModuleDirToSourceFunction{}
+
+func NewModuleDirToSourceFunction(modulesJson *modulesJsonCache) function.Function {
+	return &ModuleDirToSourceFunction{modulesJson: modulesJson}
+}
+
+type ModuleDirToSourceFunction struct {
+	modulesJson *modulesJsonCache
+}
+
+func (m *ModuleDirToSourceFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "module_dir_to_source"
+}
+
+func (m *ModuleDirToSourceFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "`module_dir_to_source` function",
+		MarkdownDescription: "This function takes in any directory and return the `Source` of the item in `modules.json` file in the current root module's `.terraform/module` folder whose `Dir` refers to the same directory. " +
+			"Unlike `module_source`, the directory doesn't have to be `${path.module}`: absolute paths are resolved relative to the root module's directory and paths are cleaned before matching, so `./.terraform/modules/kv/` and `.terraform/modules/kv` resolve to the same entry. " +
+			"Returns an empty string when no entry matches.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "dir",
+				MarkdownDescription: "The directory to resolve, either absolute or relative to the root module's directory",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (m *ModuleDirToSourceFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var dir string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &dir))
+	if resp.Error != nil {
+		return
+	}
+	s := ""
+	if module, err := m.modulesJson.parseModulesJson("", dir); err == nil {
+		s = module.Source
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, s))
+}
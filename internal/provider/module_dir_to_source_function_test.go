@@ -0,0 +1,71 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccModuleDirToSourceFunction(t *testing.T) {
+	require.NoError(t, createModulesJson())
+	absDir, err := filepath.Abs(".terraform/modules/kv")
+	require.NoError(t, err)
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccModuleDirToSourceFunctionConfig(absDir),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm"),
+				),
+			},
+			{
+				Config: testAccModuleDirToSourceFunctionConfig("./.terraform/modules/kv/modules/key/"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "./modules/key"),
+				),
+			},
+			{
+				Config: testAccModuleDirToSourceFunctionConfig(".terraform/modules/nonexistent"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestNormalizeModuleDir(t *testing.T) {
+	wd, err := filepath.Abs(".")
+	require.NoError(t, err)
+	cases := map[string]string{
+		".terraform/modules/kv":                    ".terraform/modules/kv",
+		"./.terraform/modules/kv/":                 ".terraform/modules/kv",
+		".terraform/modules/kv/../kv/modules/key":  ".terraform/modules/kv/modules/key",
+		filepath.Join(wd, ".terraform/modules/kv"): ".terraform/modules/kv",
+		wd: ".",
+		"": ".",
+	}
+	for input, expected := range cases {
+		require.Equal(t, expected, normalizeModuleDir(input, ""), input)
+	}
+}
+
+func testAccModuleDirToSourceFunctionConfig(dir string) string {
+	return fmt.Sprintf(`
+output "test" {
+  value = provider::modtm::module_dir_to_source(%q)
+}
+`, dir)
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &ModuleKeyFunction{}
+
+func NewModuleKeyFunction(modulesJson *modulesJsonCache) function.Function {
+	return &ModuleKeyFunction{modulesJson: modulesJson}
+}
+
+type ModuleKeyFunction struct {
+	modulesJson *modulesJsonCache
+}
+
+func (m *ModuleKeyFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "module_key"
+}
+
+func (m *ModuleKeyFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "`module_key` function",
+		MarkdownDescription: "This function takes in `${path.module}` and return the corresponding item's `Key` in `modules.json` file in the current root module's `.terraform/module` folder, e.g. `kv.keys` for `module.kv.module.keys`, so it could be correlated with the module's address in the state. " +
+			"Returns an empty string for the root module or when no entry matches.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "module_path",
+				MarkdownDescription: "`${path.module}`",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (m *ModuleKeyFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var modulePath string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &modulePath))
+	if resp.Error != nil {
+		return
+	}
+	s := ""
+	if module, err := m.modulesJson.parseModulesJson("", modulePath); err == nil {
+		s = module.Key
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, s))
+}
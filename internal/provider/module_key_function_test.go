@@ -0,0 +1,52 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccModuleKeyFunction(t *testing.T) {
+	require.NoError(t, createModulesJson())
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccModuleKeyFunctionConfig(".terraform/modules/kv/modules/key"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "kv.keys"),
+				),
+			},
+			{
+				Config: testAccModuleKeyFunctionConfig("./.terraform/modules/kv/"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "kv"),
+				),
+			},
+			{
+				Config: testAccModuleKeyFunctionConfig(".terraform/modules/unknown"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", ""),
+				),
+			},
+		},
+	})
+}
+
+func testAccModuleKeyFunctionConfig(modulePath string) string {
+	return fmt.Sprintf(`
+output "test" {
+  value = provider::modtm::module_key("%s")
+}
+`, modulePath)
+}
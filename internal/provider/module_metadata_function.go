@@ -0,0 +1,76 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ function.Function = &ModuleMetadataFunction{}
+
+func NewModuleMetadataFunction(modulesJson *modulesJsonCache, telemetry *functionTelemetry) function.Function {
+	return &ModuleMetadataFunction{telemetry: telemetry, modulesJson: modulesJson}
+}
+
+type ModuleMetadataFunction struct {
+	telemetry   *functionTelemetry
+	modulesJson *modulesJsonCache
+}
+
+// moduleMetadata is the result of the `module_metadata` function, the fields of a modules.json entry.
+type moduleMetadata struct {
+	Source  string `tfsdk:"source"`
+	Version string `tfsdk:"version"`
+	Key     string `tfsdk:"key"`
+	Dir     string `tfsdk:"dir"`
+}
+
+func (m *ModuleMetadataFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "module_metadata"
+}
+
+func (m *ModuleMetadataFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "`module_metadata` function",
+		MarkdownDescription: "This function takes in `${path.module}` and returns an object with the `source`, `version`, `key` and `dir` of the corresponding item in `modules.json` file in the current root module's `.terraform/module` folder, so module authors get everything with one call and one read of the file instead of calling `module_source` and `module_version` separately. " +
+			"All attributes are empty strings when no item matches, and `version` is empty for local modules.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "module_path",
+				MarkdownDescription: "`${path.module}`",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: map[string]attr.Type{
+				"source":  types.StringType,
+				"version": types.StringType,
+				"key":     types.StringType,
+				"dir":     types.StringType,
+			},
+		},
+	}
+}
+
+func (m *ModuleMetadataFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var modulePath string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &modulePath))
+	if resp.Error != nil {
+		return
+	}
+	metadata := moduleMetadata{}
+	if module, err := m.modulesJson.parseModulesJson("", modulePath); err == nil {
+		metadata = moduleMetadata{
+			Source:  module.Source,
+			Version: module.Version,
+			Key:     module.Key,
+			Dir:     module.Dir,
+		}
+	}
+	m.telemetry.send(ctx, "module_metadata", metadata.Source, metadata.Version)
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, metadata))
+}
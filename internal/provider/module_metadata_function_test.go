@@ -0,0 +1,75 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccModuleMetadataFunction(t *testing.T) {
+	require.NoError(t, createModulesJson())
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccModuleMetadataFunctionConfig(".terraform/modules/keys/modules/key"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("source", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm//modules/key"),
+					resource.TestCheckOutput("version", "0.6.1"),
+					resource.TestCheckOutput("key", "keys"),
+					resource.TestCheckOutput("dir", ".terraform/modules/keys/modules/key"),
+				),
+			},
+			{
+				Config: testAccModuleMetadataFunctionConfig(".terraform/modules/kv/modules/key"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("source", "./modules/key"),
+					resource.TestCheckOutput("version", ""),
+					resource.TestCheckOutput("key", "kv.keys"),
+					resource.TestCheckOutput("dir", ".terraform/modules/kv/modules/key"),
+				),
+			},
+			{
+				Config: testAccModuleMetadataFunctionConfig(".terraform/modules/unknown"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("source", ""),
+					resource.TestCheckOutput("key", ""),
+				),
+			},
+		},
+	})
+}
+
+func testAccModuleMetadataFunctionConfig(modulePath string) string {
+	return fmt.Sprintf(`
+locals {
+  metadata = provider::modtm::module_metadata("%s")
+}
+
+output "source" {
+  value = local.metadata.source
+}
+
+output "version" {
+  value = local.metadata.version
+}
+
+output "key" {
+  value = local.metadata.key
+}
+
+output "dir" {
+  value = local.metadata.dir
+}
+`, modulePath)
+}
@@ -0,0 +1,123 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &ModuleParentsDataSource{}
+var _ datasource.DataSourceWithConfigure = &ModuleParentsDataSource{}
+
+type ModuleParentsDataSource struct {
+	modulesJsonPath string
+	modulesJson     *modulesJsonCache
+}
+
+func NewModuleParentsDataSource() datasource.DataSource {
+	return &ModuleParentsDataSource{}
+}
+
+type ModuleParentsDataSourceModel struct {
+	ModulePath types.String        `tfsdk:"module_path"`
+	Parents    []ModuleParentModel `tfsdk:"parents"`
+}
+
+type ModuleParentModel struct {
+	Key     types.String `tfsdk:"key"`
+	Source  types.String `tfsdk:"source"`
+	Version types.String `tfsdk:"version"`
+	Dir     types.String `tfsdk:"dir"`
+}
+
+func (m *ModuleParentsDataSource) Metadata(ctx context.Context, request datasource.MetadataRequest, response *datasource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_module_parents"
+}
+
+func (m *ModuleParentsDataSource) Schema(ctx context.Context, request datasource.SchemaRequest, response *datasource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		MarkdownDescription: "`modtm_module_parents` data source returns the chain of parent modules of the current module. The chain is computed from the `Key` of the module's entry in `modules.json` file in `.terraform/modules` folder, so it could be used to compose hierarchical telemetry tags.",
+		Attributes: map[string]schema.Attribute{
+			"module_path": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "The path of the module whose parents should be resolved, usually `${path.module}`.",
+			},
+			"parents": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "The parent modules, ordered from the outermost module called by the root module to the immediate parent of the current module. The root module itself is not included. Empty when the module is called directly by the root module or cannot be found in `modules.json`.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"key": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Key` of the parent module in `modules.json`",
+						},
+						"source": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Source` of the parent module",
+						},
+						"version": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Version` of the parent module, empty for modules that are not installed from a registry",
+						},
+						"dir": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Dir` of the parent module",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (m *ModuleParentsDataSource) Configure(ctx context.Context, request datasource.ConfigureRequest, response *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if request.ProviderData == nil {
+		return
+	}
+
+	c, ok := request.ProviderData.(providerConfig)
+
+	if !ok {
+		response.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected providerConfig, got: %T. Please report this issue to the provider developers.", request.ProviderData),
+		)
+
+		return
+	}
+
+	m.modulesJsonPath = c.modulesJsonPath
+	m.modulesJson = c.modulesJson
+}
+
+func (m *ModuleParentsDataSource) Read(ctx context.Context, request datasource.ReadRequest, response *datasource.ReadResponse) {
+	data := &ModuleParentsDataSourceModel{}
+	response.Diagnostics.Append(request.Config.Get(ctx, data)...)
+
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	data.Parents = make([]ModuleParentModel, 0)
+	if modules, err := m.modulesJson.readModulesJson(m.modulesJsonPath); err == nil {
+		if module := modules.findByDir(data.ModulePath.ValueString()); module != nil {
+			for _, parent := range modules.parents(module.Key) {
+				data.Parents = append(data.Parents, ModuleParentModel{
+					Key:     types.StringValue(parent.Key),
+					Source:  types.StringValue(parent.Source),
+					Version: types.StringValue(parent.Version),
+					Dir:     types.StringValue(parent.Dir),
+				})
+			}
+		}
+	}
+	traceLog(ctx, fmt.Sprintf("read module parents for path %s, found %d parents", data.ModulePath.String(), len(data.Parents)))
+	response.Diagnostics.Append(response.State.Set(ctx, data)...)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccModuleParentsDataSource(t *testing.T) {
+	require.NoError(t, createModulesJson())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccModuleParentsDataSourceConfig(".terraform/modules/kv/modules/key"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_module_parents.test", "parents.#", "1"),
+					resource.TestCheckResourceAttr("data.modtm_module_parents.test", "parents.0.key", "kv"),
+					resource.TestCheckResourceAttr("data.modtm_module_parents.test", "parents.0.source", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm"),
+					resource.TestCheckResourceAttr("data.modtm_module_parents.test", "parents.0.version", "0.6.1"),
+					resource.TestCheckResourceAttr("data.modtm_module_parents.test", "parents.0.dir", ".terraform/modules/kv"),
+				),
+			},
+			{
+				Config: testAccModuleParentsDataSourceConfig(".terraform/modules/kv"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_module_parents.test", "parents.#", "0"),
+				),
+			},
+			{
+				Config: testAccModuleParentsDataSourceConfig(".terraform/modules/nonexistent"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_module_parents.test", "parents.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestModulesJsonParents(t *testing.T) {
+	modules := &modulesJsonModel{
+		Modules: []modulesJsonModulesModel{
+			{Key: "", Dir: "."},
+			{Key: "a", Source: "registry.terraform.io/foo/a/azurerm", Version: "1.0.0", Dir: ".terraform/modules/a"},
+			{Key: "a.b", Source: "./modules/b", Dir: ".terraform/modules/a/modules/b"},
+			{Key: "a.b.c", Source: "./modules/c", Dir: ".terraform/modules/a/modules/b/modules/c"},
+		},
+	}
+	parents := modules.parents("a.b.c")
+	require.Len(t, parents, 2)
+	require.Equal(t, "a", parents[0].Key)
+	require.Equal(t, "a.b", parents[1].Key)
+	require.Empty(t, modules.parents("a"))
+	require.Empty(t, modules.parents(""))
+}
+
+func testAccModuleParentsDataSourceConfig(modulePath string) string {
+	return fmt.Sprintf(`
+provider "modtm" {
+  enabled = false
+  module_source_regex = ["foo"]
+}
+
+data "modtm_module_parents" "test" {
+  module_path = "%s"
+}
+`, modulePath)
+}
@@ -0,0 +1,170 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// defaultRegistryHost is the host of registry sources that don't specify one, e.g. `Azure/avm-res-keyvault-vault/azurerm`.
+const defaultRegistryHost = "registry.terraform.io"
+
+const (
+	moduleSourceKindRegistry = "registry"
+	moduleSourceKindLocal    = "local"
+	moduleSourceKindGit      = "git"
+	moduleSourceKindHg       = "hg"
+	moduleSourceKindHttp     = "http"
+	moduleSourceKindS3       = "s3"
+	moduleSourceKindGcs      = "gcs"
+	moduleSourceKindOther    = "other"
+)
+
+var moduleSourceKinds = []string{moduleSourceKindRegistry, moduleSourceKindLocal, moduleSourceKindGit, moduleSourceKindHg, moduleSourceKindHttp, moduleSourceKindS3, moduleSourceKindGcs, moduleSourceKindOther}
+
+// registrySource is a module registry source address split into its components.
+type registrySource struct {
+	Host         string
+	Namespace    string
+	Name         string
+	TargetSystem string
+	// Submodule is the path after `//`, empty when the source refers to the root module of the package.
+	Submodule string
+}
+
+var (
+	registryNamespaceOrName = regexp.MustCompile(`^[0-9A-Za-z](?:[0-9A-Za-z_-]{0,62}[0-9A-Za-z])?$`)
+	registryTargetSystem    = regexp.MustCompile(`^[0-9a-z]{1,64}$`)
+	// registryHost matches host names with at least one dot, and an optional port.
+	registryHost = regexp.MustCompile(`^[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)+(?::[0-9]+)?$`)
+)
+
+// parseRegistrySource parses a module source as a public or private registry address,
+// `[<host>/]<namespace>/<name>/<target_system>[//<submodule>]`, following Terraform's rules: `github.com` and
+// `bitbucket.org` are not registry hosts since Terraform treats these sources as git shorthands, and local paths,
+// URLs and sources with a forced getter like `git::` are never registry sources. Returns false if source is not a
+// registry source.
+func parseRegistrySource(source string) (registrySource, bool) {
+	if source == "" || strings.Contains(source, "::") || strings.Contains(source, "://") || strings.ContainsAny(source, "?\\") ||
+		strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") || strings.HasPrefix(source, "/") {
+		return registrySource{}, false
+	}
+	pkg, submodule, _ := strings.Cut(source, "//")
+	segments := strings.Split(pkg, "/")
+	r := registrySource{Host: defaultRegistryHost, Submodule: submodule}
+	switch len(segments) {
+	case 3:
+	case 4:
+		r.Host = strings.ToLower(segments[0])
+		if !registryHost.MatchString(r.Host) || r.Host == "github.com" || r.Host == "bitbucket.org" {
+			return registrySource{}, false
+		}
+		segments = segments[1:]
+	default:
+		return registrySource{}, false
+	}
+	if !registryNamespaceOrName.MatchString(segments[0]) || !registryNamespaceOrName.MatchString(segments[1]) || !registryTargetSystem.MatchString(segments[2]) {
+		return registrySource{}, false
+	}
+	r.Namespace, r.Name, r.TargetSystem = segments[0], segments[1], segments[2]
+	return r, true
+}
+
+// moduleSourceAddress is a module source split into its components, the registry components are null unless kind is
+// `registry`.
+type moduleSourceAddress struct {
+	Kind         string       `tfsdk:"kind"`
+	Host         types.String `tfsdk:"host"`
+	Namespace    types.String `tfsdk:"namespace"`
+	Name         types.String `tfsdk:"name"`
+	TargetSystem types.String `tfsdk:"target_system"`
+	Submodule    types.String `tfsdk:"submodule"`
+}
+
+// forcedGetterKinds are the kinds of the sources with a forced getter, e.g. `git::https://...`.
+var forcedGetterKinds = map[string]string{
+	"git":   moduleSourceKindGit,
+	"hg":    moduleSourceKindHg,
+	"http":  moduleSourceKindHttp,
+	"https": moduleSourceKindHttp,
+	"s3":    moduleSourceKindS3,
+	"gcs":   moduleSourceKindGcs,
+}
+
+// parseModuleSource classifies a module source the way Terraform installs it. Registry sources are split into their
+// components, the other remote sources only get their `//` submodule path. Local paths, which start with `./` or
+// `../`, have no components.
+func parseModuleSource(source string) (moduleSourceAddress, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return moduleSourceAddress{}, fmt.Errorf("invalid module source: the source is empty")
+	}
+	address := moduleSourceAddress{
+		Host:         types.StringNull(),
+		Namespace:    types.StringNull(),
+		Name:         types.StringNull(),
+		TargetSystem: types.StringNull(),
+		Submodule:    types.StringNull(),
+	}
+	if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") || source == "." || source == ".." {
+		address.Kind = moduleSourceKindLocal
+		return address, nil
+	}
+	if r, ok := parseRegistrySource(source); ok {
+		address.Kind = moduleSourceKindRegistry
+		address.Host = types.StringValue(r.Host)
+		address.Namespace = types.StringValue(r.Namespace)
+		address.Name = types.StringValue(r.Name)
+		address.TargetSystem = types.StringValue(r.TargetSystem)
+		if r.Submodule != "" {
+			address.Submodule = types.StringValue(r.Submodule)
+		}
+		return address, nil
+	}
+	address.Kind = remoteModuleSourceKind(source)
+	if submodule := remoteSubmodule(source); submodule != "" {
+		address.Submodule = types.StringValue(submodule)
+	}
+	return address, nil
+}
+
+// remoteModuleSourceKind detects the getter that Terraform uses for a non-registry source.
+func remoteModuleSourceKind(source string) string {
+	if getter, _, ok := strings.Cut(source, "::"); ok {
+		if kind, ok := forcedGetterKinds[strings.ToLower(getter)]; ok {
+			return kind
+		}
+		return moduleSourceKindOther
+	}
+	lower := strings.ToLower(source)
+	switch {
+	case strings.HasPrefix(lower, "github.com/"), strings.HasPrefix(lower, "bitbucket.org/"), strings.HasPrefix(lower, "git@"):
+		return moduleSourceKindGit
+	case strings.Contains(lower, "amazonaws.com/"):
+		return moduleSourceKindS3
+	case strings.HasPrefix(lower, "www.googleapis.com/storage/"):
+		return moduleSourceKindGcs
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		return moduleSourceKindHttp
+	}
+	return moduleSourceKindOther
+}
+
+// remoteSubmodule returns the path after `//` in a remote source, without the query string, e.g. `modules/vnet` for
+// `git::https://example.com/network.git//modules/vnet?ref=v1.2.0`.
+func remoteSubmodule(source string) string {
+	if _, rest, ok := strings.Cut(source, "::"); ok {
+		source = rest
+	}
+	source, _, _ = strings.Cut(source, "?")
+	if _, rest, ok := strings.Cut(source, "://"); ok {
+		source = rest
+	}
+	_, submodule, _ := strings.Cut(source, "//")
+	return strings.Trim(submodule, "/")
+}
@@ -0,0 +1,89 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRegistrySource(t *testing.T) {
+	cases := []struct {
+		source   string
+		expected registrySource
+	}{
+		{source: "Azure/avm-res-keyvault-vault/azurerm", expected: registrySource{Host: "registry.terraform.io", Namespace: "Azure", Name: "avm-res-keyvault-vault", TargetSystem: "azurerm"}},
+		{source: "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm//modules/key", expected: registrySource{Host: "registry.terraform.io", Namespace: "Azure", Name: "avm-res-keyvault-vault", TargetSystem: "azurerm", Submodule: "modules/key"}},
+		{source: "app.terraform.io/contoso/network/azurerm", expected: registrySource{Host: "app.terraform.io", Namespace: "contoso", Name: "network", TargetSystem: "azurerm"}},
+		{source: "tfe.contoso.com:8443/contoso/network/azurerm", expected: registrySource{Host: "tfe.contoso.com:8443", Namespace: "contoso", Name: "network", TargetSystem: "azurerm"}},
+	}
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			r, ok := parseRegistrySource(c.source)
+			require.True(t, ok)
+			assert.Equal(t, c.expected, r)
+		})
+	}
+}
+
+func TestParseRegistrySource_notRegistry(t *testing.T) {
+	for _, source := range []string{
+		"",
+		"./modules/key",
+		"../network",
+		"/opt/modules/network",
+		"github.com/Azure/terraform-azurerm-aks",
+		"bitbucket.org/contoso/network/azurerm",
+		"git::https://github.com/Azure/terraform-azurerm-aks.git",
+		"git@github.com:Azure/terraform-azurerm-aks.git",
+		"https://example.com/network.zip",
+		"s3::https://s3.amazonaws.com/bucket/network.zip",
+		"Azure/avm-res-keyvault-vault/azurerm?ref=v1",
+		"Azure/avm-res-keyvault-vault/AzureRM",
+		"contoso/network/azurerm/extra/segment",
+		"localhost/contoso/network/azurerm",
+	} {
+		_, ok := parseRegistrySource(source)
+		assert.False(t, ok, source)
+	}
+}
+
+func TestParseModuleSource(t *testing.T) {
+	cases := map[string]struct {
+		kind      string
+		submodule string
+	}{
+		"./modules/key": {kind: "local"},
+		"../network":    {kind: "local"},
+		"Azure/avm-res-keyvault-vault/azurerm//modules/key":                 {kind: "registry", submodule: "modules/key"},
+		"github.com/Azure/terraform-azurerm-aks//modules/node_pool":         {kind: "git", submodule: "modules/node_pool"},
+		"git@github.com:Azure/terraform-azurerm-aks.git//modules/node_pool": {kind: "git", submodule: "modules/node_pool"},
+		"git::https://example.com/network.git//modules/vnet?ref=v1.2.0":     {kind: "git", submodule: "modules/vnet"},
+		"hg::http://example.com/network.hg":                                 {kind: "hg"},
+		"https://example.com/network.zip":                                   {kind: "http"},
+		"s3::https://s3-eu-west-1.amazonaws.com/bucket/network.zip":         {kind: "s3"},
+		"bucket.s3-eu-west-1.amazonaws.com/network.zip":                     {kind: "s3"},
+		"gcs::https://www.googleapis.com/storage/v1/bucket/network.zip":     {kind: "gcs"},
+		"www.googleapis.com/storage/v1/bucket/network.zip//modules/vnet":    {kind: "gcs", submodule: "modules/vnet"},
+		"file::/opt/modules/network":                                        {kind: "other"},
+		"/opt/modules/network":                                              {kind: "other"},
+	}
+	for source, c := range cases {
+		t.Run(source, func(t *testing.T) {
+			address, err := parseModuleSource(source)
+			require.NoError(t, err)
+			assert.Equal(t, c.kind, address.Kind)
+			if c.submodule == "" {
+				assert.True(t, address.Submodule.IsNull())
+			} else {
+				assert.Equal(t, c.submodule, address.Submodule.ValueString())
+			}
+			assert.Equal(t, c.kind != "registry", address.Namespace.IsNull())
+		})
+	}
+	_, err := parseModuleSource(" ")
+	assert.Error(t, err)
+}
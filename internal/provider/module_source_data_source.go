@@ -13,8 +13,14 @@ import (
 )
 
 var _ datasource.DataSource = &ModuleSourceDataSource{}
+var _ datasource.DataSourceWithConfigure = &ModuleSourceDataSource{}
 
-type ModuleSourceDataSource struct{}
+type ModuleSourceDataSource struct {
+	modulesJsonPath string
+	modulesJson     *modulesJsonCache
+	// strictModuleResolution returns a warning when the module source and version cannot be resolved.
+	strictModuleResolution bool
+}
 
 func NewModuleSourceDataSource() datasource.DataSource {
 	return &ModuleSourceDataSource{}
@@ -26,6 +32,7 @@ type ModuleSourceDataSourceModel struct {
 	ModulePath    types.String `tfsdk:"module_path"`
 	ModuleVersion types.String `tfsdk:"module_version"`
 	ModuleSource  types.String `tfsdk:"module_source"`
+	FallbackToGit types.Bool   `tfsdk:"fallback_to_git"`
 }
 
 func (m *ModuleSourceDataSourceModel) GetModuleVersion() types.String {
@@ -68,10 +75,36 @@ func (m *ModuleSourceDataSource) Schema(ctx context.Context, request datasource.
 				Computed:            true,
 				MarkdownDescription: "The source of the module that the telemetry resource is associated with",
 			},
+			"fallback_to_git": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Read the git metadata of `module_path` when `modules.json` has no entry for it, e.g. in local development or before `terraform init` finishes. `module_source` is then the `origin` remote URL prefixed by `git::`, `module_version` is the short commit of `HEAD`, suffixed by `-dirty` when tracked files are modified. It requires the git CLI. Defaults to `false`.",
+			},
 		},
 	}
 }
 
+func (m *ModuleSourceDataSource) Configure(ctx context.Context, request datasource.ConfigureRequest, response *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if request.ProviderData == nil {
+		return
+	}
+
+	c, ok := request.ProviderData.(providerConfig)
+
+	if !ok {
+		response.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected providerConfig, got: %T. Please report this issue to the provider developers.", request.ProviderData),
+		)
+
+		return
+	}
+
+	m.modulesJsonPath = c.modulesJsonPath
+	m.modulesJson = c.modulesJson
+	m.strictModuleResolution = c.strictModuleResolution
+}
+
 func (m *ModuleSourceDataSource) Read(ctx context.Context, request datasource.ReadRequest, response *datasource.ReadResponse) {
 	data := &ModuleSourceDataSourceModel{}
 	response.Diagnostics.Append(request.Config.Get(ctx, data)...)
@@ -80,7 +113,18 @@ func (m *ModuleSourceDataSource) Read(ctx context.Context, request datasource.Re
 		return
 	}
 
-	data = withModuleSourceAndVersion(data)
+	data, err := withModuleSourceAndVersion(ctx, data, m.modulesJson, m.modulesJsonPath)
+	if data.ModuleSource.IsNull() && data.FallbackToGit.ValueBool() && !data.ModulePath.IsUnknown() {
+		info, gitErr := readGitModuleInfo(ctx, data.ModulePath.ValueString())
+		if gitErr != nil {
+			traceLog(ctx, fmt.Sprintf("cannot read git metadata for path %s: %s", data.ModulePath.String(), gitErr.Error()))
+		} else {
+			data.ModuleSource = types.StringValue(info.source())
+			data.ModuleVersion = types.StringValue(info.version())
+			err = nil
+		}
+	}
+	response.Diagnostics.Append(moduleResolutionDiagnostics(err, m.strictModuleResolution)...)
 	traceLog(ctx, fmt.Sprintf("read module source for path %s, source: %s, version: %s", data.ModulePath.String(), data.ModuleSource.String(), data.ModuleVersion.String()))
 	response.Diagnostics.Append(response.State.Set(ctx, data)...)
 }
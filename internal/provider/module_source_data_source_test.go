@@ -8,6 +8,8 @@ import (
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/stretchr/testify/require"
 	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
 )
 
@@ -76,13 +78,94 @@ data "modtm_module_source" "test" {
 `, modulePath)
 }
 
+func TestAccModuleSourceDataSource_modulesJsonPath(t *testing.T) {
+	dataDir := t.TempDir()
+	require.NoError(t, createModulesJsonIn(dataDir))
+	modulesJsonFile := filepath.Join(dataDir, "modules", "modules.json")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccModuleSourceDataSourceWithModulesJsonPathConfig(modulesJsonFile, ".terraform/modules/kv"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_module_source.test", "module_source", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm"),
+					resource.TestCheckResourceAttr("data.modtm_module_source.test", "module_version", "0.6.1"),
+				),
+			},
+			{
+				Config: testAccModuleSourceDataSourceWithModulesJsonPathConfig(dataDir, ".terraform/modules/keys/modules/key"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_module_source.test", "module_source", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm//modules/key"),
+					resource.TestCheckResourceAttr("data.modtm_module_source.test", "module_version", "0.6.1"),
+				),
+			},
+			{
+				Config: testAccModuleSourceDataSourceWithModulesJsonPathConfig(filepath.Join(dataDir, "nonexistent.json"), ".terraform/modules/kv"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("data.modtm_module_source.test", "module_source"),
+					resource.TestCheckNoResourceAttr("data.modtm_module_source.test", "module_version"),
+				),
+			},
+		},
+	})
+}
+
+func testAccModuleSourceDataSourceWithModulesJsonPathConfig(modulesJsonPath, modulePath string) string {
+	return fmt.Sprintf(`
+provider "modtm" {
+  enabled             = false
+  module_source_regex = ["foo"]
+  modules_json_path   = %q
+}
+
+data "modtm_module_source" "test" {
+  module_path = "%s"
+}
+`, modulesJsonPath, modulePath)
+}
+
+func TestAccModuleSourceDataSource_fallbackToGit(t *testing.T) {
+	moduleDir := initGitModule(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  enabled = false
+  module_source_regex = ["foo"]
+}
+
+data "modtm_module_source" "test" {
+  module_path     = %q
+  fallback_to_git = true
+}
+`, moduleDir),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_module_source.test", "module_source", "git::https://github.com/Azure/terraform-azurerm-avm-res-keyvault-vault.git"),
+					resource.TestMatchResourceAttr("data.modtm_module_source.test", "module_version", regexp.MustCompile(`^[0-9a-f]{7,}$`)),
+				),
+			},
+		},
+	})
+}
+
 // createModulesJson creates a modules.json file with a reference to a standard module (kv) and
 // reference to a child module of the key vault module (keys) in the root module.
 func createModulesJson() error {
-	if err := os.MkdirAll(".terraform/modules", 0755); err != nil {
+	return createModulesJsonIn(".terraform")
+}
+
+// createModulesJsonIn creates the modules.json file described in createModulesJson under dataDir.
+func createModulesJsonIn(dataDir string) error {
+	if err := os.MkdirAll(filepath.Join(dataDir, "modules"), 0755); err != nil {
 		return err
 	}
-	f, err := os.Create(".terraform/modules/modules.json")
+	f, err := os.Create(filepath.Join(dataDir, "modules", "modules.json"))
 	if err != nil {
 		return err
 	}
@@ -9,11 +9,13 @@ import (
 
 var _ function.Function = &ModuleSourceFunction{}
 
-func NewModuleSourceFunction() function.Function {
-	return &ModuleSourceFunction{}
+func NewModuleSourceFunction(modulesJson *modulesJsonCache, telemetry *functionTelemetry) function.Function {
+	return &ModuleSourceFunction{telemetry: telemetry, modulesJson: modulesJson}
 }
 
 type ModuleSourceFunction struct {
+	telemetry   *functionTelemetry
+	modulesJson *modulesJsonCache
 }
 
 func (m *ModuleSourceFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
@@ -42,7 +44,8 @@ func (m *ModuleSourceFunction) Run(ctx context.Context, req function.RunRequest,
 	}
 	model := &ModuleSourceDataSourceModel{}
 	model.ModulePath = types.StringValue(modulePath)
-	model = withModuleSourceAndVersion(model)
+	model, _ = withModuleSourceAndVersion(ctx, model, m.modulesJson, "")
 	s := model.ModuleSource.ValueString()
+	m.telemetry.send(ctx, "module_source", model.ModuleSource.ValueString(), model.ModuleVersion.ValueString())
 	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, s))
 }
@@ -4,6 +4,11 @@
 package provider
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 )
@@ -17,16 +22,33 @@ type moduleSource interface {
 }
 
 // withModuleSourceAndVersion updates the module source and version based on the module path.
-func withModuleSourceAndVersion[T moduleSource](data T) T {
+// modulesJsonPath overrides the location of modules.json file, an empty string means the default location.
+// The source and version are null when they cannot be resolved, the error describes why and is written to the
+// trace logs with the modules.json path tried.
+func withModuleSourceAndVersion[T moduleSource](ctx context.Context, data T, cache *modulesJsonCache, modulesJsonPath string) (T, error) {
 	data.SetModuleSource(basetypes.NewStringNull())
 	data.SetModuleVersion(basetypes.NewStringNull())
 	if !data.GetModulePath().IsNull() && !data.GetModulePath().IsUnknown() {
-		module, err := parseModulesJson(data.GetModulePath().ValueString())
+		module, err := cache.parseModulesJson(modulesJsonPath, data.GetModulePath().ValueString())
 		if err != nil {
-			return data
+			tried, _ := locateModulesJson(modulesJsonPath)
+			traceLog(ctx, fmt.Sprintf("cannot resolve module source for path %s from %s: %s", data.GetModulePath().String(), tried, err.Error()))
+			return data, err
 		}
 		data.SetModuleSource(types.StringValue(module.Source))
 		data.SetModuleVersion(types.StringValue(module.Version))
 	}
-	return data
+	return data, nil
+}
+
+// moduleResolutionDiagnostics returns a warning on `module_path` describing err when strict is true, so module
+// authors could tell why the module source and version are null.
+func moduleResolutionDiagnostics(err error, strict bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if err == nil || !strict {
+		return diags
+	}
+	diags.AddAttributeWarning(path.Root("module_path"), "Cannot resolve module source and version",
+		fmt.Sprintf("The module source and version are null since they cannot be resolved from modules.json: %s. Run `terraform init` to install the modules, or set `modules_json_path` on the provider when modules.json is not under `TF_DATA_DIR`.", err.Error()))
+	return diags
 }
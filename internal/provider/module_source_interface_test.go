@@ -0,0 +1,57 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithModuleSourceAndVersion_unresolvedModule(t *testing.T) {
+	dataDir := t.TempDir()
+	require.NoError(t, createModulesJsonIn(dataDir))
+	cases := map[string]struct {
+		modulesJsonPath string
+		modulePath      string
+	}{
+		"missing modules.json": {
+			modulesJsonPath: filepath.Join(dataDir, "nonexistent.json"),
+			modulePath:      ".terraform/modules/kv",
+		},
+		"module not found": {
+			modulesJsonPath: dataDir,
+			modulePath:      ".terraform/modules/nonexistent",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			data := &ModuleSourceDataSourceModel{ModulePath: types.StringValue(c.modulePath)}
+			data, err := withModuleSourceAndVersion(context.Background(), data, nil, c.modulesJsonPath)
+			require.Error(t, err)
+			assert.True(t, data.ModuleSource.IsNull())
+			assert.True(t, data.ModuleVersion.IsNull())
+
+			assert.Empty(t, moduleResolutionDiagnostics(err, false))
+			diags := moduleResolutionDiagnostics(err, true)
+			require.Len(t, diags, 1)
+			assert.Equal(t, "Cannot resolve module source and version", diags[0].Summary())
+			assert.False(t, diags.HasError())
+		})
+	}
+}
+
+func TestWithModuleSourceAndVersion_resolvedModule(t *testing.T) {
+	dataDir := t.TempDir()
+	require.NoError(t, createModulesJsonIn(dataDir))
+	data := &ModuleSourceDataSourceModel{ModulePath: types.StringValue(".terraform/modules/kv")}
+	data, err := withModuleSourceAndVersion(context.Background(), data, nil, dataDir)
+	require.NoError(t, err)
+	assert.Equal(t, "0.6.1", data.ModuleVersion.ValueString())
+	assert.Empty(t, moduleResolutionDiagnostics(err, true))
+}
@@ -0,0 +1,144 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+var _ datasource.DataSource = &ModuleTelemetryDataSource{}
+var _ datasource.DataSourceWithConfigure = &ModuleTelemetryDataSource{}
+
+// planEvent is the event sent when `modtm_module_telemetry` is read.
+const planEvent = "plan"
+
+func NewModuleTelemetryDataSource() datasource.DataSource {
+	return &ModuleTelemetryDataSource{}
+}
+
+// ModuleTelemetryDataSource sends a `plan` event every time it's read, it delivers the event exactly like
+// `modtm_telemetry` does.
+type ModuleTelemetryDataSource struct {
+	sender TelemetryResource
+}
+
+var _ moduleSource = &ModuleTelemetryDataSourceModel{}
+
+type ModuleTelemetryDataSourceModel struct {
+	Id             types.String `tfsdk:"id"`
+	Tags           types.Map    `tfsdk:"tags"`
+	Endpoint       types.String `tfsdk:"endpoint"`
+	RequestTimeout types.String `tfsdk:"request_timeout"`
+	ModulePath     types.String `tfsdk:"module_path"`
+	ModuleSource   types.String `tfsdk:"module_source"`
+	ModuleVersion  types.String `tfsdk:"module_version"`
+}
+
+func (m *ModuleTelemetryDataSourceModel) GetModuleVersion() types.String {
+	return m.ModuleVersion
+}
+
+func (m *ModuleTelemetryDataSourceModel) SetModuleVersion(v types.String) {
+	m.ModuleVersion = v
+}
+
+func (m *ModuleTelemetryDataSourceModel) GetModuleSource() types.String {
+	return m.ModuleSource
+}
+
+func (m *ModuleTelemetryDataSourceModel) SetModuleSource(v types.String) {
+	m.ModuleSource = v
+}
+
+func (m *ModuleTelemetryDataSourceModel) GetModulePath() types.String {
+	return m.ModulePath
+}
+
+func (m *ModuleTelemetryDataSource) Metadata(ctx context.Context, request datasource.MetadataRequest, response *datasource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_module_telemetry"
+}
+
+func (m *ModuleTelemetryDataSource) Schema(ctx context.Context, request datasource.SchemaRequest, response *datasource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		MarkdownDescription: fmt.Sprintf("`modtm_module_telemetry` data source sends a `%s` event every time it's read, usually during `terraform plan`, so module owners could measure plan activity and not just applies, without creating any state. The event goes through the same event pipeline, routes, sink and endpoint as the events of `modtm_telemetry`, and the provider's `enabled` setting applies.", planEvent),
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "A new identifier generated on every read, sent as the `resource_id` tag",
+			},
+			"tags": schema.MapAttribute{
+				Required:            true,
+				MarkdownDescription: fmt.Sprintf("Tags to be sent with the event. The following tags are set by the provider, so they're reserved and cannot be used: %s. When specifying `module_path`, the `module_source` and `module_version` tags will be automatically added to the tags sent to the telemetry endpoint, unless they're set explicitly.", markdownCodeList(reservedTags)),
+				ElementType:         basetypes.StringType{},
+				Validators: []validator.Map{
+					mapValidator{},
+				},
+			},
+			"endpoint": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Telemetry endpoint to send the event to, it's used in the same way as `endpoint` of `modtm_telemetry`.",
+				Validators: []validator.String{
+					MustBeValidEndpoint{},
+				},
+			},
+			"request_timeout": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: fmt.Sprintf("How long to wait for the endpoint to respond to the event, e.g. `10s`, no longer than `%s`. Overrides provider's `request_timeout`.", maxRequestTimeout),
+				Validators: []validator.String{
+					MustBeValidDuration{Max: maxRequestTimeout},
+				},
+			},
+			"module_path": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "The path of the module that the data source is associated with, usually `path.module`. The provider reads the `$TF_DATA_DIR/modules/modules.json` file to resolve `module_source` and `module_version`.",
+			},
+			"module_source": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The source of the module at `module_path`, read from `modules.json`",
+			},
+			"module_version": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The version of the module at `module_path`, read from `modules.json`",
+			},
+		},
+	}
+}
+
+func (m *ModuleTelemetryDataSource) Configure(ctx context.Context, request datasource.ConfigureRequest, response *datasource.ConfigureResponse) {
+	resp := &resource.ConfigureResponse{}
+	m.sender.Configure(ctx, resource.ConfigureRequest{ProviderData: request.ProviderData}, resp)
+	response.Diagnostics.Append(resp.Diagnostics...)
+}
+
+func (m *ModuleTelemetryDataSource) Read(ctx context.Context, request datasource.ReadRequest, response *datasource.ReadResponse) {
+	data := &ModuleTelemetryDataSourceModel{}
+	response.Diagnostics.Append(request.Config.Get(ctx, data)...)
+
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	response.Diagnostics.Append(m.sender.tagLimits.validate(path.Root("tags"), data.Tags)...)
+	response.Diagnostics.Append(insecureEndpointDiagnostics(path.Root("endpoint"), data.Endpoint.ValueString(), "the data source", m.sender.allowInsecureEndpoint)...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	data, err := withModuleSourceAndVersion(ctx, data, m.sender.modulesJson, m.sender.modulesJsonPath)
+	response.Diagnostics.Append(moduleResolutionDiagnostics(err, m.sender.strictModuleResolution)...)
+	data.Id = types.StringValue(uuid.NewString())
+	traceLog(ctx, fmt.Sprintf("read module telemetry data source with id %s", data.Id.ValueString()))
+	m.sender.sendEvent(ctx, planEvent, data.Id.ValueString(), withModuleTags(mergeTags(data.Tags), data), data.Endpoint.ValueString(), data.RequestTimeout.ValueString())
+	response.Diagnostics.Append(response.State.Set(ctx, data)...)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccModuleTelemetryDataSource_sendsPlanEvent(t *testing.T) {
+	dataDir := t.TempDir()
+	require.NoError(t, createModulesJsonIn(dataDir))
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.contoso.com"}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  module_source_regex = ["avm-res-keyvault-vault"]
+  modules_json_path   = %q
+}
+
+data "modtm_module_telemetry" "test" {
+  module_path = ".terraform/modules/kv"
+  tags = {
+    avm_git_file = "main.tf"
+  }
+}
+`, filepath.Join(dataDir, "modules", "modules.json")),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("data.modtm_module_telemetry.test", "id", regexp.MustCompile(uuidRegex)),
+					resource.TestCheckResourceAttr("data.modtm_module_telemetry.test", "module_source", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm"),
+					resource.TestCheckResourceAttr("data.modtm_module_telemetry.test", "module_version", "0.6.1"),
+				),
+			},
+		},
+	})
+	sent := client.sentEvents()
+	require.NotEmpty(t, sent)
+	for _, e := range sent {
+		assert.Equal(t, planEvent, e.tags["event"])
+		assert.Equal(t, "main.tf", e.tags["avm_git_file"])
+		assert.Equal(t, "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm", e.tags["module_source"])
+		assert.Equal(t, "0.6.1", e.tags["module_version"])
+	}
+}
+
+func TestAccModuleTelemetryDataSource_disabledProviderShouldNotSend(t *testing.T) {
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.contoso.com"}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "modtm" {
+  enabled             = false
+  module_source_regex = ["foo"]
+}
+
+data "modtm_module_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+  }
+}
+`,
+			},
+		},
+	})
+	assert.Empty(t, client.sentEvents())
+}
@@ -9,11 +9,13 @@ import (
 
 var _ function.Function = &ModuleVersionFunction{}
 
-func NewModuleVersionFunction() function.Function {
-	return &ModuleVersionFunction{}
+func NewModuleVersionFunction(modulesJson *modulesJsonCache, telemetry *functionTelemetry) function.Function {
+	return &ModuleVersionFunction{telemetry: telemetry, modulesJson: modulesJson}
 }
 
 type ModuleVersionFunction struct {
+	telemetry   *functionTelemetry
+	modulesJson *modulesJsonCache
 }
 
 func (m *ModuleVersionFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
@@ -42,7 +44,8 @@ func (m *ModuleVersionFunction) Run(ctx context.Context, req function.RunRequest
 	}
 	model := &ModuleSourceDataSourceModel{}
 	model.ModulePath = types.StringValue(modulePath)
-	model = withModuleSourceAndVersion(model)
+	model, _ = withModuleSourceAndVersion(ctx, model, m.modulesJson, "")
 	s := model.ModuleVersion.ValueString()
+	m.telemetry.send(ctx, "module_version", model.ModuleSource.ValueString(), model.ModuleVersion.ValueString())
 	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, s))
 }
@@ -0,0 +1,125 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &ModulesDataSource{}
+var _ datasource.DataSourceWithConfigure = &ModulesDataSource{}
+
+type ModulesDataSource struct {
+	modulesJsonPath string
+	modulesJson     *modulesJsonCache
+}
+
+func NewModulesDataSource() datasource.DataSource {
+	return &ModulesDataSource{}
+}
+
+type ModulesDataSourceModel struct {
+	Modules []ModulesEntryModel `tfsdk:"modules"`
+}
+
+type ModulesEntryModel struct {
+	Key     types.String `tfsdk:"key"`
+	Source  types.String `tfsdk:"source"`
+	Version types.String `tfsdk:"version"`
+	Dir     types.String `tfsdk:"dir"`
+}
+
+func (m *ModulesDataSource) Metadata(ctx context.Context, request datasource.MetadataRequest, response *datasource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_modules"
+}
+
+func (m *ModulesDataSource) Schema(ctx context.Context, request datasource.SchemaRequest, response *datasource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		MarkdownDescription: "`modtm_modules` data source returns every module installed for the root module, read from `modules.json` file in `.terraform/modules` folder, so an inventory of all module dependencies of the root module could be emitted in one go.",
+		Attributes: map[string]schema.Attribute{
+			"modules": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "The modules in `modules.json`, ordered by `key`. The root module itself is not included. Empty when `modules.json` cannot be read, e.g. before `terraform init`.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"key": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Key` of the module in `modules.json`, e.g. `kv.keys` for `module.kv.module.keys`",
+						},
+						"source": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Source` of the module",
+						},
+						"version": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Version` of the module, empty for modules that are not installed from a registry",
+						},
+						"dir": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The `Dir` of the module",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (m *ModulesDataSource) Configure(ctx context.Context, request datasource.ConfigureRequest, response *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if request.ProviderData == nil {
+		return
+	}
+
+	c, ok := request.ProviderData.(providerConfig)
+
+	if !ok {
+		response.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected providerConfig, got: %T. Please report this issue to the provider developers.", request.ProviderData),
+		)
+
+		return
+	}
+
+	m.modulesJsonPath = c.modulesJsonPath
+	m.modulesJson = c.modulesJson
+}
+
+func (m *ModulesDataSource) Read(ctx context.Context, request datasource.ReadRequest, response *datasource.ReadResponse) {
+	data := &ModulesDataSourceModel{}
+	response.Diagnostics.Append(request.Config.Get(ctx, data)...)
+
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	data.Modules = make([]ModulesEntryModel, 0)
+	modules, err := m.modulesJson.readModulesJson(m.modulesJsonPath)
+	if err != nil {
+		traceLog(ctx, fmt.Sprintf("failed to read modules.json: %s", err.Error()))
+	} else {
+		data.Modules = newModulesEntryModels(modules.descendants(""))
+	}
+	traceLog(ctx, fmt.Sprintf("read modules, found %d modules", len(data.Modules)))
+	response.Diagnostics.Append(response.State.Set(ctx, data)...)
+}
+
+func newModulesEntryModels(modules []modulesJsonModulesModel) []ModulesEntryModel {
+	entries := make([]ModulesEntryModel, 0, len(modules))
+	for _, module := range modules {
+		entries = append(entries, ModulesEntryModel{
+			Key:     types.StringValue(module.Key),
+			Source:  types.StringValue(module.Source),
+			Version: types.StringValue(module.Version),
+			Dir:     types.StringValue(module.Dir),
+		})
+	}
+	return entries
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccModulesDataSource(t *testing.T) {
+	require.NoError(t, createModulesJson())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "modtm" {
+  enabled = false
+  module_source_regex = ["foo"]
+}
+
+data "modtm_modules" "test" {}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_modules.test", "modules.#", "4"),
+					resource.TestCheckResourceAttr("data.modtm_modules.test", "modules.0.key", "keys"),
+					resource.TestCheckResourceAttr("data.modtm_modules.test", "modules.0.source", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm//modules/key"),
+					resource.TestCheckResourceAttr("data.modtm_modules.test", "modules.0.version", "0.6.1"),
+					resource.TestCheckResourceAttr("data.modtm_modules.test", "modules.0.dir", ".terraform/modules/keys/modules/key"),
+					resource.TestCheckResourceAttr("data.modtm_modules.test", "modules.1.key", "kv"),
+					resource.TestCheckResourceAttr("data.modtm_modules.test", "modules.2.key", "kv.keys"),
+					resource.TestCheckResourceAttr("data.modtm_modules.test", "modules.2.version", ""),
+					resource.TestCheckResourceAttr("data.modtm_modules.test", "modules.3.key", "kv.secrets"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,292 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// modulesJsonModel represents the base structure of the modules.json file.
+type modulesJsonModel struct {
+	Modules []modulesJsonModulesModel `json:"Modules"`
+	// rootDir is the directory that the entries' `Dir` are relative to, empty means the current working directory.
+	rootDir string
+}
+
+// modulesJsonModulesModel represents the structure of the modules.json file's `Modules` array.
+type modulesJsonModulesModel struct {
+	Key     string `json:"Key"`
+	Source  string `json:"Source"`
+	Version string `json:"Version"`
+	Dir     string `json:"Dir"`
+}
+
+// modulesJsonEnv is the environment variable that overrides the location of modules.json when the provider's
+// `modules_json_path` is not set, it applies to provider functions too.
+const modulesJsonEnv = "MODTM_MODULES_JSON"
+
+// modulesJsonFilePath returns the path of the modules.json file to read. When override is empty, `MODTM_MODULES_JSON`
+// is used, and then the file under `$TF_DATA_DIR/modules`. Otherwise override is either the modules.json file itself, or a
+// directory which is used as the Terraform data dir.
+func modulesJsonFilePath(override string) string {
+	if override == "" {
+		override = os.Getenv(modulesJsonEnv)
+	}
+	if override == "" {
+		dataDir := envOrDefault("TF_DATA_DIR", ".terraform")
+		return filepath.Join(dataDir, "modules", "modules.json")
+	}
+	if info, err := os.Stat(override); err == nil && info.IsDir() {
+		return filepath.Join(override, "modules", "modules.json")
+	}
+	return override
+}
+
+// locateModulesJson returns the path of the modules.json file to read, and the directory that the entries'
+// `Dir` in it are relative to (empty for the current working directory).
+// In Terraform Cloud remote runs the working directory could be a sub folder of the directory that has been
+// initialized, so when the default modules.json file doesn't exist, the parent directories are searched too.
+// Under Terragrunt, see terragruntCacheDirs, the parent directories inside the cache are searched, and then the
+// directory of `terragrunt.hcl`, since a relative `TF_DATA_DIR` could be resolved against it.
+func locateModulesJson(override string) (string, string) {
+	modulesJsonPath := modulesJsonFilePath(override)
+	if override != "" || os.Getenv(modulesJsonEnv) != "" {
+		return modulesJsonPath, ""
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return modulesJsonPath, ""
+	}
+	workingDir, configDir, terragrunt := terragruntCacheDirs(wd)
+	if !terragrunt && !isTerraformCloudRun() {
+		return modulesJsonPath, ""
+	}
+	if _, err := os.Stat(modulesJsonPath); err == nil {
+		return modulesJsonPath, ""
+	}
+	dataDir := envOrDefault("TF_DATA_DIR", ".terraform")
+	if filepath.IsAbs(dataDir) {
+		return modulesJsonPath, ""
+	}
+	for dir := filepath.Dir(wd); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if terragrunt && !strings.HasPrefix(dir, workingDir) {
+			break
+		}
+		candidate := filepath.Join(dir, dataDir, "modules", "modules.json")
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, dir
+		}
+	}
+	if terragrunt {
+		candidate := filepath.Join(configDir, dataDir, "modules", "modules.json")
+		if _, err := os.Stat(candidate); err == nil {
+			// Terraform still runs in the cache, `Dir` is relative to the current working directory.
+			return candidate, ""
+		}
+	}
+	return modulesJsonPath, ""
+}
+
+// terragruntCacheDir is the directory that Terragrunt copies the module into before running Terraform.
+const terragruntCacheDir = ".terragrunt-cache"
+
+// terragruntCacheDirs detects whether wd is inside the Terragrunt cache layout,
+// `<config dir>/.terragrunt-cache/<hash>/<hash>/<module subdirectory>`. It returns the directory that Terragrunt
+// copied the module source into, `<config dir>/.terragrunt-cache/<hash>/<hash>`, and the directory of
+// `terragrunt.hcl`.
+func terragruntCacheDirs(wd string) (string, string, bool) {
+	segments := strings.Split(filepath.ToSlash(filepath.Clean(wd)), "/")
+	for i, segment := range segments {
+		if segment != terragruntCacheDir || i == 0 || len(segments) < i+3 {
+			continue
+		}
+		workingDir := filepath.FromSlash(strings.Join(segments[:i+3], "/"))
+		configDir := filepath.FromSlash(strings.Join(segments[:i], "/"))
+		if configDir == "" {
+			configDir = string(filepath.Separator)
+		}
+		return workingDir, configDir, true
+	}
+	return "", "", false
+}
+
+// isTerraformCloudRun returns true when the provider is running in a Terraform Cloud/Enterprise remote run.
+func isTerraformCloudRun() bool {
+	return os.Getenv("TFC_RUN_ID") != ""
+}
+
+// readModulesJson reads and unmarshals the modules.json file, see locateModulesJson for how the file is located.
+func readModulesJson(override string) (*modulesJsonModel, error) {
+	return readModulesJsonFile(locateModulesJson(override))
+}
+
+// readModulesJsonFile reads and unmarshals the modules.json file at modulesJsonPath, whose entries' `Dir` are relative
+// to rootDir.
+func readModulesJsonFile(modulesJsonPath, rootDir string) (*modulesJsonModel, error) {
+	content, err := os.ReadFile(filepath.Clean(modulesJsonPath))
+	if err != nil {
+		return nil, fmt.Errorf("readModulesJson: error reading modules.json file: %w", err)
+	}
+	var modules modulesJsonModel
+	if err = json.Unmarshal(content, &modules); err != nil {
+		return nil, fmt.Errorf("readModulesJson: error unmarshalling modules.json file: %w", err)
+	}
+	modules.rootDir = rootDir
+	return &modules, nil
+}
+
+// parseModulesJson reads the modules.json file and returns the module entry with the specified key.
+func parseModulesJson(modulesJsonPath, modulePath string) (*modulesJsonModulesModel, error) {
+	return (*modulesJsonCache)(nil).parseModulesJson(modulesJsonPath, modulePath)
+}
+
+// findByDir returns the module entry whose `Dir` refers to the same directory as dir, or nil if there's no such entry.
+func (m *modulesJsonModel) findByDir(dir string) *modulesJsonModulesModel {
+	dir = normalizeModuleDir(dir, m.rootDir)
+	for i := range m.Modules {
+		if sameModuleDir(normalizeModuleDir(m.Modules[i].Dir, ""), dir) {
+			return &m.Modules[i]
+		}
+	}
+	return nil
+}
+
+// findByKey returns the module entry whose `Key` equals key, or nil if there's no such entry.
+func (m *modulesJsonModel) findByKey(key string) *modulesJsonModulesModel {
+	for i := range m.Modules {
+		if m.Modules[i].Key == key {
+			return &m.Modules[i]
+		}
+	}
+	return nil
+}
+
+// parents returns the ancestors of the module with the given key, ordered from the outermost module
+// to the immediate parent. The root module (empty key) is not included.
+func (m *modulesJsonModel) parents(key string) []modulesJsonModulesModel {
+	var parents []modulesJsonModulesModel
+	segments := strings.Split(key, ".")
+	for i := 1; i < len(segments); i++ {
+		parent := m.findByKey(strings.Join(segments[:i], "."))
+		if parent == nil {
+			continue
+		}
+		parents = append(parents, *parent)
+	}
+	return parents
+}
+
+// descendants returns the modules called directly or transitively by the module with the given key, ordered by key.
+// All modules are descendants of the root module (empty key), but the root module itself is not included.
+func (m *modulesJsonModel) descendants(key string) []modulesJsonModulesModel {
+	var descendants []modulesJsonModulesModel
+	for _, module := range m.Modules {
+		if module.Key != "" && (key == "" || strings.HasPrefix(module.Key, key+".")) {
+			descendants = append(descendants, module)
+		}
+	}
+	sort.Slice(descendants, func(i, j int) bool {
+		return descendants[i].Key < descendants[j].Key
+	})
+	return descendants
+}
+
+// windowsPaths is true when module paths use Windows conventions: backslash separators, drive letters and
+// case-insensitive names.
+var windowsPaths = runtime.GOOS == "windows"
+
+// normalizeModuleDir converts dir into the form used by `Dir` in modules.json: a clean, slash separated
+// path relative to rootDir. Absolute paths are made relative to rootDir, when rootDir is empty the current
+// working directory is used, which is the root module's directory when Terraform runs the provider.
+// Relative paths are relative to the current working directory.
+func normalizeModuleDir(dir, rootDir string) string {
+	wd, _ := os.Getwd()
+	return normalizeModuleDirIn(dir, rootDir, wd, windowsPaths)
+}
+
+// normalizeModuleDirIn is normalizeModuleDir with the working directory wd. When windows is true, backslashes are
+// read as separators, `C:/` style drive letters and UNC paths are absolute, and directories are compared
+// case-insensitively while making dir relative.
+func normalizeModuleDirIn(dir, rootDir, wd string, windows bool) string {
+	if windows {
+		dir, rootDir, wd = strings.ReplaceAll(dir, `\`, "/"), strings.ReplaceAll(rootDir, `\`, "/"), strings.ReplaceAll(wd, `\`, "/")
+	} else {
+		dir, rootDir, wd = filepath.ToSlash(dir), filepath.ToSlash(rootDir), filepath.ToSlash(wd)
+	}
+	if rootDir != "" && !isAbsSlashPath(dir, windows) && wd != "" {
+		dir = path.Join(wd, dir)
+	}
+	if isAbsSlashPath(dir, windows) {
+		if rootDir == "" {
+			rootDir = wd
+		}
+		if rel, ok := relSlashPath(rootDir, dir, windows); ok && rootDir != "" {
+			dir = rel
+		}
+	}
+	return path.Clean(dir)
+}
+
+// sameModuleDir returns true when the normalized module directories a and b are the same directory.
+func sameModuleDir(a, b string) bool {
+	if windowsPaths {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// windowsVolume matches the drive letter of an absolute Windows path, e.g. `C:/`.
+var windowsVolume = regexp.MustCompile(`^[A-Za-z]:/`)
+
+// isAbsSlashPath returns true when the slash separated p is absolute.
+func isAbsSlashPath(p string, windows bool) bool {
+	if windows {
+		return windowsVolume.MatchString(p) || strings.HasPrefix(p, "//")
+	}
+	return strings.HasPrefix(p, "/")
+}
+
+// relSlashPath returns target relative to the absolute base, both slash separated, like filepath.Rel. It returns
+// false when target cannot be made relative to base, e.g. they are on different Windows drives.
+func relSlashPath(base, target string, windows bool) (string, bool) {
+	equal := func(a, b string) bool {
+		if windows {
+			return strings.EqualFold(a, b)
+		}
+		return a == b
+	}
+	baseSegments := strings.Split(strings.Trim(path.Clean(base), "/"), "/")
+	targetSegments := strings.Split(strings.Trim(path.Clean(target), "/"), "/")
+	if baseSegments[0] == "" {
+		baseSegments = nil
+	}
+	if targetSegments[0] == "" {
+		targetSegments = nil
+	}
+	common := 0
+	for common < len(baseSegments) && common < len(targetSegments) && equal(baseSegments[common], targetSegments[common]) {
+		common++
+	}
+	if windows && common == 0 {
+		// Different drives or UNC shares.
+		return "", false
+	}
+	var rel []string
+	for range baseSegments[common:] {
+		rel = append(rel, "..")
+	}
+	rel = append(rel, targetSegments[common:]...)
+	if len(rel) == 0 {
+		return ".", true
+	}
+	return strings.Join(rel, "/"), true
+}
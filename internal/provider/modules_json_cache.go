@@ -0,0 +1,71 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// modulesJsonCache keeps the parsed modules.json files of a provider instance, so the file is read once instead of
+// for every function call, data source and resource. An entry is read again once the file's modification time or
+// size changes. A nil cache reads the file every time.
+type modulesJsonCache struct {
+	mu      sync.Mutex
+	entries map[string]modulesJsonCacheEntry
+}
+
+type modulesJsonCacheEntry struct {
+	modTime time.Time
+	size    int64
+	modules *modulesJsonModel
+}
+
+func newModulesJsonCache() *modulesJsonCache {
+	return &modulesJsonCache{
+		entries: make(map[string]modulesJsonCacheEntry),
+	}
+}
+
+// readModulesJson is readModulesJson served from the cache. The returned model is shared and must not be modified.
+func (c *modulesJsonCache) readModulesJson(override string) (*modulesJsonModel, error) {
+	if c == nil {
+		return readModulesJson(override)
+	}
+	modulesJsonPath, rootDir := locateModulesJson(override)
+	info, err := os.Stat(modulesJsonPath)
+	if err != nil {
+		return nil, fmt.Errorf("readModulesJson: error reading modules.json file: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[modulesJsonPath]; ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() && entry.modules.rootDir == rootDir {
+		return entry.modules, nil
+	}
+	modules, err := readModulesJsonFile(modulesJsonPath, rootDir)
+	if err != nil {
+		delete(c.entries, modulesJsonPath)
+		return nil, err
+	}
+	c.entries[modulesJsonPath] = modulesJsonCacheEntry{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		modules: modules,
+	}
+	return modules, nil
+}
+
+// parseModulesJson is parseModulesJson served from the cache.
+func (c *modulesJsonCache) parseModulesJson(override, modulePath string) (*modulesJsonModulesModel, error) {
+	modules, err := c.readModulesJson(override)
+	if err != nil {
+		return nil, fmt.Errorf("parseModulesJson: %w", err)
+	}
+	if module := modules.findByDir(modulePath); module != nil {
+		return module, nil
+	}
+	return nil, fmt.Errorf("parseModulesJson: module with dir %s not found in modules.json", modulePath)
+}
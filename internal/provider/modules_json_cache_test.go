@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModulesJsonCache_readsFileOnce(t *testing.T) {
+	dataDir := t.TempDir()
+	require.NoError(t, createModulesJsonIn(dataDir))
+	cache := newModulesJsonCache()
+
+	first, err := cache.readModulesJson(dataDir)
+	require.NoError(t, err)
+	second, err := cache.readModulesJson(dataDir)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+}
+
+func TestModulesJsonCache_invalidatedWhenFileChanges(t *testing.T) {
+	dataDir := t.TempDir()
+	require.NoError(t, createModulesJsonIn(dataDir))
+	cache := newModulesJsonCache()
+	module, err := cache.parseModulesJson(dataDir, ".terraform/modules/kv")
+	require.NoError(t, err)
+	assert.Equal(t, "0.6.1", module.Version)
+
+	modulesJsonFile := filepath.Join(dataDir, "modules", "modules.json")
+	require.NoError(t, os.WriteFile(modulesJsonFile, []byte(`{"Modules":[{"Key":"kv","Source":"registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm","Version":"0.7.0","Dir":".terraform/modules/kv"}]}`), 0600))
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(modulesJsonFile, modTime, modTime))
+	module, err = cache.parseModulesJson(dataDir, ".terraform/modules/kv")
+	require.NoError(t, err)
+	assert.Equal(t, "0.7.0", module.Version)
+
+	require.NoError(t, os.Remove(modulesJsonFile))
+	_, err = cache.parseModulesJson(dataDir, ".terraform/modules/kv")
+	assert.Error(t, err)
+}
+
+func TestModulesJsonCache_nilCacheReadsFile(t *testing.T) {
+	dataDir := t.TempDir()
+	require.NoError(t, createModulesJsonIn(dataDir))
+	var cache *modulesJsonCache
+	module, err := cache.parseModulesJson(dataDir, ".terraform/modules/kv")
+	require.NoError(t, err)
+	assert.Equal(t, "kv", module.Key)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadModulesJson_terraformCloudRunSearchesParentDirectories(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, createModulesJsonIn(filepath.Join(root, ".terraform")))
+	workingDir := filepath.Join(root, "envs", "prod")
+	require.NoError(t, os.MkdirAll(workingDir, 0755))
+	chdir(t, workingDir)
+
+	_, err := readModulesJson("")
+	require.Error(t, err, "parent directories should only be searched in Terraform Cloud runs")
+
+	t.Setenv("TFC_RUN_ID", "run-CZcmD7eagjhyX0vN")
+	modules, err := readModulesJson("")
+	require.NoError(t, err)
+	module := modules.findByDir(filepath.Join(root, ".terraform", "modules", "kv"))
+	require.NotNil(t, module)
+	require.Equal(t, "kv", module.Key)
+	module = modules.findByDir("../../.terraform/modules/kv/modules/key")
+	require.NotNil(t, module)
+	require.Equal(t, "kv.keys", module.Key)
+}
+
+func TestNormalizeModuleDirIn_windows(t *testing.T) {
+	wd := `C:\Users\dev\infra`
+	cases := map[string]string{
+		`.terraform\modules\kv`:                    ".terraform/modules/kv",
+		`.\.terraform\modules\kv\`:                 ".terraform/modules/kv",
+		`.terraform\modules\kv\..\kv\modules\key`:  ".terraform/modules/kv/modules/key",
+		`C:\Users\dev\infra\.terraform\modules\kv`: ".terraform/modules/kv",
+		`c:\users\DEV\infra\.terraform\modules\kv`: ".terraform/modules/kv",
+		`C:/Users/dev/infra/.terraform/modules/kv`: ".terraform/modules/kv",
+		`C:\Users\dev\infra`:                       ".",
+		`C:\Users\dev\shared\modules\kv`:           "../shared/modules/kv",
+		`D:\infra\.terraform\modules\kv`:           "D:/infra/.terraform/modules/kv",
+	}
+	for input, expected := range cases {
+		require.Equal(t, expected, normalizeModuleDirIn(input, "", wd, true), input)
+	}
+}
+
+func TestNormalizeModuleDirIn_windowsRootDir(t *testing.T) {
+	wd := `C:\agent\work\envs\prod`
+	rootDir := `C:\agent\work`
+	require.Equal(t, ".terraform/modules/kv", normalizeModuleDirIn(`..\..\.terraform\modules\kv`, rootDir, wd, true))
+	require.Equal(t, ".terraform/modules/kv", normalizeModuleDirIn(`c:\AGENT\work\.terraform\modules\kv`, rootDir, wd, true))
+}
+
+func TestFindByDir_windowsIsCaseInsensitive(t *testing.T) {
+	windows := windowsPaths
+	windowsPaths = true
+	t.Cleanup(func() {
+		windowsPaths = windows
+	})
+	modules := &modulesJsonModel{Modules: []modulesJsonModulesModel{
+		{Key: "kv", Dir: ".terraform/modules/kv"},
+	}}
+	module := modules.findByDir(`.Terraform\Modules\KV`)
+	require.NotNil(t, module)
+	require.Equal(t, "kv", module.Key)
+}
+
+func TestTerragruntCacheDirs(t *testing.T) {
+	workingDir, configDir, ok := terragruntCacheDirs(filepath.FromSlash("/repo/live/prod/.terragrunt-cache/abc/def/modules/app"))
+	require.True(t, ok)
+	require.Equal(t, filepath.FromSlash("/repo/live/prod/.terragrunt-cache/abc/def"), workingDir)
+	require.Equal(t, filepath.FromSlash("/repo/live/prod"), configDir)
+
+	_, _, ok = terragruntCacheDirs(filepath.FromSlash("/repo/live/prod"))
+	require.False(t, ok)
+	_, _, ok = terragruntCacheDirs(filepath.FromSlash("/repo/live/prod/.terragrunt-cache/abc"))
+	require.False(t, ok, "Terragrunt copies the module two levels below the cache directory")
+}
+
+func TestReadModulesJson_terragruntSearchesCacheWorkingDir(t *testing.T) {
+	configDir := t.TempDir()
+	cacheWorkingDir := filepath.Join(configDir, ".terragrunt-cache", "abc", "def")
+	require.NoError(t, createModulesJsonIn(filepath.Join(cacheWorkingDir, ".terraform")))
+	moduleDir := filepath.Join(cacheWorkingDir, "modules", "app")
+	require.NoError(t, os.MkdirAll(moduleDir, 0755))
+	// The data dir outside the cache must not be reached.
+	require.NoError(t, os.MkdirAll(filepath.Join(configDir, ".terraform", "modules"), 0755))
+	chdir(t, moduleDir)
+
+	modules, err := readModulesJson("")
+	require.NoError(t, err)
+	module := modules.findByDir("../../.terraform/modules/kv")
+	require.NotNil(t, module)
+	require.Equal(t, "kv", module.Key)
+}
+
+func TestReadModulesJson_terragruntSearchesConfigDir(t *testing.T) {
+	configDir := t.TempDir()
+	require.NoError(t, createModulesJsonIn(filepath.Join(configDir, ".terraform")))
+	cacheWorkingDir := filepath.Join(configDir, ".terragrunt-cache", "abc", "def")
+	require.NoError(t, os.MkdirAll(cacheWorkingDir, 0755))
+	chdir(t, cacheWorkingDir)
+
+	modules, err := readModulesJson("")
+	require.NoError(t, err)
+	module := modules.findByDir(".terraform/modules/kv/modules/key")
+	require.NotNil(t, module)
+	require.Equal(t, "kv.keys", module.Key)
+}
+
+// chdir changes the current working directory for the duration of the test.
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+}
+
+func TestModulesJsonFilePath_environmentVariable(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv(modulesJsonEnv, dataDir)
+	require.Equal(t, filepath.Join(dataDir, "modules", "modules.json"), modulesJsonFilePath(""))
+	require.Equal(t, "explicit.json", modulesJsonFilePath("explicit.json"))
+}
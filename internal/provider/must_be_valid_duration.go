@@ -0,0 +1,40 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/helpers/validatordiag"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// MustBeValidDuration validates that the value is a positive duration, no longer than Max when it's set.
+type MustBeValidDuration struct {
+	Max time.Duration
+}
+
+func (m MustBeValidDuration) Description(ctx context.Context) string {
+	if m.Max > 0 {
+		return fmt.Sprintf("value must be a positive duration like `10s` or `1m`, no longer than `%s`", m.Max)
+	}
+	return "value must be a positive duration like `30m` or `24h`"
+}
+
+func (m MustBeValidDuration) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m MustBeValidDuration) ValidateString(ctx context.Context, request validator.StringRequest, response *validator.StringResponse) {
+	if request.ConfigValue.IsNull() || request.ConfigValue.IsUnknown() {
+		return
+	}
+	item := request.ConfigValue.ValueString()
+	d, err := time.ParseDuration(item)
+	if err != nil || d <= 0 || (m.Max > 0 && d > m.Max) {
+		response.Diagnostics.Append(validatordiag.InvalidAttributeValueDiagnostic(request.Path, m.Description(ctx), item))
+	}
+}
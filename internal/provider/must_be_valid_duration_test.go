@@ -0,0 +1,41 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMustBeValidDuration(t *testing.T) {
+	cases := []struct {
+		value string
+		max   time.Duration
+		valid bool
+	}{
+		{value: "24h", valid: true},
+		{value: "0s", valid: false},
+		{value: "-1m", valid: false},
+		{value: "tomorrow", valid: false},
+		{value: "10s", max: time.Minute, valid: true},
+		{value: "1m", max: time.Minute, valid: true},
+		{value: "2m", max: time.Minute, valid: false},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			resp := &validator.StringResponse{}
+			MustBeValidDuration{Max: c.max}.ValidateString(context.Background(), validator.StringRequest{
+				Path:        path.Root("request_timeout"),
+				ConfigValue: types.StringValue(c.value),
+			}, resp)
+			assert.Equal(t, !c.valid, resp.Diagnostics.HasError())
+		})
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// MustBeValidEndpoint validates that the value is an endpoint accepted by configuredEndpointPolicy.
+type MustBeValidEndpoint struct {
+}
+
+func (m MustBeValidEndpoint) Description(ctx context.Context) string {
+	return fmt.Sprintf("value must be an absolute URL with one of the schemes %s, without whitespace or credentials, no longer than %d characters", markdownCodeList(configuredEndpointPolicy.schemes), maxEndpointLength)
+}
+
+func (m MustBeValidEndpoint) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m MustBeValidEndpoint) ValidateString(ctx context.Context, request validator.StringRequest, response *validator.StringResponse) {
+	if request.ConfigValue.IsNull() || request.ConfigValue.IsUnknown() {
+		return
+	}
+	// The endpoint must be valid as it's written, it's not trimmed like a discovery document.
+	item := request.ConfigValue.ValueString()
+	if endpoint, err := configuredEndpointPolicy.validate(item); err != nil || endpoint != item {
+		detail := "the endpoint has leading or trailing whitespace"
+		if err != nil {
+			detail = err.Error()
+		}
+		response.Diagnostics.AddAttributeError(request.Path, "Invalid Endpoint", fmt.Sprintf("%s, %s.", m.Description(ctx), detail))
+	}
+}
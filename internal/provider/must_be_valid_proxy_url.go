@@ -0,0 +1,38 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/helpers/validatordiag"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+type MustBeValidProxyUrl struct {
+}
+
+func (m MustBeValidProxyUrl) Description(ctx context.Context) string {
+	return fmt.Sprintf("value must be an absolute URL with one of the schemes %s", markdownCodeList(proxyURLSchemes))
+}
+
+func (m MustBeValidProxyUrl) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m MustBeValidProxyUrl) ValidateString(ctx context.Context, request validator.StringRequest, response *validator.StringResponse) {
+	if request.ConfigValue.IsNull() || request.ConfigValue.IsUnknown() {
+		return
+	}
+	item := request.ConfigValue.ValueString()
+	if _, err := parseExplicitProxyURL(item); err != nil || item == "" {
+		// Don't echo the password back if the URL carries credentials.
+		if u, err := url.Parse(item); err == nil {
+			item = u.Redacted()
+		}
+		response.Diagnostics.Append(validatordiag.InvalidAttributeValueDiagnostic(request.Path, m.Description(ctx), item))
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &NormalizeTimestampFunction{}
+
+func NewNormalizeTimestampFunction() function.Function {
+	return &NormalizeTimestampFunction{}
+}
+
+type NormalizeTimestampFunction struct {
+}
+
+func (m *NormalizeTimestampFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "normalize_timestamp"
+}
+
+func (m *NormalizeTimestampFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "`normalize_timestamp` function",
+		MarkdownDescription: "This function parses a timestamp in one of the common git date formats and returns it in UTC as RFC3339, so tags like `avm_git_last_modified_at` are consistent whatever tooling produced them. " +
+			"Supported formats are RFC3339, `git log --date=iso`, `--date=rfc`, `--date=raw`, git's default format, and `YYYY-MM-DD hh:mm:ss` which is read as UTC.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "value",
+				MarkdownDescription: "The timestamp to normalize",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (m *NormalizeTimestampFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &value))
+	if resp.Error != nil {
+		return
+	}
+	normalized, err := normalizeGitTimestamp(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, normalized))
+}
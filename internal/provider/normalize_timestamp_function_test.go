@@ -0,0 +1,42 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccNormalizeTimestampFunction(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccNormalizeTimestampFunctionConfig("2023-05-04 07:02:32 +0200"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "2023-05-04T05:02:32Z"),
+				),
+			},
+			{
+				Config:      testAccNormalizeTimestampFunctionConfig("yesterday"),
+				ExpectError: regexp.MustCompile("unrecognized git timestamp"),
+			},
+		},
+	})
+}
+
+func testAccNormalizeTimestampFunctionConfig(value string) string {
+	return fmt.Sprintf(`
+output "test" {
+  value = provider::modtm::normalize_timestamp("%s")
+}
+`, value)
+}
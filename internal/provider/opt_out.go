@@ -0,0 +1,24 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"strconv"
+)
+
+// optOutEnvs are the standard Do-Not-Track environment variables, any of them set to a true value, e.g. `1` or
+// `true`, turns all telemetry off regardless of the `enabled` settings.
+var optOutEnvs = []string{"MODTM_DISABLE", "DO_NOT_TRACK", "AZURE_TELEMETRY_OPT_OUT"}
+
+// telemetryOptOut returns the name of the environment variable that opts out of telemetry, or an empty string
+// if there's none.
+func telemetryOptOut() string {
+	for _, env := range optOutEnvs {
+		if optOut, err := strconv.ParseBool(os.Getenv(env)); err == nil && optOut {
+			return env
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelemetryOptOut(t *testing.T) {
+	cases := []struct {
+		env      string
+		value    string
+		expected string
+	}{
+		{env: "MODTM_DISABLE", value: "1", expected: "MODTM_DISABLE"},
+		{env: "DO_NOT_TRACK", value: "1", expected: "DO_NOT_TRACK"},
+		{env: "DO_NOT_TRACK", value: "0", expected: ""},
+		{env: "AZURE_TELEMETRY_OPT_OUT", value: "true", expected: "AZURE_TELEMETRY_OPT_OUT"},
+		{env: "AZURE_TELEMETRY_OPT_OUT", value: "no", expected: ""},
+	}
+	for _, c := range cases {
+		t.Run(c.env+"="+c.value, func(t *testing.T) {
+			for _, env := range optOutEnvs {
+				t.Setenv(env, "")
+			}
+			t.Setenv(c.env, c.value)
+			assert.Equal(t, c.expected, telemetryOptOut())
+		})
+	}
+}
+
+func TestSendTags_optOut(t *testing.T) {
+	t.Setenv("DO_NOT_TRACK", "1")
+	client := &fakeTelemetryClient{}
+	res := &TelemetryResource{
+		providerEndpointFunc: func() string {
+			return "https://provider.contoso.com"
+		},
+		enabled:  true,
+		sequence: &eventSequence{},
+		client:   client,
+	}
+	model := &TelemetryResourceModel{
+		Id:       types.StringValue("00000000-0000-0000-0000-000000000000"),
+		Tags:     types.MapValueMust(types.StringType, map[string]attr.Value{"module_source": types.StringValue("foo")}),
+		Endpoint: types.StringNull(),
+	}
+	assert.Nil(t, model.sendTags(context.Background(), res, "create", nil))
+	assert.Empty(t, client.sentEvents())
+}
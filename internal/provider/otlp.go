@@ -0,0 +1,324 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// OtlpModel describes provider's `otlp` block.
+type OtlpModel struct {
+	Endpoint types.String `tfsdk:"endpoint"`
+	Protocol types.String `tfsdk:"protocol"`
+	Headers  types.Map    `tfsdk:"headers"`
+}
+
+const (
+	otlpServiceName = "terraform-provider-modtm"
+	// otlpSeverityInfo is the INFO severity number of the OpenTelemetry log data model.
+	otlpSeverityInfo = 9
+
+	otlpProtocolGrpc         = "grpc"
+	otlpProtocolHttpProtobuf = "http/protobuf"
+	otlpProtocolHttpJson     = "http/json"
+	// otlpDefaultGrpcPort is the port of the gRPC endpoint when its URL has none.
+	otlpDefaultGrpcPort = "4317"
+)
+
+var otlpProtocols = []string{otlpProtocolGrpc, otlpProtocolHttpProtobuf, otlpProtocolHttpJson}
+
+// newOtlpRoute returns a route that exports all events as OpenTelemetry log records over OTLP/gRPC, or OTLP/HTTP with
+// protobuf or JSON encoding. The endpoint, protocol and headers fall back to the standard `OTEL_EXPORTER_OTLP_*`
+// environment variables, the gRPC connection is secured with tlsConfig unless the endpoint is an `http` URL.
+func newOtlpRoute(m OtlpModel, client *http.Client, tlsConfig *tls.Config) (eventRoute, error) {
+	protocol := m.Protocol.ValueString()
+	if m.Protocol.IsNull() {
+		protocol = cmp.Or(os.Getenv("OTEL_EXPORTER_OTLP_LOGS_PROTOCOL"), os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"), otlpProtocolHttpJson)
+	}
+	if !slices.Contains(otlpProtocols, protocol) {
+		return eventRoute{}, fmt.Errorf("OTLP protocol %q is not supported, it must be one of %s", protocol, markdownCodeList(otlpProtocols))
+	}
+	endpoint := m.Endpoint.ValueString()
+	if m.Endpoint.IsNull() {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT")
+	}
+	if endpoint == "" {
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			// Only OTLP/HTTP has a path per signal, gRPC uses the base endpoint as is.
+			endpoint = base
+			if protocol != otlpProtocolGrpc {
+				endpoint = strings.TrimSuffix(base, "/") + "/v1/logs"
+			}
+		}
+	}
+	if endpoint == "" {
+		return eventRoute{}, errors.New("the OTLP endpoint must be set, either by `endpoint` or by `OTEL_EXPORTER_OTLP_LOGS_ENDPOINT` or `OTEL_EXPORTER_OTLP_ENDPOINT` environment variable")
+	}
+	headers, err := parseOtlpHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return eventRoute{}, err
+	}
+	for k, v := range m.Headers.Elements() {
+		if s, ok := v.(types.String); ok {
+			headers[k] = s.ValueString()
+		}
+	}
+	otlpClient := &otlpTelemetryClient{client: client, headers: headers, protocol: protocol}
+	if protocol == otlpProtocolGrpc {
+		if otlpClient.conn, err = newOtlpGrpcConn(endpoint, tlsConfig); err != nil {
+			return eventRoute{}, err
+		}
+	}
+	return eventRoute{
+		endpoint:     endpoint,
+		samplingRate: 1,
+		client:       otlpClient,
+	}, nil
+}
+
+// newOtlpGrpcConn returns a gRPC connection to the host of the endpoint URL, it's only established on the first
+// export. The connection is in plaintext for an `http` URL.
+func newOtlpGrpcConn(endpoint string, tlsConfig *tls.Config) (*grpc.ClientConn, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP gRPC endpoint %q, it must be an `http` or `https` URL, e.g. `https://collector:4317`", endpoint)
+	}
+	target := u.Host
+	if u.Port() == "" {
+		target = net.JoinHostPort(u.Hostname(), otlpDefaultGrpcPort)
+	}
+	creds := credentials.NewTLS(tlsConfig)
+	if strings.EqualFold(u.Scheme, "http") {
+		creds = insecure.NewCredentials()
+	}
+	return grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+}
+
+// otlpEndpointDiagnostics rejects an insecure OTLP endpoint unless `allow_insecure_endpoint` is set, the error is
+// attached to the `otlp` block's `endpoint` unless the endpoint is set by the `OTEL_EXPORTER_OTLP_*` environment
+// variables.
+func otlpEndpointDiagnostics(m OtlpModel, endpoint string, allowInsecure bool) diag.Diagnostics {
+	if m.Endpoint.IsNull() {
+		return insecureEndpointDiagnostics(path.Empty(), endpoint, "OTEL_EXPORTER_OTLP_* environment variables", allowInsecure)
+	}
+	return insecureEndpointDiagnostics(path.Root("otlp").AtName("endpoint"), endpoint, "the provider block", allowInsecure)
+}
+
+// parseOtlpHeaders parses headers in the format of `OTEL_EXPORTER_OTLP_HEADERS`, e.g. `api-key=secret,tenant=contoso`.
+func parseOtlpHeaders(raw string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid OTLP header %q, it must be `key=value`", pair)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTLP header %q: %w", pair, err)
+		}
+		headers[strings.TrimSpace(k)] = value
+	}
+	return headers, nil
+}
+
+var _ telemetryClient = &otlpTelemetryClient{}
+
+// otlpTelemetryClient exports every event as a log record, the event name becomes the body and the `event.name`
+// attribute, and the tags become attributes.
+type otlpTelemetryClient struct {
+	client  *http.Client
+	headers map[string]string
+	// protocol is one of otlpProtocols, empty means `http/json`.
+	protocol string
+	// conn is the connection to the collector when protocol is `grpc`.
+	conn *grpc.ClientConn
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpResourceLogs struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+func (o *otlpTelemetryClient) discoverEndpoint(ctx context.Context) (string, error) {
+	return "", errors.New("OTLP doesn't support endpoint discovery")
+}
+
+func (o *otlpTelemetryClient) send(ctx context.Context, endpoint string, tags map[string]string) error {
+	request := newOtlpLogsRequest(tags)
+	ctx, cancel := withSendTimeout(ctx)
+	defer cancel()
+	if o.protocol == otlpProtocolGrpc {
+		return o.export(ctx, request)
+	}
+	body, err := json.Marshal(request)
+	contentType := "application/json"
+	if o.protocol == otlpProtocolHttpProtobuf {
+		body, err = proto.Marshal(request.proto())
+		contentType = "application/x-protobuf"
+	}
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range o.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP endpoint responded %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// export sends the request over gRPC with the headers as metadata. Log records rejected by the collector are an error.
+func (o *otlpTelemetryClient) export(ctx context.Context, request otlpLogsRequest) error {
+	ctx = metadata.NewOutgoingContext(ctx, metadata.New(o.headers))
+	resp, err := collogspb.NewLogsServiceClient(o.conn).Export(ctx, request.proto())
+	if err != nil {
+		return err
+	}
+	if rejected := resp.GetPartialSuccess().GetRejectedLogRecords(); rejected > 0 {
+		return fmt.Errorf("OTLP endpoint rejected %d log records: %s", rejected, resp.GetPartialSuccess().GetErrorMessage())
+	}
+	return nil
+}
+
+// close closes the gRPC connection, if any.
+func (o *otlpTelemetryClient) close(context.Context) {
+	if o.conn != nil {
+		_ = o.conn.Close()
+	}
+}
+
+func newOtlpLogsRequest(tags map[string]string) otlpLogsRequest {
+	now := strconv.FormatInt(timeNow().UnixNano(), 10)
+	record := otlpLogRecord{
+		TimeUnixNano:         now,
+		ObservedTimeUnixNano: now,
+		SeverityNumber:       otlpSeverityInfo,
+		SeverityText:         "INFO",
+		Body:                 otlpAnyValue{StringValue: tags["event"]},
+		Attributes:           []otlpKeyValue{{Key: "event.name", Value: otlpAnyValue{StringValue: tags["event"]}}},
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		record.Attributes = append(record.Attributes, otlpKeyValue{Key: k, Value: otlpAnyValue{StringValue: tags[k]}})
+	}
+	scopeLogs := otlpScopeLogs{LogRecords: []otlpLogRecord{record}}
+	scopeLogs.Scope.Name = "modtm"
+	resourceLogs := otlpResourceLogs{ScopeLogs: []otlpScopeLogs{scopeLogs}}
+	resourceLogs.Resource.Attributes = []otlpKeyValue{{Key: "service.name", Value: otlpAnyValue{StringValue: otlpServiceName}}}
+	return otlpLogsRequest{ResourceLogs: []otlpResourceLogs{resourceLogs}}
+}
+
+// proto converts the request to its protobuf message, shared by OTLP/HTTP with protobuf encoding and OTLP/gRPC.
+func (r otlpLogsRequest) proto() *collogspb.ExportLogsServiceRequest {
+	request := &collogspb.ExportLogsServiceRequest{}
+	for _, resourceLogs := range r.ResourceLogs {
+		rl := &logspb.ResourceLogs{Resource: &resourcepb.Resource{Attributes: otlpProtoAttributes(resourceLogs.Resource.Attributes)}}
+		for _, scopeLogs := range resourceLogs.ScopeLogs {
+			sl := &logspb.ScopeLogs{Scope: &commonpb.InstrumentationScope{Name: scopeLogs.Scope.Name}}
+			for _, record := range scopeLogs.LogRecords {
+				timeUnixNano, _ := strconv.ParseUint(record.TimeUnixNano, 10, 64)
+				observedTimeUnixNano, _ := strconv.ParseUint(record.ObservedTimeUnixNano, 10, 64)
+				sl.LogRecords = append(sl.LogRecords, &logspb.LogRecord{
+					TimeUnixNano:         timeUnixNano,
+					ObservedTimeUnixNano: observedTimeUnixNano,
+					SeverityNumber:       logspb.SeverityNumber(record.SeverityNumber),
+					SeverityText:         record.SeverityText,
+					Body:                 otlpProtoString(record.Body.StringValue),
+					Attributes:           otlpProtoAttributes(record.Attributes),
+				})
+			}
+			rl.ScopeLogs = append(rl.ScopeLogs, sl)
+		}
+		request.ResourceLogs = append(request.ResourceLogs, rl)
+	}
+	return request
+}
+
+func otlpProtoAttributes(attributes []otlpKeyValue) []*commonpb.KeyValue {
+	kvs := make([]*commonpb.KeyValue, 0, len(attributes))
+	for _, kv := range attributes {
+		kvs = append(kvs, &commonpb.KeyValue{Key: kv.Key, Value: otlpProtoString(kv.Value.StringValue)})
+	}
+	return kvs
+}
+
+func otlpProtoString(s string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+}
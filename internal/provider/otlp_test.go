@@ -0,0 +1,214 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNewOtlpRoute_endpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_LOGS_PROTOCOL", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "")
+	_, err := newOtlpRoute(OtlpModel{Endpoint: types.StringNull(), Headers: types.MapNull(types.StringType)}, http.DefaultClient, nil)
+	assert.ErrorContains(t, err, "OTEL_EXPORTER_OTLP_ENDPOINT")
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	route, err := newOtlpRoute(OtlpModel{Endpoint: types.StringNull(), Headers: types.MapNull(types.StringType)}, http.DefaultClient, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "http://collector:4318/v1/logs", route.endpoint)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", "http://collector:4318/custom/logs")
+	route, err = newOtlpRoute(OtlpModel{Endpoint: types.StringNull(), Headers: types.MapNull(types.StringType)}, http.DefaultClient, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "http://collector:4318/custom/logs", route.endpoint)
+
+	route, err = newOtlpRoute(OtlpModel{Endpoint: types.StringValue("https://otel.contoso.com/v1/logs"), Headers: types.MapNull(types.StringType)}, http.DefaultClient, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://otel.contoso.com/v1/logs", route.endpoint)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/xml")
+	_, err = newOtlpRoute(OtlpModel{Endpoint: types.StringValue("https://otel.contoso.com/v1/logs"), Headers: types.MapNull(types.StringType)}, http.DefaultClient, nil)
+	assert.ErrorContains(t, err, "http/xml")
+
+	t.Setenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc")
+	route, err = newOtlpRoute(OtlpModel{Endpoint: types.StringNull(), Headers: types.MapNull(types.StringType)}, http.DefaultClient, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "http://collector:4318/", route.endpoint, "the gRPC endpoint has no path per signal")
+	route.client.(*otlpTelemetryClient).close(context.Background())
+
+	route, err = newOtlpRoute(OtlpModel{Endpoint: types.StringNull(), Protocol: types.StringValue(otlpProtocolHttpProtobuf), Headers: types.MapNull(types.StringType)}, http.DefaultClient, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "http://collector:4318/v1/logs", route.endpoint, "the protocol in the otlp block wins over the environment")
+}
+
+func TestOtlpEndpointDiagnostics(t *testing.T) {
+	configured := OtlpModel{Endpoint: types.StringValue("http://otel.contoso.com/v1/logs"), Headers: types.MapNull(types.StringType)}
+	diags := otlpEndpointDiagnostics(configured, "http://otel.contoso.com/v1/logs", false)
+	require.True(t, diags.HasError())
+	assert.Contains(t, diags[0].Detail(), "the provider block")
+	assert.False(t, otlpEndpointDiagnostics(configured, "http://otel.contoso.com/v1/logs", true).HasError())
+
+	fromEnv := OtlpModel{Endpoint: types.StringNull(), Headers: types.MapNull(types.StringType)}
+	diags = otlpEndpointDiagnostics(fromEnv, "http://collector.contoso.com:4318/v1/logs", false)
+	require.True(t, diags.HasError())
+	assert.Contains(t, diags[0].Detail(), "OTEL_EXPORTER_OTLP_*")
+	assert.False(t, otlpEndpointDiagnostics(fromEnv, "http://localhost:4318/v1/logs", false).HasError())
+	assert.False(t, otlpEndpointDiagnostics(fromEnv, "https://collector.contoso.com:4318/v1/logs", false).HasError())
+}
+
+func TestParseOtlpHeaders(t *testing.T) {
+	headers, err := parseOtlpHeaders("api-key=se%3Dcret, tenant=contoso")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"api-key": "se=cret", "tenant": "contoso"}, headers)
+
+	headers, err = parseOtlpHeaders("")
+	require.NoError(t, err)
+	assert.Empty(t, headers)
+
+	_, err = parseOtlpHeaders("api-key")
+	assert.Error(t, err)
+}
+
+func TestOtlpTelemetryClient_send(t *testing.T) {
+	var request otlpLogsRequest
+	var apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("api-key")
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &request)
+	}))
+	defer server.Close()
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return time.Unix(1700000000, 0)
+	})
+	defer stub.Reset()
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=from-env,tenant=contoso")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "")
+
+	route, err := newOtlpRoute(OtlpModel{
+		Endpoint: types.StringValue(server.URL + "/v1/logs"),
+		Headers:  types.MapValueMust(types.StringType, map[string]attr.Value{"api-key": types.StringValue("secret")}),
+	}, server.Client(), nil)
+	require.NoError(t, err)
+	require.NoError(t, route.client.send(context.Background(), route.endpoint, map[string]string{"event": "create", "module_source": "foo"}))
+
+	assert.Equal(t, "secret", apiKey)
+	require.Len(t, request.ResourceLogs, 1)
+	assert.Equal(t, []otlpKeyValue{{Key: "service.name", Value: otlpAnyValue{StringValue: otlpServiceName}}}, request.ResourceLogs[0].Resource.Attributes)
+	require.Len(t, request.ResourceLogs[0].ScopeLogs, 1)
+	records := request.ResourceLogs[0].ScopeLogs[0].LogRecords
+	require.Len(t, records, 1)
+	assert.Equal(t, "1700000000000000000", records[0].TimeUnixNano)
+	assert.Equal(t, "create", records[0].Body.StringValue)
+	assert.Equal(t, []otlpKeyValue{
+		{Key: "event.name", Value: otlpAnyValue{StringValue: "create"}},
+		{Key: "event", Value: otlpAnyValue{StringValue: "create"}},
+		{Key: "module_source", Value: otlpAnyValue{StringValue: "foo"}},
+	}, records[0].Attributes)
+}
+
+func TestOtlpTelemetryClient_sendHttpProtobuf(t *testing.T) {
+	var request collogspb.ExportLogsServiceRequest
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		_ = proto.Unmarshal(data, &request)
+	}))
+	defer server.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "")
+
+	route, err := newOtlpRoute(OtlpModel{
+		Endpoint: types.StringValue(server.URL + "/v1/logs"),
+		Protocol: types.StringValue(otlpProtocolHttpProtobuf),
+		Headers:  types.MapNull(types.StringType),
+	}, server.Client(), nil)
+	require.NoError(t, err)
+	require.NoError(t, route.client.send(context.Background(), route.endpoint, map[string]string{"event": "create", "module_source": "foo"}))
+
+	assert.Equal(t, "application/x-protobuf", contentType)
+	assertOtlpProtoRequest(t, &request)
+}
+
+// otlpLogsServer is an OTLP/gRPC collector that keeps the last exported request and its metadata.
+type otlpLogsServer struct {
+	collogspb.UnimplementedLogsServiceServer
+	mu       sync.Mutex
+	request  *collogspb.ExportLogsServiceRequest
+	metadata metadata.MD
+}
+
+func (s *otlpLogsServer) Export(ctx context.Context, request *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.request = request
+	s.metadata, _ = metadata.FromIncomingContext(ctx)
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func TestOtlpTelemetryClient_sendGrpc(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	collector := &otlpLogsServer{}
+	collogspb.RegisterLogsServiceServer(server, collector)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=secret")
+
+	route, err := newOtlpRoute(OtlpModel{
+		Endpoint: types.StringValue("http://" + listener.Addr().String()),
+		Protocol: types.StringValue(otlpProtocolGrpc),
+		Headers:  types.MapNull(types.StringType),
+	}, http.DefaultClient, nil)
+	require.NoError(t, err)
+	defer route.client.(*otlpTelemetryClient).close(context.Background())
+	require.NoError(t, route.client.send(context.Background(), route.endpoint, map[string]string{"event": "create", "module_source": "foo"}))
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	assert.Equal(t, []string{"secret"}, collector.metadata.Get("api-key"))
+	assertOtlpProtoRequest(t, collector.request)
+}
+
+func assertOtlpProtoRequest(t *testing.T, request *collogspb.ExportLogsServiceRequest) {
+	require.Len(t, request.GetResourceLogs(), 1)
+	resourceLogs := request.GetResourceLogs()[0]
+	assert.Equal(t, otlpServiceName, resourceLogs.GetResource().GetAttributes()[0].GetValue().GetStringValue())
+	require.Len(t, resourceLogs.GetScopeLogs(), 1)
+	records := resourceLogs.GetScopeLogs()[0].GetLogRecords()
+	require.Len(t, records, 1)
+	assert.Equal(t, "create", records[0].GetBody().GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, records[0].GetSeverityNumber())
+	var keys []string
+	for _, kv := range records[0].GetAttributes() {
+		keys = append(keys, kv.GetKey())
+	}
+	assert.Equal(t, []string{"event.name", "event", "module_source"}, keys)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ function.Function = &ParseGitRemoteFunction{}
+
+func NewParseGitRemoteFunction() function.Function {
+	return &ParseGitRemoteFunction{}
+}
+
+type ParseGitRemoteFunction struct {
+}
+
+func (m *ParseGitRemoteFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_git_remote"
+}
+
+func (m *ParseGitRemoteFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "`parse_git_remote` function",
+		MarkdownDescription: "This function parses a git remote URL, e.g. the output of `git config --get remote.origin.url`, and returns an object with its `host`, `org` and `repo`, so tags like `avm_git_org` and `avm_git_repo` could be populated reliably. " +
+			"HTTPS (`https://github.com/Azure/terraform-azurerm-aks.git`), SSH (`ssh://git@github.com/Azure/terraform-azurerm-aks.git`) and scp-like (`git@github.com:Azure/terraform-azurerm-aks.git`) remotes are supported. " +
+			"`repo` is the last segment of the path without the `.git` suffix and `org` is everything before it, e.g. `group/subgroup` on GitLab or `org/project` on Azure DevOps.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "url",
+				MarkdownDescription: "The git remote URL",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: map[string]attr.Type{
+				"host": types.StringType,
+				"org":  types.StringType,
+				"repo": types.StringType,
+			},
+		},
+	}
+}
+
+func (m *ParseGitRemoteFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var remote string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &remote))
+	if resp.Error != nil {
+		return
+	}
+	r, err := parseGitRemote(remote)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, r))
+}
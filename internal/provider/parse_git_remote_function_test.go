@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccParseGitRemoteFunction(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccParseGitRemoteFunctionConfig("git@github.com:Azure/terraform-azurerm-aks.git"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("host", "github.com"),
+					resource.TestCheckOutput("org", "Azure"),
+					resource.TestCheckOutput("repo", "terraform-azurerm-aks"),
+				),
+			},
+			{
+				Config:      testAccParseGitRemoteFunctionConfig("terraform-azurerm-aks"),
+				ExpectError: regexp.MustCompile("invalid git remote"),
+			},
+		},
+	})
+}
+
+func testAccParseGitRemoteFunctionConfig(remote string) string {
+	return fmt.Sprintf(`
+locals {
+  remote = provider::modtm::parse_git_remote("%s")
+}
+
+output "host" {
+  value = local.remote.host
+}
+
+output "org" {
+  value = local.remote.org
+}
+
+output "repo" {
+  value = local.remote.repo
+}
+`, remote)
+}
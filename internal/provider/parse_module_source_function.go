@@ -0,0 +1,65 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ function.Function = &ParseModuleSourceFunction{}
+
+func NewParseModuleSourceFunction() function.Function {
+	return &ParseModuleSourceFunction{}
+}
+
+type ParseModuleSourceFunction struct {
+}
+
+func (m *ParseModuleSourceFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_module_source"
+}
+
+func (m *ParseModuleSourceFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "`parse_module_source` function",
+		MarkdownDescription: fmt.Sprintf("This function parses a module source, e.g. the output of `module_source`, and returns an object with its `kind`, one of %s, so module authors don't have to write regexes in HCL. ", markdownCodeList(moduleSourceKinds)) +
+			"Registry sources like `registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm//modules/key` are split into `host`, `namespace`, `name`, `target_system` and `submodule`, the `host` defaults to `registry.terraform.io`. " +
+			"For other remote sources only `submodule`, the path after `//`, is set, e.g. `modules/vnet` for `git::https://example.com/network.git//modules/vnet?ref=v1.2.0`. The attributes that don't apply are null.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "source",
+				MarkdownDescription: "The module source",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: map[string]attr.Type{
+				"kind":          types.StringType,
+				"host":          types.StringType,
+				"namespace":     types.StringType,
+				"name":          types.StringType,
+				"target_system": types.StringType,
+				"submodule":     types.StringType,
+			},
+		},
+	}
+}
+
+func (m *ParseModuleSourceFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var source string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &source))
+	if resp.Error != nil {
+		return
+	}
+	address, err := parseModuleSource(source)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, address))
+}
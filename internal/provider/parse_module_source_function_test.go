@@ -0,0 +1,69 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccParseModuleSourceFunction(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccParseModuleSourceFunctionConfig("registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm//modules/key"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("kind", "registry"),
+					resource.TestCheckOutput("namespace", "Azure"),
+					resource.TestCheckOutput("name", "avm-res-keyvault-vault"),
+					resource.TestCheckOutput("submodule", "modules/key"),
+				),
+			},
+			{
+				Config: testAccParseModuleSourceFunctionConfig("git::https://example.com/network.git//modules/vnet?ref=v1.2.0"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("kind", "git"),
+					resource.TestCheckOutput("namespace", "null"),
+					resource.TestCheckOutput("submodule", "modules/vnet"),
+				),
+			},
+			{
+				Config:      testAccParseModuleSourceFunctionConfig(""),
+				ExpectError: regexp.MustCompile("invalid module source"),
+			},
+		},
+	})
+}
+
+func testAccParseModuleSourceFunctionConfig(source string) string {
+	return fmt.Sprintf(`
+locals {
+  source = provider::modtm::parse_module_source("%s")
+}
+
+output "kind" {
+  value = local.source.kind
+}
+
+output "namespace" {
+  value = coalesce(local.source.namespace, "null")
+}
+
+output "name" {
+  value = coalesce(local.source.name, "null")
+}
+
+output "submodule" {
+  value = coalesce(local.source.submodule, "null")
+}
+`, source)
+}
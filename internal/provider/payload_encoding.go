@@ -0,0 +1,140 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+const (
+	payloadEncodingJSON    = "json"
+	payloadEncodingMsgpack = "msgpack"
+)
+
+var payloadEncodings = []string{payloadEncodingJSON, payloadEncodingMsgpack}
+
+const (
+	payloadFormatLegacy = "legacy"
+	payloadFormatV2     = "v2"
+	// payloadSchemaVersion is the `schema_version` of the v2 envelope, it's bumped on breaking changes.
+	payloadSchemaVersion = 2
+)
+
+var payloadFormats = []string{payloadFormatLegacy, payloadFormatV2}
+
+var payloadContentTypes = map[string]string{
+	payloadEncodingJSON:    "application/json",
+	payloadEncodingMsgpack: "application/msgpack",
+}
+
+// payloadEnvelope is the v2 payload, the tags that identify the event are lifted out of the tags so collectors
+// could rely on them.
+type payloadEnvelope struct {
+	SchemaVersion int               `json:"schema_version"`
+	Event         string            `json:"event"`
+	ResourceId    string            `json:"resource_id"`
+	Timestamp     string            `json:"timestamp"`
+	Tags          map[string]string `json:"tags"`
+}
+
+func newPayloadEnvelope(tags map[string]string) payloadEnvelope {
+	e := payloadEnvelope{
+		SchemaVersion: payloadSchemaVersion,
+		Event:         tags["event"],
+		ResourceId:    tags["resource_id"],
+		Timestamp:     tags["timestamp"],
+		Tags:          make(map[string]string, len(tags)),
+	}
+	for k, v := range tags {
+		if k != "event" && k != "resource_id" && k != "timestamp" {
+			e.Tags[k] = v
+		}
+	}
+	return e
+}
+
+// payload returns the value to be encoded as payload of the tags in the given format, the tags themselves for
+// the legacy format.
+func payload(format string, tags map[string]string) any {
+	if format == payloadFormatV2 {
+		return newPayloadEnvelope(tags)
+	}
+	return tags
+}
+
+// encodePayload encodes the tags in the given format with the given encoding and returns the body with its
+// Content-Type.
+func encodePayload(encoding, format string, tags map[string]string) ([]byte, string, error) {
+	switch encoding {
+	case payloadEncodingMsgpack:
+		if format == payloadFormatV2 {
+			return newPayloadEnvelope(tags).msgpack(), payloadContentTypes[payloadEncodingMsgpack], nil
+		}
+		return encodeMsgpackMap(tags), payloadContentTypes[payloadEncodingMsgpack], nil
+	case payloadEncodingJSON, "":
+		body, err := json.Marshal(payload(format, tags))
+		return body, payloadContentTypes[payloadEncodingJSON], err
+	default:
+		return nil, "", fmt.Errorf("unknown payload encoding %q", encoding)
+	}
+}
+
+// encodeMsgpackMap encodes a map of strings as a MessagePack map, keys are sorted so the output is stable.
+func encodeMsgpackMap(m map[string]string) []byte {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b []byte
+	n := len(keys)
+	switch {
+	case n < 16:
+		b = append(b, 0x80|byte(n))
+	case n <= 0xffff:
+		b = append(b, 0xde)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, 0xdf)
+		b = binary.BigEndian.AppendUint32(b, uint32(n)) // #nosec G115
+	}
+	for _, k := range keys {
+		b = appendMsgpackString(b, k)
+		b = appendMsgpackString(b, m[k])
+	}
+	return b
+}
+
+// msgpack encodes the envelope as a MessagePack map with the same keys as its JSON encoding.
+func (e payloadEnvelope) msgpack() []byte {
+	b := []byte{0x85}
+	b = appendMsgpackString(b, "schema_version")
+	b = append(b, byte(e.SchemaVersion)) // positive fixint
+	for _, kv := range [][2]string{{"event", e.Event}, {"resource_id", e.ResourceId}, {"timestamp", e.Timestamp}} {
+		b = appendMsgpackString(b, kv[0])
+		b = appendMsgpackString(b, kv[1])
+	}
+	b = appendMsgpackString(b, "tags")
+	return append(b, encodeMsgpackMap(e.Tags)...)
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= 0xff:
+		b = append(b, 0xd9, byte(n))
+	case n <= 0xffff:
+		b = append(b, 0xda)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, 0xdb)
+		b = binary.BigEndian.AppendUint32(b, uint32(n)) // #nosec G115
+	}
+	return append(b, s...)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeMsgpackMap(t *testing.T) {
+	cases := []struct {
+		desc     string
+		m        map[string]string
+		expected []byte
+	}{
+		{
+			desc:     "empty map",
+			m:        map[string]string{},
+			expected: []byte{0x80},
+		},
+		{
+			desc:     "fixstr keys are sorted",
+			m:        map[string]string{"event": "create", "a": ""},
+			expected: append([]byte{0x82, 0xa1, 'a', 0xa0, 0xa5}, append([]byte("event"), append([]byte{0xa6}, "create"...)...)...),
+		},
+		{
+			desc:     "str8",
+			m:        map[string]string{"k": strings.Repeat("x", 32)},
+			expected: append([]byte{0x81, 0xa1, 'k', 0xd9, 32}, strings.Repeat("x", 32)...),
+		},
+		{
+			desc:     "str16",
+			m:        map[string]string{"k": strings.Repeat("x", 256)},
+			expected: append([]byte{0x81, 0xa1, 'k', 0xda, 0x01, 0x00}, strings.Repeat("x", 256)...),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, encodeMsgpackMap(c.m))
+		})
+	}
+}
+
+func TestEncodeMsgpackMap_map16(t *testing.T) {
+	m := make(map[string]string)
+	for _, k := range strings.Split("abcdefghijklmnop", "") {
+		m[k] = ""
+	}
+	b := encodeMsgpackMap(m)
+	assert.Equal(t, []byte{0xde, 0x00, 0x10, 0xa1, 'a', 0xa0}, b[:6])
+	assert.Len(t, b, 3+16*3)
+}
+
+func TestEncodePayload(t *testing.T) {
+	body, contentType, err := encodePayload("", "", map[string]string{"event": "create"})
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", contentType)
+	assert.JSONEq(t, `{"event":"create"}`, string(body))
+
+	_, contentType, err = encodePayload(payloadEncodingMsgpack, "", map[string]string{"event": "create"})
+	require.NoError(t, err)
+	assert.Equal(t, "application/msgpack", contentType)
+
+	_, _, err = encodePayload("xml", "", nil)
+	assert.Error(t, err)
+}
+
+func TestEncodePayload_v2(t *testing.T) {
+	tags := map[string]string{"event": "create", "resource_id": "id", "timestamp": "2024-01-02T03:04:05Z", "module_source": "foo"}
+	body, _, err := encodePayload(payloadEncodingJSON, payloadFormatV2, tags)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"schema_version":2,"event":"create","resource_id":"id","timestamp":"2024-01-02T03:04:05Z","tags":{"module_source":"foo"}}`, string(body))
+	assert.Len(t, tags, 4, "tags must not be modified")
+
+	body, _, err = encodePayload(payloadEncodingMsgpack, payloadFormatV2, tags)
+	require.NoError(t, err)
+	expected := append([]byte{0x85, 0xae}, "schema_version"...)
+	expected = append(expected, 0x02)
+	for _, s := range []string{"event", "create", "resource_id", "id", "timestamp", "2024-01-02T03:04:05Z", "tags"} {
+		expected = appendMsgpackString(expected, s)
+	}
+	expected = append(expected, encodeMsgpackMap(map[string]string{"module_source": "foo"})...)
+	assert.Equal(t, expected, body)
+}
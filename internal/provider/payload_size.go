@@ -0,0 +1,87 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// defaultMaxPayloadSize is the default limit of the JSON encoded tags of an event, in bytes.
+	defaultMaxPayloadSize = 16 * 1024
+	// minMaxPayloadSize leaves room for the tags added by the provider.
+	minMaxPayloadSize = 1024
+	// truncatedSuffix marks a truncated tag value.
+	truncatedSuffix = "[TRUNCATED]"
+)
+
+func payloadSize(tags map[string]string) int {
+	content, _ := json.Marshal(tags)
+	return len(content)
+}
+
+// truncatePayload truncates the longest tag values until the JSON encoded tags fit in maxSize bytes. The longest
+// value is truncated first, ties are broken by the tag key, so the same tags are always truncated the same way.
+// It returns the sorted keys of the truncated tags, ok is false when the tags don't fit even if all values are
+// truncated.
+func truncatePayload(tags map[string]string, maxSize int) (truncated []string, ok bool) {
+	for {
+		size := payloadSize(tags)
+		if size <= maxSize {
+			slices.Sort(truncated)
+			return truncated, true
+		}
+		key := ""
+		for k, v := range tags {
+			if slices.Contains(reservedTags, k) || len(v) <= len(truncatedSuffix) {
+				continue
+			}
+			if key == "" || len(v) > len(tags[key]) || (len(v) == len(tags[key]) && k < key) {
+				key = k
+			}
+		}
+		if key == "" {
+			slices.Sort(truncated)
+			return truncated, false
+		}
+		v := tags[key]
+		keep := max(len(v)-(size-maxSize)-len(truncatedSuffix), 0)
+		for keep > 0 && !utf8.RuneStart(v[keep]) {
+			keep--
+		}
+		tags[key] = v[:keep] + truncatedSuffix
+		if !slices.Contains(truncated, key) {
+			truncated = append(truncated, key)
+		}
+	}
+}
+
+// payloadSizeStage truncates the tag values of an event whose JSON encoded tags are larger than maxSize bytes, so a
+// mistakenly large tag map cannot cause collector rejections. The event is dropped if it's still too large. A
+// non-positive maxSize means no limit.
+func payloadSizeStage(maxSize int) eventStage {
+	return eventStage{
+		name: stagePayloadSize,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			if maxSize <= 0 {
+				return true
+			}
+			truncated, ok := truncatePayload(e.tags, maxSize)
+			if !ok {
+				errorLog(ctx, fmt.Sprintf("skip %s telemetry event: payload is larger than %d bytes", e.name, maxSize))
+				return false
+			}
+			if len(truncated) > 0 {
+				traceLog(ctx, fmt.Sprintf("truncated %s tags of %s telemetry event", strings.Join(truncated, ", "), e.name))
+				e.truncatedTags = truncated
+			}
+			return true
+		},
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncatePayload(t *testing.T) {
+	tags := map[string]string{
+		"event":       "create",
+		"resource_id": strings.Repeat("r", 100),
+		"a":           strings.Repeat("a", 300),
+		"b":           strings.Repeat("b", 300),
+		"c":           "small",
+	}
+	truncated, ok := truncatePayload(tags, 500)
+	require.True(t, ok)
+	assert.Equal(t, []string{"a"}, truncated)
+	assert.LessOrEqual(t, payloadSize(tags), 500)
+	assert.True(t, strings.HasSuffix(tags["a"], truncatedSuffix))
+	assert.Equal(t, strings.Repeat("b", 300), tags["b"])
+	assert.Equal(t, "small", tags["c"])
+	assert.Equal(t, strings.Repeat("r", 100), tags["resource_id"])
+
+	truncated, ok = truncatePayload(map[string]string{"a": "small"}, 500)
+	assert.True(t, ok)
+	assert.Empty(t, truncated)
+
+	_, ok = truncatePayload(map[string]string{"resource_id": strings.Repeat("r", 600)}, 500)
+	assert.False(t, ok)
+}
+
+func TestTruncatePayload_keepsValidUtf8(t *testing.T) {
+	tags := map[string]string{"a": strings.Repeat("中", 100)}
+	_, ok := truncatePayload(tags, 100)
+	require.True(t, ok)
+	assert.True(t, strings.HasSuffix(tags["a"], truncatedSuffix))
+	assert.True(t, strings.HasPrefix(tags["a"], "中"))
+	assert.LessOrEqual(t, payloadSize(tags), 100)
+	assert.NotContains(t, tags["a"], "�")
+}
+
+func TestPayloadSizeStage(t *testing.T) {
+	e := &telemetryEvent{name: "create", tags: map[string]string{"a": strings.Repeat("a", 2000)}}
+	assert.True(t, payloadSizeStage(minMaxPayloadSize).process(context.Background(), e))
+	assert.Equal(t, []string{"a"}, e.truncatedTags)
+	assert.LessOrEqual(t, payloadSize(e.tags), minMaxPayloadSize)
+
+	e = &telemetryEvent{name: "create", tags: map[string]string{"a": strings.Repeat("a", 2000)}}
+	assert.True(t, payloadSizeStage(0).process(context.Background(), e))
+	assert.Empty(t, e.truncatedTags)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// prewarmer is implemented by telemetry clients that could establish the connection to an endpoint ahead of the
+// first event.
+type prewarmer interface {
+	prewarm(ctx context.Context, endpoint string) error
+}
+
+var _ prewarmer = &httpTelemetryClient{}
+
+// prewarm sends a HEAD request to the endpoint, so the DNS lookup and the TLS handshake are done and the connection
+// is kept alive in the client's pool for the first event. The response status doesn't matter.
+func (h *httpTelemetryClient) prewarm(ctx context.Context, endpoint string) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// prewarmConnection resolves the provider's endpoint and prewarms the connection to it, when the client supports it.
+func prewarmConnection(ctx context.Context, client telemetryClient, endpointFunc func() string) {
+	p, ok := client.(prewarmer)
+	if !ok {
+		return
+	}
+	endpoint := endpointFunc()
+	if endpoint == "" {
+		return
+	}
+	if err := p.prewarm(ctx, endpoint); err != nil {
+		traceLog(ctx, fmt.Sprintf("failed to prewarm connection to %s: %s", endpoint, err.Error()))
+		return
+	}
+	traceLog(ctx, fmt.Sprintf("prewarmed connection to %s", endpoint))
+}
@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrewarmConnection_connectionIsReusedByFirstEvent(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+	connections := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		methods = append(methods, request.Method)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			defer mu.Unlock()
+			connections++
+		}
+	}
+	server.Start()
+	defer server.Close()
+	client := newHttpTelemetryClient(server.Client(), payloadEncodingJSON)
+
+	prewarmConnection(context.Background(), client, func() string {
+		return server.URL
+	})
+	require.NoError(t, client.send(context.Background(), server.URL, map[string]string{"event": "create"}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{http.MethodHead, http.MethodPost}, methods)
+	assert.Equal(t, 1, connections)
+}
+
+func TestPrewarmConnection_noEndpoint(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+	client := newHttpTelemetryClient(server.Client(), payloadEncodingJSON)
+
+	prewarmConnection(context.Background(), client, func() string {
+		return ""
+	})
+	assert.False(t, requested)
+}
@@ -5,18 +5,26 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/boolvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	listvalidators "github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -27,6 +35,7 @@ import (
 
 // Ensure ModuleTelemetryProvider satisfies various provider interfaces.
 var _ provider.Provider = &ModuleTelemetryProvider{}
+var _ provider.ProviderWithEphemeralResources = &ModuleTelemetryProvider{}
 
 // ModuleTelemetryProvider defines the provider implementation.
 type ModuleTelemetryProvider struct {
@@ -34,20 +43,205 @@ type ModuleTelemetryProvider struct {
 	// provider is built and ran locally, and "test" when running acceptance
 	// testing.
 	version string
+	// client is used instead of the default HTTP client when it's not nil, so tests and embedders could supply fakes.
+	client telemetryClient
+
+	functionTelemetryMu sync.Mutex
+	// functionTelemetryResolved is set once the function telemetry is set by Configure or read from the environment.
+	functionTelemetryResolved bool
+	functionTelemetry         *functionTelemetry
+
+	// modulesJson caches the parsed modules.json files for the functions, data sources and resources.
+	modulesJson *modulesJsonCache
 }
 
 // ModuleTelemetryProviderModel describes the provider data model.
 type ModuleTelemetryProviderModel struct {
-	Endpoint          types.String `tfsdk:"endpoint"`
-	Enabled           types.Bool   `tfsdk:"enabled"`
-	ModuleSourceRegex types.List   `tfsdk:"module_source_regex"`
+	Endpoint                types.String           `tfsdk:"endpoint"`
+	Endpoints               types.List             `tfsdk:"endpoints"`
+	FallbackEndpoints       types.List             `tfsdk:"fallback_endpoints"`
+	AllowInsecureEndpoint   types.Bool             `tfsdk:"allow_insecure_endpoint"`
+	Enabled                 types.Bool             `tfsdk:"enabled"`
+	ModuleSourceRegex       types.List             `tfsdk:"module_source_regex"`
+	ModuleSourceDenyRegex   types.List             `tfsdk:"module_source_deny_regex"`
+	ModulesJsonPath         types.String           `tfsdk:"modules_json_path"`
+	StrictModuleResolution  types.Bool             `tfsdk:"strict_module_resolution"`
+	DestroyPlannedEvent     types.Bool             `tfsdk:"destroy_planned_event"`
+	SkipOnTerraformTest     types.Bool             `tfsdk:"skip_on_terraform_test"`
+	MaxConcurrentSends      types.Int64            `tfsdk:"max_concurrent_sends"`
+	MaxEventsPerMinute      types.Int64            `tfsdk:"max_events_per_minute"`
+	SendQueueSize           types.Int64            `tfsdk:"send_queue_size"`
+	SendQueueOverflow       types.String           `tfsdk:"send_queue_overflow_policy"`
+	DisabledEventStages     types.List             `tfsdk:"disabled_event_stages"`
+	EnrichmentCommand       types.List             `tfsdk:"enrichment_command"`
+	HighPriorityEvents      types.List             `tfsdk:"high_priority_events"`
+	TimestampFormat         types.String           `tfsdk:"timestamp_format"`
+	TimestampPrecision      types.String           `tfsdk:"timestamp_precision"`
+	FipsMode                types.Bool             `tfsdk:"fips_mode"`
+	DetectSystemProxy       types.Bool             `tfsdk:"detect_system_proxy"`
+	ProxyUrl                types.String           `tfsdk:"proxy_url"`
+	CaCertPem               types.String           `tfsdk:"ca_cert_pem"`
+	ClientCertPem           types.String           `tfsdk:"client_cert_pem"`
+	ClientKeyPem            types.String           `tfsdk:"client_key_pem"`
+	InsecureSkipVerify      types.Bool             `tfsdk:"insecure_skip_verify"`
+	ProxyUsername           types.String           `tfsdk:"proxy_username"`
+	ProxyPassword           types.String           `tfsdk:"proxy_password"`
+	Offline                 types.Bool             `tfsdk:"offline"`
+	SinkPath                types.String           `tfsdk:"sink_path"`
+	Sink                    types.String           `tfsdk:"sink"`
+	DisableDiscovery        types.Bool             `tfsdk:"disable_default_endpoint_discovery"`
+	PayloadEncoding         types.String           `tfsdk:"payload_encoding"`
+	PayloadFormat           types.String           `tfsdk:"payload_format"`
+	Transport               types.String           `tfsdk:"transport"`
+	SendMode                types.String           `tfsdk:"send_mode"`
+	CircuitBreakerCooldown  types.String           `tfsdk:"circuit_breaker_cooldown"`
+	EndpointCacheTTL        types.String           `tfsdk:"endpoint_cache_ttl"`
+	SpoolDir                types.String           `tfsdk:"spool_dir"`
+	IncludeBackendId        types.Bool             `tfsdk:"include_backend_id"`
+	BackendIdSalt           types.String           `tfsdk:"backend_id_salt"`
+	SamplingRules           types.List             `tfsdk:"sampling_rules"`
+	Routes                  types.List             `tfsdk:"routes"`
+	AppInsightsConnection   types.String           `tfsdk:"app_insights_connection_string"`
+	EventHub                *EventHubModel         `tfsdk:"event_hub"`
+	Otlp                    *OtlpModel             `tfsdk:"otlp"`
+	FunctionTelemetry       types.Bool             `tfsdk:"function_telemetry"`
+	NormalizeTimestamp      types.Bool             `tfsdk:"normalize_git_timestamp"`
+	ThrottleWindow          types.String           `tfsdk:"throttle_window"`
+	ThrottleCachePath       types.String           `tfsdk:"throttle_cache_path"`
+	AppConfiguration        *AppConfigurationModel `tfsdk:"app_configuration"`
+	IncludeAzureEnvironment types.Bool             `tfsdk:"include_azure_environment"`
+	CollectRuntimeMetadata  types.Bool             `tfsdk:"collect_runtime_metadata"`
+	CollectAzureContext     types.Bool             `tfsdk:"collect_azure_context"`
+	HashTags                types.List             `tfsdk:"hash_tags"`
+	RedactPatterns          types.List             `tfsdk:"redact_patterns"`
+	MaxPayloadSize          types.Int64            `tfsdk:"max_payload_size"`
+	TagLimits               *TagLimitsModel        `tfsdk:"tag_limits"`
+	HashTagsSalt            types.String           `tfsdk:"hash_tags_salt"`
+	PrewarmConnection       types.Bool             `tfsdk:"prewarm_connection"`
+	RequestTimeout          types.String           `tfsdk:"request_timeout"`
+	EndpointHeaders         types.Map              `tfsdk:"endpoint_headers"`
+	BearerToken             types.String           `tfsdk:"bearer_token"`
+	UseAzureAuth            types.Bool             `tfsdk:"use_azure_auth"`
+	AzureAuthResource       types.String           `tfsdk:"azure_auth_resource"`
+	Tags                    types.Map              `tfsdk:"tags"`
 }
 
 type providerConfig struct {
-	endpointFunc      func() string
-	enabled           bool
-	defaultEndpoint   bool
-	moduleSourceRegex []*regexp.Regexp
+	endpointFunc    func() string
+	enabled         bool
+	defaultEndpoint bool
+	// endpoints are the additional endpoints that every event is sent to, in parallel with the provider's endpoint.
+	endpoints []string
+	// fallbackEndpoints are tried in order when an event cannot be sent to the provider's endpoint.
+	fallbackEndpoints []string
+	// allowInsecureEndpoint allows `http` endpoints on other hosts than localhost.
+	allowInsecureEndpoint bool
+	moduleSourceRegex     []*regexp.Regexp
+	// moduleSourceDenyRegex excludes module sources even when they match moduleSourceRegex.
+	moduleSourceDenyRegex []*regexp.Regexp
+	modulesJsonPath       string
+	modulesJson           *modulesJsonCache
+	// strictModuleResolution returns a warning when the module source and version cannot be resolved.
+	strictModuleResolution bool
+	// terraformTest is true when the provider is launched by `terraform test`.
+	terraformTest       bool
+	skipOnTerraformTest bool
+	terraformVersion    string
+	// runtimeMetadata are the tags describing the runtime, nil when `collect_runtime_metadata` is `false`.
+	runtimeMetadata map[string]string
+	// terraformCommand is the subcommand of the Terraform CLI that launched the provider, empty if unknown.
+	terraformCommand string
+	// sendLimiter is shared by all resources so the limit applies to the whole provider instance.
+	sendLimiter *sendLimiter
+	// rateLimiter is shared by all resources so the rate applies to the whole provider instance.
+	rateLimiter       *rateLimiter
+	pipeline          eventPipeline
+	enrichmentCommand []string
+	// destroyPlannedEvent sends the `destroy_planned` event when a `modtm_telemetry` resource is planned for destruction.
+	destroyPlannedEvent bool
+	// highPriorityEvents are the events that are kept when low priority events are shed under pressure.
+	highPriorityEvents []string
+	// sequence is shared by all resources so the sequence numbers are monotonic across the whole run.
+	sequence           *eventSequence
+	timestampFormat    string
+	timestampPrecision string
+	crypto             cryptoPolicy
+	// client sends telemetry and discovers the default endpoint.
+	client telemetryClient
+	// offline guarantees that the provider never makes any network call.
+	offline bool
+	// sink receives every event locally, nil if events are not written locally.
+	sink eventSink
+	// backendId is the salted hash of the backend configuration, empty if it's not included.
+	backendId string
+	// samplingRules returns the sampling rules, they might be read from App Configuration on first use.
+	samplingRules func() []samplingRule
+	// endpointSource tells where the provider's endpoint comes from, one of the endpointSource constants.
+	endpointSource     string
+	maxConcurrentSends int64
+	maxEventsPerMinute int64
+	payloadEncoding    string
+	payloadFormat      string
+	transport          string
+	sendMode           string
+	// executionEnvironment tells where the provider runs, e.g. a GitHub-hosted runner or Azure Cloud Shell.
+	executionEnvironment string
+	// includeAzureEnvironment tags events with coarse facts about the Azure VM or agent the provider runs on.
+	includeAzureEnvironment bool
+	// collectAzureContext tags events with the hashed subscription and tenant ids.
+	collectAzureContext bool
+	// hashTags are the keys of the tags whose values are replaced with their SHA-256 hashes.
+	hashTags     []string
+	hashTagsSalt string
+	// redactPatterns match the substrings of tag values that are replaced with `[REDACTED]`.
+	redactPatterns []*regexp.Regexp
+	// maxPayloadSize is the limit of the JSON encoded tags of an event, in bytes.
+	maxPayloadSize int
+	tagLimits      tagLimits
+	// normalizeGitTimestamp rewrites the `avm_git_last_modified_at` tag to UTC RFC3339.
+	normalizeGitTimestamp bool
+	// spool persists delete events that failed to be sent, nil if spooling is off.
+	spool *eventSpool
+	// throttle suppresses repeated events within the throttle window, nil if throttling is off.
+	throttle *eventThrottle
+	// routes mirror matching events to additional endpoints.
+	routes []eventRoute
+	// requestTimeout is how long to wait for the endpoint to respond to an event.
+	requestTimeout time.Duration
+	// tags are merged into the tags of every event, the resource's tags win on conflicts.
+	tags map[string]string
+}
+
+const (
+	endpointSourceProvider         = "provider"
+	endpointSourceEnv              = "env"
+	endpointSourceBlob             = "blob"
+	endpointSourceAppConfiguration = "app_configuration"
+	endpointSourceNone             = "none"
+)
+
+// isOffline tells whether the provider must not make any network call, either because `offline` is `true` or because
+// events go to a local sink instead of the endpoint.
+func (data ModuleTelemetryProviderModel) isOffline() bool {
+	return data.Offline.ValueBool() || (data.Sink.ValueString() != "" && data.Sink.ValueString() != sinkHttp)
+}
+
+// resolveEndpointSource tells where the provider's endpoint comes from, following the same order as endpointFunc.
+func resolveEndpointSource(data ModuleTelemetryProviderModel, endpointEnv string, appConfiguration bool) string {
+	switch {
+	case data.isOffline():
+		return endpointSourceNone
+	case !data.Endpoint.IsNull():
+		return endpointSourceProvider
+	case endpointEnv != "":
+		return endpointSourceEnv
+	case appConfiguration:
+		return endpointSourceAppConfiguration
+	case data.DisableDiscovery.ValueBool():
+		return endpointSourceNone
+	default:
+		return endpointSourceBlob
+	}
 }
 
 func (p *ModuleTelemetryProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -59,11 +253,34 @@ func (p *ModuleTelemetryProvider) Schema(ctx context.Context, req provider.Schem
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
 			"endpoint": schema.StringAttribute{
-				MarkdownDescription: "Telemetry endpoint to send data to.",
+				MarkdownDescription: "Telemetry endpoint to send data to, an absolute `http` or `https` URL without credentials, `http` is only allowed for localhost unless `allow_insecure_endpoint` is `true`. An endpoint set by `MODTM_ENDPOINT` environment variable or read from `app_configuration` must be valid too, otherwise it's discarded and no telemetry is sent to the provider's endpoint.",
+				Optional:            true,
+				Validators: []validator.String{
+					MustBeValidEndpoint{},
+				},
+			},
+			"endpoints": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Additional telemetry endpoints that every event is sent to, along with the provider's endpoint resolved from `endpoint`, `MODTM_ENDPOINT` environment variable or the default endpoint discovery, e.g. to report to both Microsoft's collector and an internal collector. The event is sent to all endpoints in parallel, each send is limited by its own `request_timeout` and logged on its own. Failing to reach one endpoint doesn't affect the others.",
+				Validators: []validator.List{
+					listvalidators.ValueStringsAre(MustBeValidEndpoint{}),
+				},
+			},
+			"fallback_endpoints": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Telemetry endpoints that an event is retried against in order when the provider's endpoint responds an error or times out, e.g. geo-redundant internal collectors. The next endpoint is only tried when the previous one fails, each attempt is limited by its own `request_timeout`. The first endpoint is used when the provider has no endpoint, e.g. when the default endpoint discovery fails. It doesn't apply to the `endpoint` of resources and the additional `endpoints`.",
+				Validators: []validator.List{
+					listvalidators.ValueStringsAre(MustBeValidEndpoint{}),
+				},
+			},
+			"allow_insecure_endpoint": schema.BoolAttribute{
+				MarkdownDescription: "Allow `http` endpoints, so telemetry could be sent unencrypted, e.g. to a collector in a private network. Otherwise a non-HTTPS endpoint is rejected, unless its host is `localhost` or a loopback address. Applies to the provider's `endpoint`, `endpoints` and `fallback_endpoints`, `MODTM_ENDPOINT` environment variable, the endpoint read from `app_configuration`, the `endpoint` of `routes`, the `otlp` endpoint whether it's set in the provider block or by `OTEL_EXPORTER_OTLP_*` environment variables, the endpoints of the events replayed from `spool_dir`, and the `endpoint` and `endpoints` of resources, data sources and ephemeral resources. Defaults to `false`.",
 				Optional:            true,
 			},
 			"enabled": schema.BoolAttribute{
-				MarkdownDescription: "Sending telemetry or not, set this argument to `false` would turn telemetry off. Defaults to `true`.",
+				MarkdownDescription: "Sending telemetry or not, set this argument to `false` would turn telemetry off. Defaults to `true`. Telemetry is always off when one of the standard opt-out environment variables `MODTM_DISABLE`, `DO_NOT_TRACK` or `AZURE_TELEMETRY_OPT_OUT` is set to `1` or `true`.",
 				Optional:            true,
 			},
 			"module_source_regex": schema.ListAttribute{
@@ -75,6 +292,514 @@ func (p *ModuleTelemetryProvider) Schema(ctx context.Context, req provider.Schem
 					listvalidators.ValueStringsAre(&MustBeValidRegex{}),
 				},
 			},
+			"module_source_deny_regex": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "List of regex as deny list for module source, e.g. `^git::ssh://internal`. Module source that matches one of the regex won't be collected, even when it matches `module_source_regex`.",
+				Validators: []validator.List{
+					listvalidators.ValueStringsAre(&MustBeValidRegex{}),
+				},
+			},
+			"tags": schema.MapAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: fmt.Sprintf("Common tags merged into the tags of every telemetry event, e.g. the environment name, so they don't need to be threaded into every module instance. The tags of `modtm_telemetry` and `modtm_telemetry_event` resources, including `additional_tags`, win over the tags with the same keys, and so do the tags added by the provider. The %s tags are reserved and cannot be used.", markdownCodeList(reservedTags)),
+				Optional:            true,
+				Validators: []validator.Map{
+					mapValidator{},
+				},
+			},
+			"modules_json_path": schema.StringAttribute{
+				MarkdownDescription: "Explicit path of the `modules.json` file to read module source and version from, for layouts where it cannot be inferred from `TF_DATA_DIR`, e.g. monorepos or generated workspaces. If the path is a directory, it's treated as an alternate Terraform data dir and `modules/modules.json` under it will be read. Could also be set by the `MODTM_MODULES_JSON` environment variable. Defaults to `$TF_DATA_DIR/modules/modules.json`. Provider functions are not affected by this argument since they are called without provider configuration, but they honor `MODTM_MODULES_JSON`.",
+				Optional:            true,
+			},
+			"strict_module_resolution": schema.BoolAttribute{
+				MarkdownDescription: "Return a warning diagnostic describing the failure when the module source and version cannot be resolved from `modules.json`, e.g. the file is missing or malformed, or has no entry for the module path, instead of silently using null values. Applies to `modtm_telemetry`, `modtm_module_source` and `modtm_module_telemetry`. The path tried and the error are always written to the trace logs. Defaults to `false`.",
+				Optional:            true,
+			},
+			"destroy_planned_event": schema.BoolAttribute{
+				MarkdownDescription: fmt.Sprintf("Send a `%s` event when a `modtm_telemetry` resource is planned for destruction, e.g. the module is removed from the configuration or `terraform destroy` is run, so module owners could tell the removal of a module apart from in-place update churn. The event is sent during the plan, with the tags in the state, and before the `delete` event that is sent when the destruction is applied. Defaults to `false`.", destroyPlannedEvent),
+				Optional:            true,
+			},
+			"skip_on_terraform_test": schema.BoolAttribute{
+				MarkdownDescription: "When the provider is launched by `terraform test`, telemetry events are tagged with `terraform_test = \"true\"` by default so they could be told apart from real deployments. Set this argument to `true` to skip sending telemetry under `terraform test` entirely. Defaults to `false`. Detection relies on the command line of the Terraform process and is only supported on Linux.",
+				Optional:            true,
+			},
+			"max_concurrent_sends": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of telemetry requests that could be sent at the same time by this provider instance, the excess requests are queued until a previous request finishes. Useful when a very parallel apply sends events through a constrained proxy. Defaults to unlimited.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"max_events_per_minute": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of telemetry events that could be sent per minute by this provider instance, in bursts of up to the same number, so configurations with hundreds of `modtm_telemetry` resources don't hammer the collector or trip WAF rules. The excess events wait for their turn, but no longer than the request timeout, otherwise they're dropped. An event mirrored to `routes` counts once. Defaults to unlimited.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"tag_limits": schema.SingleNestedAttribute{
+				MarkdownDescription: "Limits of the tags set in the configuration, so collectors with strict schemas could be protected when the plan is made rather than rejecting the events later. They apply to `tags` and `additional_tags` of the resources and data sources, and to `tags` of the provider. The tags added by the provider are not counted.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"max_tags": schema.Int64Attribute{
+						MarkdownDescription: "Maximum number of tags, `tags` and `additional_tags` count together.",
+						Optional:            true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+					"max_key_length": schema.Int64Attribute{
+						MarkdownDescription: "Maximum length of a tag key, in characters.",
+						Optional:            true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+					"max_value_length": schema.Int64Attribute{
+						MarkdownDescription: "Maximum length of a tag value, in characters.",
+						Optional:            true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+					"key_pattern": schema.StringAttribute{
+						MarkdownDescription: "Regex that every tag key should match, e.g. `^[a-z][a-z0-9_]*$` to only allow snake case keys.",
+						Optional:            true,
+						Validators: []validator.String{
+							&MustBeValidRegex{},
+						},
+					},
+				},
+			},
+			"max_payload_size": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum size of the JSON encoded tags of a telemetry event in bytes, so a mistakenly large tag map cannot cause collector rejections. The longest tag values are truncated and marked with `%s` until the payload fits, the tags added by the provider are never truncated, and a warning is reported. An event that doesn't fit even then is dropped. No less than `%d`. Defaults to `%d`.", truncatedSuffix, minMaxPayloadSize, defaultMaxPayloadSize),
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(minMaxPayloadSize),
+				},
+			},
+			"send_queue_size": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of telemetry events that could wait for a free slot when `max_concurrent_sends` is reached, so resource usage stays predictable during huge applies against a slow or dead endpoint. `send_queue_overflow_policy` decides what happens when the queue is full. Requires `max_concurrent_sends`. Defaults to unlimited.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+					int64validator.AlsoRequires(path.MatchRoot("max_concurrent_sends")),
+				},
+			},
+			"send_queue_overflow_policy": schema.StringAttribute{
+				MarkdownDescription: "What to do with a new telemetry event when the send queue is full: `block` waits for room in the queue, `drop_oldest` drops the event that has been waiting for the longest time, `drop_newest` drops the new event. Requires `send_queue_size`. Defaults to `block`.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(queueOverflowPolicies...),
+					stringvalidator.AlsoRequires(path.MatchRoot("send_queue_size")),
+				},
+			},
+			"disabled_event_stages": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: fmt.Sprintf("Every telemetry event goes through an ordered pipeline of stages which enrich, transform or filter it before it's sent. Stages listed in this argument are skipped. Available stages in the order they run: %s. `read_dedupe` drops a `read` event identical to one already sent in the same Terraform operation, e.g. when a refresh reads a resource more than once. %s enforce the module source filters and the privacy settings, they always run and can't be disabled.", markdownCodeList(optionalEventStageNames), markdownCodeList(requiredEventStages)),
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidators.ValueStringsAre(stringvalidator.OneOf(optionalEventStageNames...)),
+				},
+			},
+			"enrichment_command": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "An external command, as a list of the executable and its arguments, that the provider runs for every telemetry event to inject site-specific metadata (e.g. cost center, CMDB id). The draft payload is written to the command's stdin as a JSON object, and the command must print a JSON object of strings to stdout, which will be added to the payload. Tags that already exist in the payload are never overwritten. The command is killed after 5 seconds, the event is sent without additions when the command fails.",
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidators.SizeAtLeast(1),
+				},
+			},
+			"high_priority_events": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: fmt.Sprintf("Names of the telemetry events that are classified as high priority, the other events are low priority. When the provider is under pressure, e.g. the send queue is full, low priority events are shed first so high-value lifecycle events still get through, and high priority events waiting in the queue are sent first. Defaults to %s.", markdownCodeList(defaultHighPriorityEvents)),
+				Optional:            true,
+			},
+			"timestamp_format": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Every telemetry event carries a `timestamp` tag recording when the event was generated in UTC, and a `sequence` tag which is a number that increases monotonically within one Terraform run, so the collector could reconstruct the exact ordering even when deliveries are retried or arrive out of order. This argument sets the format of the `timestamp` tag, possible values are %s. Defaults to `rfc3339`.", markdownCodeList(timestampFormats)),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(timestampFormats...),
+				},
+			},
+			"timestamp_precision": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Precision of the `timestamp` tag, possible values are %s. Defaults to `ms`.", markdownCodeList(timestampPrecisions)),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(timestampPrecisions...),
+				},
+			},
+			"fips_mode": schema.BoolAttribute{
+				MarkdownDescription: fmt.Sprintf("Restrict the provider to FIPS 140 approved cryptographic algorithms, as required by FedRAMP-scoped pipelines. Hashing and signing features only accept %s, and outgoing requests only negotiate TLS 1.2 or above with FIPS approved cipher suites and curves. The primitives themselves come from a FIPS validated module only when the provider binary is built with `GOEXPERIMENT=boringcrypto`, in which case FIPS mode is always on. Could also be turned on by setting `MODTM_FIPS_MODE` environment variable to `true`. Defaults to `false`.", markdownCodeList(fipsHashAlgorithms)),
+				Optional:            true,
+			},
+			"detect_system_proxy": schema.BoolAttribute{
+				MarkdownDescription: "Send telemetry through the proxy configured at OS level when none of `HTTP_PROXY`, `HTTPS_PROXY` and `NO_PROXY` environment variables is set, so telemetry works out of the box on managed corporate machines where only system proxy settings exist. On Windows, the current user's Internet Options (including WPAD and PAC files) and then the WinHTTP proxy set by `netsh winhttp` are used. On macOS, the manual proxy settings of the System Configuration framework are used, PAC files are not supported. Defaults to `true`.",
+				Optional:            true,
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded CA certificates that are trusted in addition to the system roots, e.g. for an internal collector whose certificate is issued by a private CA.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"client_cert_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded client certificate presented to endpoints that require mutual TLS.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("client_key_pem")),
+				},
+			},
+			"client_key_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded private key of `client_cert_pem`.",
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("client_cert_pem")),
+				},
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				MarkdownDescription: "Skip the verification of endpoints' certificates. It makes the connections vulnerable to interception and is only meant for testing. Defaults to `false`.",
+				Optional:            true,
+			},
+			"proxy_url": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("The proxy that all outgoing requests of the provider go through, including the endpoint discovery, e.g. `http://proxy.contoso.com:3128` or `socks5://127.0.0.1:1080`. Possible schemes are %s. It wins over `HTTP_PROXY`, `HTTPS_PROXY` and `NO_PROXY` environment variables and the OS-level proxy settings. Could also be set by `%s` environment variable.", markdownCodeList(proxyURLSchemes), proxyEnv),
+				Optional:            true,
+				Validators: []validator.String{
+					MustBeValidProxyUrl{},
+				},
+			},
+			"proxy_username": schema.StringAttribute{
+				MarkdownDescription: "The user name to authenticate against the proxy with, for proxies that require per-user credentials. The credentials are sent with the basic scheme in the `Proxy-Authorization` header of HTTP proxies, or used for the username/password authentication of SOCKS5 proxies. They apply to the proxy from `proxy_url`, from `HTTP_PROXY`/`HTTPS_PROXY` environment variables or from the OS-level settings, unless the proxy's address already carries credentials. Proxies that only accept NTLM or Negotiate authentication are not supported.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"proxy_password": schema.StringAttribute{
+				MarkdownDescription: "The password of `proxy_username`.",
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("proxy_username")),
+				},
+			},
+			"offline": schema.BoolAttribute{
+				MarkdownDescription: "Guarantee zero network calls, so the provider could remain declared in modules used inside fully disconnected enclaves: the default endpoint is never discovered and no telemetry is sent to any endpoint, including the `endpoint` of `modtm_telemetry` resources. Events could still be written to a local file with `sink_path`. Defaults to `false`.",
+				Optional:            true,
+			},
+			"disable_default_endpoint_discovery": schema.BoolAttribute{
+				MarkdownDescription: "When neither the `endpoint` argument nor `MODTM_ENDPOINT` environment variable is set, the provider reads the default Microsoft telemetry service endpoint from a hard-coded Azure blob URL. The discovered endpoint must be an HTTPS URL on an Azure or Microsoft domain, otherwise it's discarded and no telemetry is sent to the provider's endpoint. Set this argument to `true` to never contact that URL, so the provider simply does nothing unless an endpoint is configured, e.g. by the `endpoint` argument of `modtm_telemetry` resources. Defaults to `false`.",
+				Optional:            true,
+			},
+			"endpoint_cache_ttl": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("How long the default endpoint discovered from the Azure blob is cached in `modtm/endpoint.json` in the user's cache directory, e.g. `~/.cache` on Linux, so repeated plans don't read the blob every time. When the blob cannot be read, the last discovered endpoint is used even if it has expired. Set `%s` environment variable to `true` to turn the cache off. Defaults to `%s`.", endpointCacheBypassEnv, defaultEndpointCacheTTL),
+				Optional:            true,
+				Validators: []validator.String{
+					MustBeValidDuration{},
+				},
+			},
+			"payload_encoding": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Encoding of the telemetry payload sent to the endpoint, possible values are %s. `msgpack` is a compact alternative for high-throughput private collectors, it's sent with `Content-Type: application/msgpack`. When the endpoint responds `415 Unsupported Media Type`, the provider falls back to `json` for the rest of the run. Defaults to `json`.", markdownCodeList(payloadEncodings)),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(payloadEncodings...),
+				},
+			},
+			"payload_format": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Format of the telemetry payload sent to the endpoint, possible values are %s. `legacy` is a flat object of the event's tags. `v2` is a versioned envelope `{\"schema_version\": 2, \"event\": ..., \"resource_id\": ..., \"timestamp\": ..., \"tags\": {...}}`, giving collectors a stable contract, the other tags are in `tags`. Applies to all transports and encodings, and to the `routes` without a protocol of their own, but not to `event_hub` and `sink_path`. Defaults to `legacy`.", markdownCodeList(payloadFormats)),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(payloadFormats...),
+				},
+			},
+			"circuit_breaker_cooldown": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Once a telemetry event fails to reach an endpoint, e.g. on a connection error or a timeout, the events to that endpoint are skipped for this long, so offline machines don't wait out the request timeout of every event. The skipped events are failed deliveries. After the cool-down the next event is sent to probe the endpoint. Error statuses responded by the endpoint, e.g. `400` or `503`, don't skip the following events. Defaults to `%s`.", defaultCircuitBreakerCooldown),
+				Optional:            true,
+				Validators: []validator.String{
+					MustBeValidDuration{},
+				},
+			},
+			"send_mode": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Whether resources wait for their telemetry events to be sent, possible values are %s. `sync` waits for the endpoint to respond, so delivery failures are recorded in the resource's private state and the failed `delete` events could be spooled. `async` queues the events and returns immediately, which shaves seconds off applies that instantiate many modules; up to %d events are queued and sent in the background, %d at a time or `max_concurrent_sends` if it's lower, `fallback_endpoints` and the spooling of `delete` events apply once the delivery has failed, but the resource's private state only records that the event has been queued. The `routes` with a protocol of their own are still sent synchronously. The queued events are sent until the short deadline Terraform leaves to the exiting provider, the rest are lost. Defaults to `sync`.", markdownCodeList(sendModes), asyncQueueSize, asyncWorkers),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(sendModes...),
+				},
+			},
+			"transport": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("How telemetry events are delivered to the endpoint, possible values are %s. `http` sends one HTTP request per event. `stream` is meant for applies emitting thousands of events: it keeps one HTTP POST request open per endpoint and streams events through its body as newline delimited JSON (`Content-Type: application/x-ndjson`), avoiding per-event HTTP overhead, the request is finished when the provider exits. The endpoint must read the request body incrementally. `batch` queues the events of all `modtm_telemetry` resources in memory and sends them in a single HTTP POST request per endpoint, with a JSON array of the events' tags as body, when the provider exits at the end of the plan or apply; events are lost if the request doesn't finish within the short time Terraform leaves to the exiting provider. The events of `stream` and `batch` are only delivered once the endpoint responds the request with a 2xx status. When the request fails, responds another status or the stream breaks, every event of the request is sent to `fallback_endpoints` on its own and failed `delete` events are spooled, and like `async` the resource's private state only records that the event has been queued. `payload_encoding` doesn't apply to `stream` and `batch`. Defaults to `http`.", markdownCodeList(transports)),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(transports...),
+				},
+			},
+			"spool_dir": schema.StringAttribute{
+				MarkdownDescription: "Directory to persist `delete` telemetry events that failed to be sent due to an outage, so the next provider run using the same directory sends them again, tagged with `replayed = \"true\"`. Since the state of a deleted resource is gone, the events cannot be kept in the resource's state. Events spooled more than 7 days ago are discarded, and so are events whose endpoint is malformed or insecure, as `allow_insecure_endpoint` decides, since the files could be tampered with. Spooled events are sent again in their own HTTP requests whatever the `transport` and `send_mode`, and only removed once the endpoint has accepted them. Could also be set by `MODTM_SPOOL_DIR` environment variable, e.g. to a directory that is cached between pipeline runs. Spooling is off when neither is set, and in offline mode.",
+				Optional:            true,
+			},
+			"include_backend_id": schema.BoolAttribute{
+				MarkdownDescription: "Tag every telemetry event with `backend_id`, a salted hash of the backend configuration cached by `terraform init` (e.g. storage account, container and key for `azurerm` backend) and the current workspace, so the service could count distinct state files using a module without learning where the state lives. Credentials are not part of the hash. The tag is omitted when the working directory hasn't been initialized. Defaults to `false`.",
+				Optional:            true,
+			},
+			"backend_id_salt": schema.StringAttribute{
+				MarkdownDescription: "Salt of the `backend_id` hash, set it to a secret value to prevent the service from guessing well-known backend configurations. Requires `include_backend_id`.",
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("include_backend_id")),
+				},
+			},
+			"routes": schema.ListNestedAttribute{
+				MarkdownDescription: "Additional endpoints that a subset of the telemetry events is mirrored to, e.g. an internal collector that only receives `create` and `delete` events of `registry.terraform.io/MyOrg/.*` modules, while all events are still sent to the provider's endpoint. Only events that go through the provider's event pipeline are mirrored, so `module_source_regex` and `sampling_rules` of the provider apply first. Mirrored events are sent with the same payload, failures are logged and don't affect the delivery to the provider's endpoint. No event is mirrored when `offline` is `true`.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"endpoint": schema.StringAttribute{
+							MarkdownDescription: "The endpoint that matching events are sent to.",
+							Required:            true,
+							Validators: []validator.String{
+								MustBeValidEndpoint{},
+							},
+						},
+						"events": schema.ListAttribute{
+							ElementType:         types.StringType,
+							MarkdownDescription: "Names of the events to mirror, e.g. `create` and `delete`. Defaults to all events.",
+							Optional:            true,
+							Validators: []validator.List{
+								listvalidators.SizeAtLeast(1),
+							},
+						},
+						"module_source_regex": schema.StringAttribute{
+							MarkdownDescription: "Regex that the `module_source` tag should match for the event to be mirrored. Defaults to all module sources.",
+							Optional:            true,
+							Validators: []validator.String{
+								&MustBeValidRegex{},
+							},
+						},
+						"sampling_rate": schema.Float64Attribute{
+							MarkdownDescription: "Fraction of the resources whose matching events are mirrored, between `0` and `1`. The decision is made per resource like `sampling_rules`. Defaults to `1`.",
+							Optional:            true,
+							Validators: []validator.Float64{
+								float64validator.Between(0, 1),
+							},
+						},
+					},
+				},
+			},
+			"app_insights_connection_string": schema.StringAttribute{
+				MarkdownDescription: "Connection string of an Azure Application Insights resource that all telemetry events are sent to, in addition to the provider's endpoint, so teams that centralize telemetry in Application Insights don't need a separate collector. Every event is sent as a custom event named after the event, e.g. `create`, with its tags as custom dimensions. Like `routes`, only events that go through the provider's event pipeline are sent, failures are logged and don't affect the delivery to the provider's endpoint, and no event is sent when `offline` is `true`.",
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"event_hub": schema.SingleNestedAttribute{
+				MarkdownDescription: "An Azure Event Hub that all telemetry events are sent to, in addition to the provider's endpoint, for organizations that fan telemetry into Event Hubs for downstream processing. Every event is sent as a message whose body is a JSON object of the event's tags. The event hub is accessed with the shared access key of `connection_string`, otherwise with an AAD token acquired with the `DefaultAzureCredential` of the Azure SDK like for `use_azure_auth`, whose identity needs the `Azure Event Hubs Data Sender` role. The token is requested through the provider's `proxy`, TLS and FIPS settings, and refreshed before it expires. Like `routes`, only events that go through the provider's event pipeline are sent, failures are logged and don't affect the delivery to the provider's endpoint, and no event is sent when `offline` is `true`.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"connection_string": schema.StringAttribute{
+						MarkdownDescription: "Connection string of the namespace or of the event hub, with a shared access policy that has the `Send` claim.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"namespace": schema.StringAttribute{
+						MarkdownDescription: "The namespace of the event hub, e.g. `contoso` or `contoso.servicebus.windows.net`. Required unless `connection_string` is set.",
+						Optional:            true,
+					},
+					"name": schema.StringAttribute{
+						MarkdownDescription: "The name of the event hub. Required unless `connection_string` contains `EntityPath`.",
+						Optional:            true,
+					},
+				},
+			},
+			"otlp": schema.SingleNestedAttribute{
+				MarkdownDescription: "Export all telemetry events as OpenTelemetry log records to an OTLP endpoint, in addition to the provider's endpoint, so platform teams could route module telemetry through their existing OpenTelemetry collectors. Every event is a log record whose body and `event.name` attribute are the event name, with the event's tags as attributes. Records are exported over OTLP/gRPC, or OTLP/HTTP with protobuf or JSON encoding, see `protocol`. The standard `OTEL_EXPORTER_OTLP_LOGS_ENDPOINT`, `OTEL_EXPORTER_OTLP_ENDPOINT`, `OTEL_EXPORTER_OTLP_LOGS_PROTOCOL`, `OTEL_EXPORTER_OTLP_PROTOCOL` and `OTEL_EXPORTER_OTLP_HEADERS` environment variables are honored, so `otlp = {}` is enough when they're set. The endpoint must be HTTPS unless `allow_insecure_endpoint` is `true`, or its host is `localhost` or a loopback address. Like `routes`, only events that go through the provider's event pipeline are exported, failures are logged and don't affect the delivery to the provider's endpoint, and no event is exported when `offline` is `true`.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"endpoint": schema.StringAttribute{
+						MarkdownDescription: "The full URL that log records are posted to, e.g. `http://localhost:4318/v1/logs`, or the URL of the collector for `grpc`, e.g. `https://collector:4317`, whose connection is in plaintext for an `http` URL. Defaults to `OTEL_EXPORTER_OTLP_LOGS_ENDPOINT` environment variable, or `OTEL_EXPORTER_OTLP_ENDPOINT` environment variable, followed by `/v1/logs` unless the protocol is `grpc`.",
+						Optional:            true,
+						Validators: []validator.String{
+							stringvalidator.LengthAtLeast(1),
+						},
+					},
+					"protocol": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("The OTLP protocol, possible values are %s. `grpc` connections use the TLS settings of the provider and the `HTTPS_PROXY` environment variable rather than `proxy`. Defaults to `OTEL_EXPORTER_OTLP_LOGS_PROTOCOL` or `OTEL_EXPORTER_OTLP_PROTOCOL` environment variable, then `%s`.", markdownCodeList(otlpProtocols), otlpProtocolHttpJson),
+						Optional:            true,
+						Validators: []validator.String{
+							stringvalidator.OneOf(otlpProtocols...),
+						},
+					},
+					"headers": schema.MapAttribute{
+						ElementType:         types.StringType,
+						MarkdownDescription: "Headers sent with every request, e.g. an API key of the collector. They're merged over the headers of `OTEL_EXPORTER_OTLP_HEADERS` environment variable.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+				},
+			},
+			"sampling_rules": schema.ListNestedAttribute{
+				MarkdownDescription: "Sampling rules keyed by module source, so high-interest modules keep full fidelity while bulk noise is sampled down, e.g. `1` for `Azure/avm-.*` followed by `0.01` for `.*`. The first rule whose regex matches the `module_source` tag decides the rate, events matching no rule are always sent. The decision is made per resource, so all events of the same `modtm_telemetry` resource are either sent or dropped together. Sampled events are tagged with `sample_rate` when the rate is below `1`.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"module_source_regex": schema.StringAttribute{
+							MarkdownDescription: "Regex that the module source should match.",
+							Required:            true,
+							Validators: []validator.String{
+								&MustBeValidRegex{},
+							},
+						},
+						"rate": schema.Float64Attribute{
+							MarkdownDescription: "Fraction of the resources whose events are sent, between `0` and `1`.",
+							Required:            true,
+							Validators: []validator.Float64{
+								float64validator.Between(0, 1),
+							},
+						},
+					},
+				},
+			},
+			"sink_path": schema.StringAttribute{
+				MarkdownDescription: "Path of a local file that every telemetry event is appended to as a line of JSON, in addition to being sent to the endpoint unless `offline` is `true`. The file is created if it doesn't exist.",
+				Optional:            true,
+			},
+			"sink": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Where telemetry events go, possible values are %s. `http` sends events to the endpoint. `file` appends events to `sink_path` instead, so air-gapped environments could collect telemetry locally and forward it later. `stdout` and `stderr` print every event as a line of JSON, so module consumers could verify exactly what would be sent without standing up an endpoint; Terraform captures the provider's output in its logs, e.g. with `TF_LOG=DEBUG`. Every value but `http` implies `offline`, so the provider never makes any network call. Could also be set by `%s` environment variable. Defaults to `http`.", markdownCodeList(sinks), sinkEnv),
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(sinks...),
+				},
+			},
+			"normalize_git_timestamp": schema.BoolAttribute{
+				MarkdownDescription: "Rewrite the `avm_git_last_modified_at` tag to UTC RFC3339 before sending, as the `normalize_timestamp` function does, since different tooling reports it in different formats and time zones. Values that cannot be parsed are sent as they are. Defaults to `false`.",
+				Optional:            true,
+			},
+			"throttle_window": schema.StringAttribute{
+				MarkdownDescription: "Suppress repeated events with the same `module_source` tag, `module_version` tag and event name on this machine within the window, e.g. `24h`, which dramatically reduces the volume from tight plan/apply loops during development. The time of the last event is cached in `throttle_cache_path`. Throttling is off when it's not set.",
+				Optional:            true,
+				Validators: []validator.String{
+					MustBeValidDuration{},
+				},
+			},
+			"throttle_cache_path": schema.StringAttribute{
+				MarkdownDescription: "Path of the local file that caches when events were last sent for `throttle_window`. Defaults to `modtm/throttle.json` in the user's cache directory, e.g. `~/.cache` on Linux.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("throttle_window")),
+				},
+			},
+			"app_configuration": schema.SingleNestedAttribute{
+				MarkdownDescription: "Read the provider's endpoint and sampling rules from an Azure App Configuration store, an enterprise-friendly alternative to the public blob discovery. " +
+					"The endpoint is read from the `<key_prefix>endpoint` key, it's used when neither the `endpoint` argument nor `MODTM_ENDPOINT` environment variable is set, and the public blob is never read. " +
+					"The sampling rules are read from the `<key_prefix>sampling_rules` key as a JSON array of objects with `module_source_regex` and `rate`, they're used when the `sampling_rules` argument is not set. " +
+					"The store is read with the credential of the connection string, which could also be set by `MODTM_APP_CONFIGURATION_CONNECTION_STRING` environment variable, otherwise with an AAD token acquired with the `DefaultAzureCredential` of the Azure SDK like for `use_azure_auth`, through the provider's `proxy`, TLS and FIPS settings. The store is never read in offline mode.",
+				Optional: true,
+				Attributes: map[string]schema.Attribute{
+					"endpoint": schema.StringAttribute{
+						MarkdownDescription: "Endpoint of the store, e.g. `https://contoso.azconfig.io`. Required unless `connection_string` is set.",
+						Optional:            true,
+					},
+					"connection_string": schema.StringAttribute{
+						MarkdownDescription: "Read-only connection string of the store.",
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"key_prefix": schema.StringAttribute{
+						MarkdownDescription: "Prefix of the keys to read. Defaults to `modtm:`.",
+						Optional:            true,
+					},
+					"label": schema.StringAttribute{
+						MarkdownDescription: "Label of the keys to read. Defaults to no label.",
+						Optional:            true,
+					},
+				},
+			},
+			"collect_runtime_metadata": schema.BoolAttribute{
+				MarkdownDescription: "Tag every telemetry event with the runtime metadata: `terraform_version`, `provider_version`, `os` and `arch`. Tags set by the resources are kept. Set this argument to `false` to leave them out. Defaults to `true`.",
+				Optional:            true,
+			},
+			"collect_azure_context": schema.BoolAttribute{
+				MarkdownDescription: "Tag every telemetry event with `azure_subscription_hash` and `azure_tenant_hash`, salted SHA-256 hashes of the subscription and tenant ids read from `ARM_SUBSCRIPTION_ID` and `ARM_TENANT_ID` environment variables, so module owners could count distinct deployments without storing the raw ids. When `ARM_SUBSCRIPTION_ID` is not set, the subscription id is read from the Azure Instance Metadata Service if the provider runs on an Azure VM or agent, except in offline mode. Defaults to `false`.",
+				Optional:            true,
+			},
+			"hash_tags": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Keys of the tags whose values are replaced with their hex encoded SHA-256 hashes before being sent, e.g. `avm_git_org` and `avm_git_repo`, so the values could be counted for uniqueness while identifiable strings are kept out of the telemetry backend. Hashing runs after the tags are enriched, so it applies to the tags added by `enrichment_command` too.",
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidators.ValueStringsAre(stringvalidator.LengthAtLeast(1)),
+				},
+			},
+			"redact_patterns": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "List of regex matching the substrings of tag values that are replaced with `[REDACTED]` before being sent, e.g. `[\\w.+-]+@[\\w-]+\\.[\\w.]+` for emails or `AccountKey=[^;]+` for connection strings, protecting against accidental inclusion of secrets in tags. Redaction runs after the tags are enriched and before `hash_tags`.",
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidators.ValueStringsAre(&MustBeValidRegex{}),
+				},
+			},
+			"hash_tags_salt": schema.StringAttribute{
+				MarkdownDescription: "Salt of the `hash_tags` hashes, the values are hashed with HMAC-SHA-256 keyed by the salt when it's set. Set it to a secret value to prevent the service from guessing well-known values. Requires `hash_tags`.",
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("hash_tags")),
+				},
+			},
+			"include_azure_environment": schema.BoolAttribute{
+				MarkdownDescription: "When the provider runs on an Azure VM or agent, tag events with coarse, non-identifying facts read from Azure Instance Metadata Service: `azure_region` is the region and `azure_vm_family` is the family of the VM size, e.g. `D` for `Standard_D4s_v3`. Nothing else of the VM, like its name, id or subscription, is sent. IMDS is never queried in offline mode. Defaults to `false`.",
+				Optional:            true,
+			},
+			"request_timeout": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("How long to wait for the endpoint to respond to a telemetry event, e.g. `10s` for slow networks or proxies, or `1s` to keep applies snappy. Could be overridden by `request_timeout` of `modtm_telemetry` resources. No longer than `%s`. Defaults to `%s`.", maxRequestTimeout, sendTimeout),
+				Optional:            true,
+				Validators: []validator.String{
+					MustBeValidDuration{Max: maxRequestTimeout},
+				},
+			},
+			"endpoint_headers": schema.MapAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Headers added to every request that sends telemetry events, e.g. an API key required by an internal collector. They're sent to every endpoint that receives events through HTTP, including the `endpoint` of `modtm_telemetry` resources and the endpoints of `routes`, but not to the default endpoint discovery.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"bearer_token": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("A token sent as `Authorization: Bearer <token>` header with every request that sends telemetry events, it wins over an `Authorization` header in `endpoint_headers` and is sent to the same endpoints. Could also be set by `%s` environment variable.", endpointTokenEnv),
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"use_azure_auth": schema.BoolAttribute{
+				MarkdownDescription: "Authenticate the requests that send telemetry events with an AAD (Entra ID) access token for `azure_auth_resource`, sent as `Authorization: Bearer <token>` header, for collectors protected by Azure API Management or App Service authentication. The token is acquired with the `DefaultAzureCredential` of the Azure SDK, which tries in order the service principal set by `AZURE_TENANT_ID`, `AZURE_CLIENT_ID` and `AZURE_CLIENT_SECRET` or `AZURE_CLIENT_CERTIFICATE_PATH` environment variables, the workload identity set by `AZURE_FEDERATED_TOKEN_FILE`, the managed identity of the Azure VM or agent, then the Azure CLI and Azure Developer CLI sessions. Token requests go through the provider's `proxy`, TLS and FIPS settings, and the token is refreshed before it expires. The token is sent to the same endpoints as `bearer_token`, which cannot be set at the same time. Defaults to `false`.",
+				Optional:            true,
+				Validators: []validator.Bool{
+					boolvalidator.AlsoRequires(path.MatchRoot("azure_auth_resource")),
+					boolvalidator.ConflictsWith(path.MatchRoot("bearer_token")),
+				},
+			},
+			"azure_auth_resource": schema.StringAttribute{
+				MarkdownDescription: "The resource that the AAD token of `use_azure_auth` is issued for, usually the Application ID URI of the collector's app registration, e.g. `api://contoso-telemetry-collector`.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+					stringvalidator.AlsoRequires(path.MatchRoot("use_azure_auth")),
+				},
+			},
+			"prewarm_connection": schema.BoolAttribute{
+				MarkdownDescription: "Resolve the provider's endpoint and establish the connection to it in the background when the provider is configured, so the first event doesn't pay the DNS lookup and TLS handshake within its send deadline. The connection is kept alive for later events. A `HEAD` request is sent to the endpoint for that. Defaults to `false`.",
+				Optional:            true,
+			},
+			"function_telemetry": schema.BoolAttribute{
+				MarkdownDescription: "Send a lightweight `function` event the first time the `module_source`, `module_version` or `module_metadata` function resolves a module source that matches `module_source_regex`, tagged with `function`, `module_source` and `module_version`. Terraform usually calls provider functions on a provider instance that is not configured, in which case the provider block is not seen and the `MODTM_FUNCTION_TELEMETRY` environment variable turns the events on instead, its value being the regex that module sources should match. Like the events of resources, the events go through `module_source_regex`, `module_source_deny_regex`, `redact_patterns` and `hash_tags`, the `sink`, `max_events_per_minute` and `max_concurrent_sends`. On an unconfigured instance, no event is sent when `MODTM_SINK` is set to anything but `http`, or when `MODTM_ENDPOINT` is malformed or an `http` endpoint on another host than `localhost` or a loopback address. Defaults to `false`.",
+				Optional:            true,
+			},
 		},
 	}
 }
@@ -91,9 +816,151 @@ func (p *ModuleTelemetryProvider) Configure(ctx context.Context, req provider.Co
 	if !data.Enabled.IsNull() {
 		enabled = data.Enabled.ValueBool()
 	}
+	if env := telemetryOptOut(); env != "" {
+		traceLog(ctx, fmt.Sprintf("Telemetry is turned off by %s environment variable", env))
+		enabled = false
+	}
+	if sink := os.Getenv(sinkEnv); data.Sink.IsNull() && sink != "" {
+		if slices.Contains(sinks, sink) {
+			data.Sink = types.StringValue(sink)
+		} else {
+			resp.Diagnostics.AddWarning(fmt.Sprintf("Invalid %s", sinkEnv), fmt.Sprintf("%q is not one of %s, the sink is ignored.", sink, markdownCodeList(sinks)))
+		}
+	}
 	var once sync.Once
 	endpoint := ""
 	endpointEnv := os.Getenv("MODTM_ENDPOINT")
+	allowInsecureEndpoint := data.AllowInsecureEndpoint.ValueBool()
+	resp.Diagnostics.Append(insecureEndpointDiagnostics(path.Root("endpoint"), data.Endpoint.ValueString(), "the provider block", allowInsecureEndpoint)...)
+	resp.Diagnostics.Append(insecureEndpointDiagnostics(path.Empty(), endpointEnv, "MODTM_ENDPOINT environment variable", allowInsecureEndpoint)...)
+	var endpoints []string
+	resp.Diagnostics.Append(data.Endpoints.ElementsAs(ctx, &endpoints, false)...)
+	for i, e := range endpoints {
+		resp.Diagnostics.Append(insecureEndpointDiagnostics(path.Root("endpoints").AtListIndex(i), e, "the provider block", allowInsecureEndpoint)...)
+	}
+	var fallbackEndpoints []string
+	resp.Diagnostics.Append(data.FallbackEndpoints.ElementsAs(ctx, &fallbackEndpoints, false)...)
+	for i, e := range fallbackEndpoints {
+		resp.Diagnostics.Append(insecureEndpointDiagnostics(path.Root("fallback_endpoints").AtListIndex(i), e, "the provider block", allowInsecureEndpoint)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	crypto := cryptoPolicy{fipsMode: boringCrypto || data.FipsMode.ValueBool() || (data.FipsMode.IsNull() && strings.EqualFold(os.Getenv("MODTM_FIPS_MODE"), "true"))}
+	if crypto.fipsMode && !boringCrypto {
+		resp.Diagnostics.AddWarning("FIPS mode without a FIPS validated module",
+			"FIPS mode restricts the algorithms the provider uses, but this provider binary is not built with `GOEXPERIMENT=boringcrypto` so the cryptographic primitives don't come from a FIPS validated module.")
+	}
+	detectSystemProxy := true
+	if !data.DetectSystemProxy.IsNull() {
+		detectSystemProxy = data.DetectSystemProxy.ValueBool()
+	}
+	proxy := proxySettings{detectSystemProxy: detectSystemProxy}
+	if !data.ProxyUsername.IsNull() {
+		proxy.credentials = url.UserPassword(data.ProxyUsername.ValueString(), data.ProxyPassword.ValueString())
+	}
+	proxyUrl := data.ProxyUrl.ValueString()
+	if data.ProxyUrl.IsNull() {
+		proxyUrl = os.Getenv(proxyEnv)
+	}
+	if u, err := parseExplicitProxyURL(proxyUrl); err != nil {
+		resp.Diagnostics.AddWarning(fmt.Sprintf("Invalid %s", proxyEnv), fmt.Sprintf("%s, the proxy is ignored.", err.Error()))
+	} else {
+		proxy.url = u
+	}
+	customTLS, err := newTLSSettings(data.CaCertPem.ValueString(), data.ClientCertPem.ValueString(), data.ClientKeyPem.ValueString(), data.InsecureSkipVerify.ValueBool())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid TLS configuration", err.Error())
+		return
+	}
+	if customTLS.insecureSkipVerify {
+		resp.Diagnostics.AddWarning("Certificate verification is disabled",
+			"`insecure_skip_verify` is `true`, the certificates of endpoints are not verified and telemetry could be intercepted.")
+	}
+	httpClient := newHTTPClient(crypto, proxy, customTLS)
+	bearerToken := data.BearerToken.ValueString()
+	if data.BearerToken.IsNull() {
+		bearerToken = os.Getenv(endpointTokenEnv)
+	}
+	headers := newEndpointHeaders(data.EndpointHeaders, bearerToken)
+	var token *aadTokenSource
+	if data.UseAzureAuth.ValueBool() {
+		if token, err = newAadTokenSource(data.AzureAuthResource.ValueString(), httpClient); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("use_azure_auth"), "Invalid Azure credential", err.Error())
+			return
+		}
+	}
+	client := p.client
+	// directClient sends events immediately whatever the transport, e.g. to replay the spool.
+	directClient := client
+	if client == nil {
+		switch data.Transport.ValueString() {
+		case transportStream:
+			streamClient := newStreamTelemetryClient(httpClient)
+			streamClient.headers = headers
+			streamClient.token = token
+			streamClient.format = data.PayloadFormat.ValueString()
+			registerShutdownHook(streamClient.close)
+			client, directClient = streamClient, streamClient.httpTelemetryClient
+		case transportBatch:
+			batchClient := newBatchTelemetryClient(httpClient)
+			batchClient.headers = headers
+			batchClient.token = token
+			batchClient.format = data.PayloadFormat.ValueString()
+			registerShutdownHook(batchClient.flush)
+			client, directClient = batchClient, batchClient.httpTelemetryClient
+		default:
+			h := newHttpTelemetryClient(httpClient, data.PayloadEncoding.ValueString())
+			h.headers = headers
+			h.token = token
+			h.format = data.PayloadFormat.ValueString()
+			client, directClient = h, h
+		}
+	}
+	cooldown := defaultCircuitBreakerCooldown
+	if d, err := time.ParseDuration(data.CircuitBreakerCooldown.ValueString()); err == nil {
+		cooldown = d
+	}
+	if _, ok := client.(queuingClient); !ok {
+		// A queuing transport has no send failure for a circuit breaker to trip on, and resources must see it queues events.
+		client = newCircuitBreakerClient(client, cooldown)
+		directClient = client
+	} else {
+		directClient = newCircuitBreakerClient(directClient, cooldown)
+	}
+	if data.SendMode.ValueString() == sendModeAsync {
+		asyncClient := newAsyncTelemetryClient(client, asyncQueueSize, data.MaxConcurrentSends.ValueInt64())
+		registerShutdownHook(asyncClient.flush)
+		client = asyncClient
+	}
+
+	loadAppConfiguration := func() appConfigurationSettings {
+		return appConfigurationSettings{}
+	}
+	connectionStringEnv := os.Getenv("MODTM_APP_CONFIGURATION_CONNECTION_STRING")
+	appConfigurationEnabled := !data.isOffline() && (data.AppConfiguration != nil || connectionStringEnv != "")
+	if appConfigurationEnabled {
+		m := AppConfigurationModel{}
+		if data.AppConfiguration != nil {
+			m = *data.AppConfiguration
+		}
+		store, err := newAppConfigurationStore(m, connectionStringEnv, httpClient, crypto)
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid App Configuration", err.Error())
+			return
+		}
+		// Configure's context ends when the configuration is done, the settings are read on first use.
+		loadAppConfiguration = sync.OnceValue(func() appConfigurationSettings {
+			return store.settings(context.Background())
+		})
+	}
+
+	endpointCacheTTL := defaultEndpointCacheTTL
+	if d, err := time.ParseDuration(data.EndpointCacheTTL.ValueString()); err == nil {
+		endpointCacheTTL = d
+	}
+	endpointCache := newEndpointCache(defaultEndpointCachePath(), endpointCacheTTL)
 
 	c := providerConfig{
 		endpointFunc: func() string {
@@ -102,12 +969,31 @@ func (p *ModuleTelemetryProvider) Configure(ctx context.Context, req provider.Co
 					endpoint = readEndpointFromProviderBlock(data)
 					traceLog(ctx, fmt.Sprintf("Load provider's endpoint from provider block: %s", endpoint))
 				} else if endpointEnv != "" {
-					endpoint = endpointEnv
+					endpoint = configuredEndpoint(ctx, endpointEnv, "MODTM_ENDPOINT environment variable")
 					traceLog(ctx, fmt.Sprintf("Load provider's endpoint from environment variable: %s", endpoint))
+				} else if appConfigurationEnabled {
+					endpoint = loadAppConfiguration().endpoint
+					if endpoint != "" {
+						endpoint = configuredEndpoint(ctx, endpoint, "app configuration")
+					}
+					if isInsecureEndpoint(endpoint) && !allowInsecureEndpoint {
+						errorLog(ctx, "Discarded insecure endpoint from app configuration, no telemetry will be sent to provider's endpoint")
+						endpoint = ""
+					}
+					traceLog(ctx, fmt.Sprintf("Load provider's endpoint from app configuration: %s", endpoint))
+				} else if data.DisableDiscovery.ValueBool() {
+					endpoint = ""
+					traceLog(ctx, "Default endpoint discovery is disabled, no telemetry will be sent to provider's endpoint")
 				} else {
-					e, err := readEndpointFromBlob()
+					// The endpoint is resolved on first use, after Configure's context has ended.
+					e, err := endpointCache.discover(context.WithoutCancel(ctx), client)
 					if err != nil {
 						endpoint = ""
+						var invalidErr *invalidEndpointError
+						if errors.As(err, &invalidErr) {
+							errorLog(ctx, "Discarded malformed endpoint from default blob storage, no telemetry will be sent to provider's endpoint", invalidErr.logFields())
+							return
+						}
 						traceLog(ctx, "Failed to load provider's endpoint from default blob storage")
 						return
 					}
@@ -118,16 +1004,213 @@ func (p *ModuleTelemetryProvider) Configure(ctx context.Context, req provider.Co
 			})
 			return endpoint
 		},
-		enabled: enabled,
+		enabled:                enabled,
+		endpoints:              endpoints,
+		fallbackEndpoints:      fallbackEndpoints,
+		allowInsecureEndpoint:  allowInsecureEndpoint,
+		modulesJsonPath:        data.ModulesJsonPath.ValueString(),
+		modulesJson:            p.modulesJson,
+		strictModuleResolution: data.StrictModuleResolution.ValueBool(),
+		skipOnTerraformTest:    data.SkipOnTerraformTest.ValueBool(),
+		terraformVersion:       req.TerraformVersion,
+		terraformCommand:       detectTerraformCommand(),
+		executionEnvironment:   detectExecutionEnvironment(),
+		sendLimiter:            newSendLimiter(data.MaxConcurrentSends.ValueInt64(), data.SendQueueSize.ValueInt64(), data.SendQueueOverflow.ValueString()),
+		rateLimiter:            newRateLimiter(data.MaxEventsPerMinute.ValueInt64()),
+		sequence:               &eventSequence{},
+		timestampFormat:        data.TimestampFormat.ValueString(),
+		timestampPrecision:     data.TimestampPrecision.ValueString(),
+		crypto:                 crypto,
+		client:                 client,
+	}
+	c.offline = data.isOffline()
+	if c.offline {
+		traceLog(ctx, "Provider is offline, no telemetry will be sent")
+		c.endpointFunc = func() string {
+			return ""
+		}
+	}
+	c.sink = newEventSink(data.Sink.ValueString(), data.SinkPath.ValueString())
+	if data.Sink.ValueString() == sinkFile && c.sink == nil {
+		resp.Diagnostics.AddAttributeError(path.Root("sink_path"), "Missing sink_path", "`sink_path` must be set when `sink` is `file`.")
+		return
+	}
+	c.endpointSource = resolveEndpointSource(data, endpointEnv, appConfigurationEnabled)
+	spoolDir := data.SpoolDir.ValueString()
+	if data.SpoolDir.IsNull() {
+		spoolDir = os.Getenv("MODTM_SPOOL_DIR")
+	}
+	if enabled && !c.offline && data.PrewarmConnection.ValueBool() {
+		// Configure's context ends when the configuration is done, the connection is prewarmed in the background.
+		go prewarmConnection(context.Background(), client, c.endpointFunc)
+	}
+	if enabled && !c.offline {
+		c.spool = newEventSpool(spoolDir)
+		// Configure's context ends when the configuration is done, spooled events are sent in the background.
+		go c.spool.replay(context.Background(), directClient, allowInsecureEndpoint)
+	}
+	c.tagLimits = newTagLimits(data.TagLimits)
+	resp.Diagnostics.Append(c.tagLimits.validate(path.Root("tags"), data.Tags)...)
+	c.tags = mergeTags(data.Tags)
+	c.requestTimeout = sendTimeout
+	if d, err := time.ParseDuration(data.RequestTimeout.ValueString()); err == nil {
+		c.requestTimeout = d
+	}
+	c.maxConcurrentSends = data.MaxConcurrentSends.ValueInt64()
+	c.maxEventsPerMinute = data.MaxEventsPerMinute.ValueInt64()
+	c.payloadEncoding = data.PayloadEncoding.ValueString()
+	if c.payloadEncoding == "" {
+		c.payloadEncoding = payloadEncodingJSON
+	}
+	c.payloadFormat = data.PayloadFormat.ValueString()
+	if c.payloadFormat == "" {
+		c.payloadFormat = payloadFormatLegacy
+	}
+	c.sendMode = data.SendMode.ValueString()
+	if c.sendMode == "" {
+		c.sendMode = sendModeSync
+	}
+	c.transport = data.Transport.ValueString()
+	if c.transport == "" {
+		c.transport = transportHttp
+	}
+	c.terraformTest = c.terraformCommand == "test"
+	if c.terraformTest {
+		traceLog(ctx, "Provider is launched by `terraform test`")
 	}
 
 	for _, value := range data.ModuleSourceRegex.Elements() {
 		c.moduleSourceRegex = append(c.moduleSourceRegex, regexp.MustCompile(value.(basetypes.StringValue).ValueString()))
 	}
+	for _, value := range data.ModuleSourceDenyRegex.Elements() {
+		c.moduleSourceDenyRegex = append(c.moduleSourceDenyRegex, regexp.MustCompile(value.(basetypes.StringValue).ValueString()))
+	}
+
+	var disabledStages []string
+	resp.Diagnostics.Append(data.DisabledEventStages.ElementsAs(ctx, &disabledStages, false)...)
+	resp.Diagnostics.Append(disabledEventStagesDiagnostics(disabledStages)...)
+	resp.Diagnostics.Append(data.EnrichmentCommand.ElementsAs(ctx, &c.enrichmentCommand, false)...)
+	c.destroyPlannedEvent = data.DestroyPlannedEvent.ValueBool()
+	c.highPriorityEvents = defaultHighPriorityEvents
+	if !data.HighPriorityEvents.IsNull() {
+		resp.Diagnostics.Append(data.HighPriorityEvents.ElementsAs(ctx, &c.highPriorityEvents, false)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var samplingRules []SamplingRuleModel
+	resp.Diagnostics.Append(data.SamplingRules.ElementsAs(ctx, &samplingRules, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var rules []samplingRule
+	for _, rule := range samplingRules {
+		rules = append(rules, samplingRule{
+			moduleSourceRegex: regexp.MustCompile(rule.ModuleSourceRegex.ValueString()),
+			rate:              rule.Rate.ValueFloat64(),
+		})
+	}
+	c.samplingRules = func() []samplingRule {
+		return rules
+	}
+	if data.SamplingRules.IsNull() && appConfigurationEnabled {
+		c.samplingRules = func() []samplingRule {
+			return loadAppConfiguration().samplingRules
+		}
+	}
+	var routes []RouteModel
+	resp.Diagnostics.Append(data.Routes.ElementsAs(ctx, &routes, false)...)
+	for i, route := range routes {
+		resp.Diagnostics.Append(insecureEndpointDiagnostics(path.Root("routes").AtListIndex(i).AtName("endpoint"), route.Endpoint.ValueString(), "routes", allowInsecureEndpoint)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	eventRoutes, diags := newEventRoutes(ctx, routes)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	c.routes = eventRoutes
+	if !data.AppInsightsConnection.IsNull() {
+		route, err := newAppInsightsRoute(data.AppInsightsConnection.ValueString(), httpClient)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("app_insights_connection_string"), "Invalid Application Insights connection string", err.Error())
+			return
+		}
+		c.routes = append(c.routes, route)
+	}
+	if data.Otlp != nil {
+		route, err := newOtlpRoute(*data.Otlp, httpClient, customTLS.apply(crypto.tlsConfig()))
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("otlp"), "Invalid OTLP exporter", err.Error())
+			return
+		}
+		registerShutdownHook(route.client.(*otlpTelemetryClient).close)
+		resp.Diagnostics.Append(otlpEndpointDiagnostics(*data.Otlp, route.endpoint, allowInsecureEndpoint)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		c.routes = append(c.routes, route)
+	}
+	if data.EventHub != nil {
+		route, err := newEventHubRoute(*data.EventHub, httpClient, crypto)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("event_hub"), "Invalid Event Hub", err.Error())
+			return
+		}
+		c.routes = append(c.routes, route)
+	}
+	if data.IncludeBackendId.ValueBool() {
+		dataDir := envOrDefault("TF_DATA_DIR", ".terraform")
+		id, err := backendId(dataDir, terraformWorkspace(dataDir), data.BackendIdSalt.ValueString(), c.crypto)
+		if err != nil {
+			traceLog(ctx, fmt.Sprintf("Failed to compute backend id: %s", err.Error()))
+		}
+		c.backendId = id
+	}
+	c.normalizeGitTimestamp = data.NormalizeTimestamp.ValueBool()
+	c.includeAzureEnvironment = data.IncludeAzureEnvironment.ValueBool() && !c.offline
+	c.collectAzureContext = data.CollectAzureContext.ValueBool()
+	resp.Diagnostics.Append(data.HashTags.ElementsAs(ctx, &c.hashTags, false)...)
+	c.hashTagsSalt = data.HashTagsSalt.ValueString()
+	c.maxPayloadSize = defaultMaxPayloadSize
+	if !data.MaxPayloadSize.IsNull() {
+		c.maxPayloadSize = int(data.MaxPayloadSize.ValueInt64())
+	}
+	for _, value := range data.RedactPatterns.Elements() {
+		c.redactPatterns = append(c.redactPatterns, regexp.MustCompile(value.(basetypes.StringValue).ValueString()))
+	}
+	if data.CollectRuntimeMetadata.IsNull() || data.CollectRuntimeMetadata.ValueBool() {
+		c.runtimeMetadata = runtimeMetadata(c.terraformVersion, p.version)
+	}
+	if window, err := time.ParseDuration(data.ThrottleWindow.ValueString()); err == nil {
+		throttleCachePath := data.ThrottleCachePath.ValueString()
+		if throttleCachePath == "" {
+			throttleCachePath = defaultThrottleCachePath()
+		}
+		c.throttle = newEventThrottle(throttleCachePath, window)
+	}
+	c.pipeline = newEventPipeline(c, disabledStages)
+	var ft *functionTelemetry
+	if enabled && !c.offline && data.FunctionTelemetry.ValueBool() {
+		ft = newFunctionTelemetry(c)
+	}
+	p.setFunctionTelemetry(ft)
 
 	c.defaultEndpoint = data.Endpoint.IsNull() && endpointEnv == ""
 	resp.DataSourceData = c
 	resp.ResourceData = resp.DataSourceData
+	resp.EphemeralResourceData = resp.DataSourceData
+}
+
+// markdownCodeList formats items as a comma separated list of inline code.
+func markdownCodeList(items []string) string {
+	quoted := make([]string, 0, len(items))
+	for _, item := range items {
+		quoted = append(quoted, fmt.Sprintf("`%s`", item))
+	}
+	return strings.Join(quoted, ", ")
 }
 
 func readEndpointFromProviderBlock(data ModuleTelemetryProviderModel) string {
@@ -141,61 +1224,90 @@ func readEndpointFromProviderBlock(data ModuleTelemetryProviderModel) string {
 func (p *ModuleTelemetryProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewTelemetryResource,
+		NewTelemetryEventResource,
+	}
+}
+
+func (p *ModuleTelemetryProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewTelemetrySessionEphemeralResource,
 	}
 }
 
 func (p *ModuleTelemetryProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewModuleSourceDataSource,
+		NewModuleParentsDataSource,
+		NewModulesDataSource,
+		NewModuleDependenciesDataSource,
+		NewProviderLocksDataSource,
+		NewTerraformMetadataDataSource,
+		NewProviderConfigDataSource,
+		NewModuleTelemetryDataSource,
 	}
 }
 
 func (p *ModuleTelemetryProvider) Functions(ctx context.Context) []func() function.Function {
 	return []func() function.Function{
-		NewModuleSourceFunction,
-		NewModuleVersionFunction,
+		func() function.Function {
+			return NewModuleSourceFunction(p.modulesJson, p.moduleFunctionTelemetry())
+		},
+		func() function.Function {
+			return NewModuleVersionFunction(p.modulesJson, p.moduleFunctionTelemetry())
+		},
+		func() function.Function {
+			return NewModuleMetadataFunction(p.modulesJson, p.moduleFunctionTelemetry())
+		},
+		func() function.Function {
+			return NewModuleDirToSourceFunction(p.modulesJson)
+		},
+		func() function.Function {
+			return NewModuleKeyFunction(p.modulesJson)
+		},
+		func() function.Function {
+			return NewIsRegistryModuleFunction(p.modulesJson)
+		},
+		func() function.Function {
+			return NewVersionSatisfiesFunction(p.modulesJson)
+		},
+		NewUuidV5Function,
+		NewNormalizeTimestampFunction,
+		NewParseGitRemoteFunction,
+		NewParseModuleSourceFunction,
 	}
 }
 
-func New(version string) func() provider.Provider {
-	return func() provider.Provider {
-		return &ModuleTelemetryProvider{
-			version: version,
-		}
-	}
+func (p *ModuleTelemetryProvider) setFunctionTelemetry(t *functionTelemetry) {
+	p.functionTelemetryMu.Lock()
+	defer p.functionTelemetryMu.Unlock()
+	p.functionTelemetry = t
+	p.functionTelemetryResolved = true
 }
 
-var endpointBlobUrl = "https://avmtftelemetrysvc.blob.core.windows.net/blob/endpoint"
-
-func readEndpointFromBlob() (string, error) {
-	c := make(chan int)
-	errChan := make(chan error)
-	var endpoint string
-	var returnError error
-	go func() {
-		resp, err := http.Get(endpointBlobUrl) // #nosec G107
-		if err != nil {
-			errChan <- err
-			return
+// moduleFunctionTelemetry returns the function telemetry set by Configure, or the one read from the environment
+// if the provider instance is not configured when the first function is called.
+func (p *ModuleTelemetryProvider) moduleFunctionTelemetry() *functionTelemetry {
+	p.functionTelemetryMu.Lock()
+	defer p.functionTelemetryMu.Unlock()
+	if !p.functionTelemetryResolved {
+		client := p.client
+		if client == nil {
+			crypto := cryptoPolicy{fipsMode: boringCrypto || strings.EqualFold(os.Getenv("MODTM_FIPS_MODE"), "true")}
+			proxy := proxySettings{detectSystemProxy: true}
+			proxy.url, _ = parseExplicitProxyURL(os.Getenv(proxyEnv))
+			client = newHttpTelemetryClient(newHTTPClient(crypto, proxy, tlsSettings{}), payloadEncodingJSON)
 		}
-		defer func() {
-			_ = resp.Body.Close()
-		}()
+		p.functionTelemetry = newFunctionTelemetryFromEnv(client)
+		p.functionTelemetryResolved = true
+	}
+	return p.functionTelemetry
+}
 
-		bytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			errChan <- err
-			return
+func New(version string) func() provider.Provider {
+	return func() provider.Provider {
+		return &ModuleTelemetryProvider{
+			version:     version,
+			modulesJson: newModulesJsonCache(),
 		}
-		endpoint = string(bytes)
-		c <- 1
-	}()
-	select {
-	case <-c:
-		return endpoint, returnError
-	case err := <-errChan:
-		return "", err
-	case <-time.After(5 * time.Second):
-		return "", fmt.Errorf("timeout on reading default endpoint")
 	}
 }
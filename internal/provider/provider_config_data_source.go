@@ -0,0 +1,221 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &ProviderConfigDataSource{}
+var _ datasource.DataSourceWithConfigure = &ProviderConfigDataSource{}
+
+type ProviderConfigDataSource struct {
+	config providerConfig
+}
+
+func NewProviderConfigDataSource() datasource.DataSource {
+	return &ProviderConfigDataSource{}
+}
+
+type ProviderConfigDataSourceModel struct {
+	Enabled                         types.Bool   `tfsdk:"enabled"`
+	Offline                         types.Bool   `tfsdk:"offline"`
+	AllowInsecureEndpoint           types.Bool   `tfsdk:"allow_insecure_endpoint"`
+	Endpoint                        types.String `tfsdk:"endpoint"`
+	Endpoints                       []string     `tfsdk:"endpoints"`
+	EndpointSource                  types.String `tfsdk:"endpoint_source"`
+	FallbackEndpoints               []string     `tfsdk:"fallback_endpoints"`
+	ResourceEndpointOverride        types.Bool   `tfsdk:"resource_endpoint_override"`
+	ModuleSourceRegex               []string     `tfsdk:"module_source_regex"`
+	ModuleSourceDenyRegex           []string     `tfsdk:"module_source_deny_regex"`
+	EventStages                     []string     `tfsdk:"event_stages"`
+	TerraformTest                   types.Bool   `tfsdk:"terraform_test"`
+	MaxConcurrentSends              types.Int64  `tfsdk:"max_concurrent_sends"`
+	MaxEventsPerMinute              types.Int64  `tfsdk:"max_events_per_minute"`
+	PayloadEncoding                 types.String `tfsdk:"payload_encoding"`
+	PayloadFormat                   types.String `tfsdk:"payload_format"`
+	Transport                       types.String `tfsdk:"transport"`
+	SendMode                        types.String `tfsdk:"send_mode"`
+	FipsMode                        types.Bool   `tfsdk:"fips_mode"`
+	SendTimeoutSeconds              types.Int64  `tfsdk:"send_timeout_seconds"`
+	EndpointDiscoveryTimeoutSeconds types.Int64  `tfsdk:"endpoint_discovery_timeout_seconds"`
+}
+
+func (m *ProviderConfigDataSource) Metadata(ctx context.Context, request datasource.MetadataRequest, response *datasource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_provider_config"
+}
+
+func (m *ProviderConfigDataSource) Schema(ctx context.Context, request datasource.SchemaRequest, response *datasource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		MarkdownDescription: "`modtm_provider_config` data source returns the effective configuration of the provider after defaults, environment variables and endpoint discovery are resolved, so troubleshooting why a telemetry event didn't arrive doesn't require reading the provider's source code.",
+		Attributes: map[string]schema.Attribute{
+			"enabled": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether telemetry is enabled",
+			},
+			"allow_insecure_endpoint": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether `http` endpoints on other hosts than localhost are allowed",
+			},
+			"offline": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether the provider is offline, in which case no network call is made",
+			},
+			"endpoint": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The provider's telemetry endpoint. Reading this data source triggers the discovery of the default endpoint when `endpoint_source` is `blob`, or the read of App Configuration when it's `app_configuration`, the value is empty when the discovery fails.",
+			},
+			"endpoints": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "The additional endpoints that every event is sent to",
+			},
+			"fallback_endpoints": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "The endpoints that an event is retried against in order when the provider's endpoint fails",
+			},
+			"endpoint_source": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Where the provider's endpoint comes from: `provider` for the `endpoint` argument, `env` for `MODTM_ENDPOINT` environment variable, `app_configuration` for the endpoint read from `app_configuration`, `blob` for the default endpoint discovered from Microsoft's blob, or `none` when there's no provider endpoint, e.g. in offline mode or when the default endpoint discovery is disabled.",
+			},
+			"resource_endpoint_override": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether the `endpoint` argument of `modtm_telemetry` resources takes precedence over the provider's endpoint, which is the case when neither the `endpoint` argument nor `MODTM_ENDPOINT` environment variable is set",
+			},
+			"module_source_regex": schema.ListAttribute{
+				Computed:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "The allow list of module source regexes",
+			},
+			"module_source_deny_regex": schema.ListAttribute{
+				Computed:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "The deny list of module source regexes",
+			},
+			"event_stages": schema.ListAttribute{
+				Computed:            true,
+				ElementType:         types.StringType,
+				MarkdownDescription: "The enabled stages of the event pipeline, in the order they run",
+			},
+			"terraform_test": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether the provider is launched by `terraform test`",
+			},
+			"max_concurrent_sends": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Maximum number of concurrent telemetry requests, null when unlimited",
+			},
+			"max_events_per_minute": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Maximum number of telemetry events sent per minute, null when unlimited",
+			},
+			"payload_encoding": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The encoding of the telemetry payload",
+			},
+			"payload_format": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The format of the telemetry payload",
+			},
+			"send_mode": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether resources wait for their telemetry events to be sent",
+			},
+			"transport": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "How telemetry events are delivered to the endpoint",
+			},
+			"fips_mode": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether FIPS mode is on",
+			},
+			"send_timeout_seconds": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "How long the provider waits for the endpoint to respond to a telemetry event, in seconds, as set by `request_timeout`",
+			},
+			"endpoint_discovery_timeout_seconds": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "How long the provider waits for the default endpoint discovery, in seconds",
+			},
+		},
+	}
+}
+
+func (m *ProviderConfigDataSource) Configure(ctx context.Context, request datasource.ConfigureRequest, response *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if request.ProviderData == nil {
+		return
+	}
+
+	c, ok := request.ProviderData.(providerConfig)
+
+	if !ok {
+		response.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected providerConfig, got: %T. Please report this issue to the provider developers.", request.ProviderData),
+		)
+
+		return
+	}
+
+	m.config = c
+}
+
+func (m *ProviderConfigDataSource) Read(ctx context.Context, request datasource.ReadRequest, response *datasource.ReadResponse) {
+	data := &ProviderConfigDataSourceModel{}
+	response.Diagnostics.Append(request.Config.Get(ctx, data)...)
+
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	c := m.config
+	data.Enabled = types.BoolValue(c.enabled)
+	data.Offline = types.BoolValue(c.offline)
+	data.AllowInsecureEndpoint = types.BoolValue(c.allowInsecureEndpoint)
+	data.Endpoint = types.StringValue("")
+	if c.endpointFunc != nil {
+		data.Endpoint = types.StringValue(c.endpointFunc())
+	}
+	data.Endpoints = append([]string{}, c.endpoints...)
+	data.EndpointSource = types.StringValue(c.endpointSource)
+	data.FallbackEndpoints = append([]string{}, c.fallbackEndpoints...)
+	data.ResourceEndpointOverride = types.BoolValue(c.defaultEndpoint && !c.offline)
+	data.ModuleSourceRegex = make([]string, 0, len(c.moduleSourceRegex))
+	for _, regex := range c.moduleSourceRegex {
+		data.ModuleSourceRegex = append(data.ModuleSourceRegex, regex.String())
+	}
+	data.ModuleSourceDenyRegex = make([]string, 0, len(c.moduleSourceDenyRegex))
+	for _, regex := range c.moduleSourceDenyRegex {
+		data.ModuleSourceDenyRegex = append(data.ModuleSourceDenyRegex, regex.String())
+	}
+	data.EventStages = make([]string, 0, len(c.pipeline))
+	for _, stage := range c.pipeline {
+		data.EventStages = append(data.EventStages, stage.name)
+	}
+	data.TerraformTest = types.BoolValue(c.terraformTest)
+	data.MaxConcurrentSends = types.Int64Null()
+	if c.maxConcurrentSends > 0 {
+		data.MaxConcurrentSends = types.Int64Value(c.maxConcurrentSends)
+	}
+	data.MaxEventsPerMinute = types.Int64Null()
+	if c.maxEventsPerMinute > 0 {
+		data.MaxEventsPerMinute = types.Int64Value(c.maxEventsPerMinute)
+	}
+	data.PayloadEncoding = types.StringValue(c.payloadEncoding)
+	data.PayloadFormat = types.StringValue(c.payloadFormat)
+	data.Transport = types.StringValue(c.transport)
+	data.SendMode = types.StringValue(c.sendMode)
+	data.FipsMode = types.BoolValue(c.crypto.fipsMode)
+	data.SendTimeoutSeconds = types.Int64Value(int64(c.requestTimeout.Seconds()))
+	data.EndpointDiscoveryTimeoutSeconds = types.Int64Value(int64(endpointDiscoveryTimeout.Seconds()))
+	traceLog(ctx, fmt.Sprintf("read provider config, endpoint source is %s", c.endpointSource))
+	response.Diagnostics.Append(response.State.Set(ctx, data)...)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccProviderConfigDataSource(t *testing.T) {
+	t.Setenv("MODTM_ENDPOINT", "")
+	client := &fakeTelemetryClient{endpoint: "https://discovered.contoso.com"}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "modtm" {
+  module_source_regex   = ["foo", "^Azure/"]
+  disabled_event_stages = ["enrichment_command"]
+  max_concurrent_sends  = 2
+}
+
+data "modtm_provider_config" "test" {}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "enabled", "true"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "offline", "false"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "endpoint", "https://discovered.contoso.com"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "endpoint_source", "blob"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "resource_endpoint_override", "true"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "module_source_regex.#", "2"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "module_source_regex.1", "^Azure/"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "event_stages.#", "8"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "event_stages.6", stageGitTimestamp),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "max_concurrent_sends", "2"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "payload_encoding", "json"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "send_timeout_seconds", "5"),
+				),
+			},
+			{
+				Config: `
+provider "modtm" {
+  endpoint            = "https://provider.contoso.com"
+  module_source_regex = ["foo"]
+}
+
+data "modtm_provider_config" "test" {}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "endpoint", "https://provider.contoso.com"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "endpoint_source", "provider"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "resource_endpoint_override", "false"),
+					resource.TestCheckNoResourceAttr("data.modtm_provider_config.test", "max_concurrent_sends"),
+					resource.TestCheckNoResourceAttr("data.modtm_provider_config.test", "max_events_per_minute"),
+				),
+			},
+			{
+				Config: `
+provider "modtm" {
+  module_source_regex = ["foo"]
+  offline             = true
+}
+
+data "modtm_provider_config" "test" {}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "endpoint", ""),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "endpoint_source", "none"),
+					resource.TestCheckResourceAttr("data.modtm_provider_config.test", "resource_endpoint_override", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestResolveEndpointSource(t *testing.T) {
+	assert.Equal(t, endpointSourceEnv, resolveEndpointSource(ModuleTelemetryProviderModel{}, "https://env.contoso.com", true))
+	assert.Equal(t, endpointSourceBlob, resolveEndpointSource(ModuleTelemetryProviderModel{}, "", false))
+	assert.Equal(t, endpointSourceAppConfiguration, resolveEndpointSource(ModuleTelemetryProviderModel{}, "", true))
+	assert.Equal(t, endpointSourceNone, resolveEndpointSource(ModuleTelemetryProviderModel{Sink: types.StringValue(sinkFile)}, "https://env.contoso.com", true))
+}
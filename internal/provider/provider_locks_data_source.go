@@ -0,0 +1,102 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &ProviderLocksDataSource{}
+
+type ProviderLocksDataSource struct {
+}
+
+func NewProviderLocksDataSource() datasource.DataSource {
+	return &ProviderLocksDataSource{}
+}
+
+type ProviderLocksDataSourceModel struct {
+	LockFilePath types.String        `tfsdk:"lock_file_path"`
+	Providers    []ProviderLockModel `tfsdk:"providers"`
+}
+
+type ProviderLockModel struct {
+	Address     types.String `tfsdk:"address"`
+	Version     types.String `tfsdk:"version"`
+	Constraints types.String `tfsdk:"constraints"`
+}
+
+func (m *ProviderLocksDataSource) Metadata(ctx context.Context, request datasource.MetadataRequest, response *datasource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_provider_locks"
+}
+
+func (m *ProviderLocksDataSource) Schema(ctx context.Context, request datasource.SchemaRequest, response *datasource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		MarkdownDescription: "`modtm_provider_locks` data source returns the providers selected in the root module's dependency lock file `.terraform.lock.hcl`, so module owners could learn which provider versions their modules run against, e.g. by adding them to telemetry tags.",
+		Attributes: map[string]schema.Attribute{
+			"lock_file_path": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: fmt.Sprintf("The path of the dependency lock file, relative to the root module's directory. Defaults to `%s`.", defaultLockFilePath),
+			},
+			"providers": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "The locked providers, ordered by `address`. Empty when the lock file doesn't exist, e.g. before `terraform init`, a warning is returned when it cannot be parsed.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"address": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The source address of the provider, e.g. `registry.terraform.io/hashicorp/azurerm`",
+						},
+						"version": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The selected version of the provider",
+						},
+						"constraints": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The version constraints of the provider in the configuration when it was selected, e.g. `>= 3.71.0, < 4.0.0`. Null when there's no constraint.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (m *ProviderLocksDataSource) Read(ctx context.Context, request datasource.ReadRequest, response *datasource.ReadResponse) {
+	data := &ProviderLocksDataSourceModel{}
+	response.Diagnostics.Append(request.Config.Get(ctx, data)...)
+
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	path := defaultLockFilePath
+	if !data.LockFilePath.IsNull() {
+		path = data.LockFilePath.ValueString()
+	}
+	data.Providers = make([]ProviderLockModel, 0)
+	providers, err := readLockFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		traceLog(ctx, fmt.Sprintf("dependency lock file %s not found", path))
+	case err != nil:
+		response.Diagnostics.AddWarning("Failed to read the dependency lock file", fmt.Sprintf("No provider lock is returned, %s: %s", path, err.Error()))
+	}
+	for _, p := range providers {
+		data.Providers = append(data.Providers, ProviderLockModel{
+			Address:     types.StringValue(p.Address),
+			Version:     types.StringValue(p.Version),
+			Constraints: types.StringPointerValue(p.Constraints),
+		})
+	}
+	traceLog(ctx, fmt.Sprintf("read provider locks from %s, found %d providers", path, len(data.Providers)))
+	response.Diagnostics.Append(response.State.Set(ctx, data)...)
+}
@@ -0,0 +1,45 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccProviderLocksDataSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".terraform.lock.hcl")
+	require.NoError(t, os.WriteFile(path, []byte(testLockFile), 0600))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  enabled = false
+  module_source_regex = ["foo"]
+}
+
+data "modtm_provider_locks" "test" {
+  lock_file_path = %q
+}
+`, path),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.modtm_provider_locks.test", "providers.#", "2"),
+					resource.TestCheckResourceAttr("data.modtm_provider_locks.test", "providers.1.address", "registry.terraform.io/hashicorp/azurerm"),
+					resource.TestCheckResourceAttr("data.modtm_provider_locks.test", "providers.1.version", "3.116.0"),
+					resource.TestCheckResourceAttr("data.modtm_provider_locks.test", "providers.1.constraints", ">= 3.71.0, < 4.0.0"),
+					resource.TestCheckNoResourceAttr("data.modtm_provider_locks.test", "providers.0.constraints"),
+				),
+			},
+		},
+	})
+}
@@ -4,12 +4,19 @@
 package provider
 
 import (
+	"os"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 )
 
+func TestMain(m *testing.M) {
+	// The endpoints discovered by tests must not be cached in the user's cache directory, nor leak into other tests.
+	_ = os.Setenv(endpointCacheBypassEnv, "true")
+	os.Exit(m.Run())
+}
+
 // testAccProtoV6ProviderFactories are used to instantiate a provider during
 // acceptance testing. The factory function will be invoked for every Terraform
 // CLI command executed to create a provider server to which the CLI can
@@ -18,6 +25,16 @@ var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServe
 	"modtm": providerserver.NewProtocol6WithError(New("test")()),
 }
 
+// testAccProtoV6ProviderFactoriesWithClient instantiates providers that talk to the given client instead of the network.
+func testAccProtoV6ProviderFactoriesWithClient(client telemetryClient) map[string]func() (tfprotov6.ProviderServer, error) {
+	return map[string]func() (tfprotov6.ProviderServer, error){
+		"modtm": providerserver.NewProtocol6WithError(&ModuleTelemetryProvider{
+			version: "test",
+			client:  client,
+		}),
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	// You can add code here to run prior to any test case execution, for example assertions
 	// about the appropriate environment variables being set are common to see in a pre-check
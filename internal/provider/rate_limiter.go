@@ -0,0 +1,73 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var errRateLimited = errors.New("telemetry event dropped since max_events_per_minute is reached")
+
+// rateLimiter is a token bucket that limits the telemetry events sent by a provider instance to eventsPerMinute,
+// allowing a burst of eventsPerMinute events. A nil *rateLimiter doesn't limit anything.
+type rateLimiter struct {
+	mu sync.Mutex
+	// tokens could be negative, the events that have reserved a future token are waiting for it.
+	tokens    float64
+	capacity  float64
+	perSecond float64
+	last      time.Time
+}
+
+func newRateLimiter(eventsPerMinute int64) *rateLimiter {
+	if eventsPerMinute <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		tokens:    float64(eventsPerMinute),
+		capacity:  float64(eventsPerMinute),
+		perSecond: float64(eventsPerMinute) / 60,
+		last:      timeNow(),
+	}
+}
+
+// wait reserves a token and waits until it's available. It returns errRateLimited without waiting if the token
+// wouldn't be available before ctx's deadline, or ctx's error if ctx is done while waiting.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := timeNow()
+	l.tokens = min(l.capacity, l.tokens+now.Sub(l.last).Seconds()*l.perSecond)
+	l.last = now
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.perSecond * float64(time.Second))
+	}
+	if deadline, ok := ctx.Deadline(); ok && delay > time.Until(deadline) {
+		l.tokens++
+		l.mu.Unlock()
+		return errRateLimited
+	}
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
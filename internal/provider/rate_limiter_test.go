@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_wait(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return now
+	})
+	defer stub.Reset()
+
+	limiter := newRateLimiter(2)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, limiter.wait(ctx))
+	assert.NoError(t, limiter.wait(ctx))
+	assert.ErrorIs(t, limiter.wait(ctx), errRateLimited, "the next token is 30 seconds away")
+
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, limiter.wait(ctx))
+	assert.ErrorIs(t, limiter.wait(ctx), errRateLimited)
+
+	now = now.Add(time.Hour)
+	assert.NoError(t, limiter.wait(ctx))
+	assert.NoError(t, limiter.wait(ctx))
+	assert.ErrorIs(t, limiter.wait(ctx), errRateLimited, "burst is capped")
+}
+
+func TestRateLimiter_nilDoesNotLimit(t *testing.T) {
+	var limiter *rateLimiter
+	assert.Nil(t, newRateLimiter(0))
+	assert.NoError(t, limiter.wait(context.Background()))
+}
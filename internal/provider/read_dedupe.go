@@ -0,0 +1,65 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+const readEvent = "read"
+
+// readDedupe remembers the read events let through by the provider instance. Terraform starts a provider instance
+// for every operation, so a refresh that reads the same resource more than once only sends one read event.
+type readDedupe struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+func newReadDedupe() *readDedupe {
+	return &readDedupe{seen: make(map[string]struct{})}
+}
+
+// firstSeen returns true the first time it's called with key.
+func (d *readDedupe) firstSeen(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.seen[key]; ok {
+		return false
+	}
+	d.seen[key] = struct{}{}
+	return true
+}
+
+// readDedupeKey identifies an event by its `resource_id` tag, its name and the hash of its tags. The `sequence` and
+// `timestamp` tags are different for every event so they're not hashed.
+func readDedupeKey(e *telemetryEvent) string {
+	tags := maps.Clone(e.tags)
+	delete(tags, sequenceTag)
+	delete(tags, timestampTag)
+	// Map keys are sorted by json.Marshal, so the hash is stable.
+	content, _ := json.Marshal(tags)
+	return fmt.Sprintf("%s|%s|%x", e.tags[resourceIdTag], e.name, sha256.Sum256(content))
+}
+
+// readDedupeStage drops a read event if an identical read event has been let through by the provider instance.
+func readDedupeStage(dedupe *readDedupe) eventStage {
+	return eventStage{
+		name: stageReadDedupe,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			if e.name != readEvent {
+				return true
+			}
+			if !dedupe.firstSeen(readDedupeKey(e)) {
+				traceLog(ctx, fmt.Sprintf("skip %s telemetry event: duplicate of an event sent in this operation", e.name))
+				return false
+			}
+			return true
+		},
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDedupeStage(t *testing.T) {
+	stage := readDedupeStage(newReadDedupe())
+	event := func(name, resourceId, sequence, version string) *telemetryEvent {
+		return &telemetryEvent{name: name, tags: map[string]string{
+			"event":          name,
+			"resource_id":    resourceId,
+			"sequence":       sequence,
+			"timestamp":      "2024-01-02T03:04:0" + sequence + "Z",
+			"module_version": version,
+		}}
+	}
+	ctx := context.Background()
+	assert.True(t, stage.process(ctx, event("read", "a", "1", "1.0.0")))
+	assert.False(t, stage.process(ctx, event("read", "a", "2", "1.0.0")), "identical read event")
+	assert.True(t, stage.process(ctx, event("read", "b", "3", "1.0.0")), "another resource")
+	assert.True(t, stage.process(ctx, event("read", "a", "4", "1.1.0")), "different tags")
+	assert.True(t, stage.process(ctx, event("update", "a", "5", "1.1.0")))
+	assert.True(t, stage.process(ctx, event("update", "a", "6", "1.1.0")), "only read events are deduplicated")
+}
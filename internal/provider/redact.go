@@ -0,0 +1,33 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// redacted replaces the substrings of tag values matching `redact_patterns`.
+const redacted = "[REDACTED]"
+
+// redactStage replaces the substrings of all tag values that match one of the patterns with `[REDACTED]`.
+func redactStage(patterns []*regexp.Regexp) eventStage {
+	return eventStage{
+		name: stageRedact,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			for k, v := range e.tags {
+				r := v
+				for _, pattern := range patterns {
+					r = pattern.ReplaceAllLiteralString(r, redacted)
+				}
+				if r != v {
+					traceLog(ctx, fmt.Sprintf("redacted %s tag of %s telemetry event", k, e.name))
+					e.tags[k] = r
+				}
+			}
+			return true
+		},
+	}
+}
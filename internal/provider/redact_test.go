@@ -0,0 +1,30 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactStage(t *testing.T) {
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`),
+		regexp.MustCompile(`AccountKey=[^;]+`),
+	}
+	e := &telemetryEvent{tags: map[string]string{
+		"owner":      "alice@contoso.com, bob@contoso.com",
+		"connection": "DefaultEndpointsProtocol=https;AccountName=foo;AccountKey=c2VjcmV0;EndpointSuffix=core.windows.net",
+		"module":     "avm-res-keyvault-vault",
+	}}
+	assert.True(t, redactStage(patterns).process(context.Background(), e))
+	assert.Equal(t, map[string]string{
+		"owner":      "[REDACTED], [REDACTED]",
+		"connection": "DefaultEndpointsProtocol=https;AccountName=foo;[REDACTED];EndpointSuffix=core.windows.net",
+		"module":     "avm-res-keyvault-vault",
+	}, e.tags)
+}
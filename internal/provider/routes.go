@@ -0,0 +1,93 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// RouteModel describes an item of provider's `routes`.
+type RouteModel struct {
+	Endpoint          types.String  `tfsdk:"endpoint"`
+	Events            types.List    `tfsdk:"events"`
+	ModuleSourceRegex types.String  `tfsdk:"module_source_regex"`
+	SamplingRate      types.Float64 `tfsdk:"sampling_rate"`
+}
+
+// eventRoute mirrors the events that pass its filters to an additional endpoint.
+type eventRoute struct {
+	endpoint string
+	// events are the names of the events to mirror, all events when empty.
+	events []string
+	// moduleSourceRegex is the regex that the `module_source` tag should match, nil matches all events.
+	moduleSourceRegex *regexp.Regexp
+	samplingRate      float64
+	// client sends the events to the endpoint in its own protocol, e.g. Application Insights, the provider's client
+	// is used when it's nil.
+	client telemetryClient
+}
+
+func newEventRoutes(ctx context.Context, models []RouteModel) ([]eventRoute, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	var routes []eventRoute
+	for _, m := range models {
+		route := eventRoute{
+			endpoint:     m.Endpoint.ValueString(),
+			samplingRate: 1,
+		}
+		diags.Append(m.Events.ElementsAs(ctx, &route.events, false)...)
+		if !m.ModuleSourceRegex.IsNull() {
+			route.moduleSourceRegex = regexp.MustCompile(m.ModuleSourceRegex.ValueString())
+		}
+		if !m.SamplingRate.IsNull() {
+			route.samplingRate = m.SamplingRate.ValueFloat64()
+		}
+		routes = append(routes, route)
+	}
+	return routes, diags
+}
+
+// matches returns true if the event should be mirrored to the route's endpoint. Like the `sampling_rules`, the
+// sampling decision is made on the `resource_id` tag so all events of the same resource are mirrored together.
+func (r eventRoute) matches(e *telemetryEvent) bool {
+	if len(r.events) > 0 && !slices.Contains(r.events, e.name) {
+		return false
+	}
+	if r.moduleSourceRegex != nil && !r.moduleSourceRegex.MatchString(e.tags["module_source"]) {
+		return false
+	}
+	return r.samplingRate >= 1 || samplingPoint(e.tags["resource_id"]) < r.samplingRate
+}
+
+// sendToRoutes mirrors the event to the endpoints of all matching routes. Failures are only logged, they don't
+// affect the delivery state of the resource.
+func (r *TelemetryResource) sendToRoutes(ctx context.Context, e *telemetryEvent, timeout time.Duration) {
+	for _, route := range r.routes {
+		if !route.matches(e) {
+			continue
+		}
+		if err := r.sendLimiter.acquire(ctx, e.highPriority); err != nil {
+			traceLog(ctx, fmt.Sprintf("skip %s telemetry event for route %s: %s", e.name, route.endpoint, err.Error()))
+			continue
+		}
+		client := r.client
+		if route.client != nil {
+			client = route.client
+		}
+		sendCtx, cancel := withRequestTimeout(ctx, timeout)
+		err := client.send(sendCtx, route.endpoint, e.tags)
+		cancel()
+		r.sendLimiter.release()
+		if err != nil {
+			errorLog(ctx, fmt.Sprintf("error on sending %s telemetry event to route %s: %+v", e.name, route.endpoint, err))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventRoute_matches(t *testing.T) {
+	event := func(name, moduleSource, resourceId string) *telemetryEvent {
+		return &telemetryEvent{
+			name: name,
+			tags: map[string]string{"module_source": moduleSource, "resource_id": resourceId},
+		}
+	}
+	cases := []struct {
+		desc     string
+		route    eventRoute
+		event    *telemetryEvent
+		expected bool
+	}{
+		{
+			desc:     "no filter",
+			route:    eventRoute{samplingRate: 1},
+			event:    event("read", "foo", "id"),
+			expected: true,
+		},
+		{
+			desc:     "event not listed",
+			route:    eventRoute{events: []string{"create", "delete"}, samplingRate: 1},
+			event:    event("read", "foo", "id"),
+			expected: false,
+		},
+		{
+			desc:     "event listed",
+			route:    eventRoute{events: []string{"create", "delete"}, samplingRate: 1},
+			event:    event("delete", "foo", "id"),
+			expected: true,
+		},
+		{
+			desc:     "module source mismatch",
+			route:    eventRoute{moduleSourceRegex: regexp.MustCompile("^registry.terraform.io/MyOrg/"), samplingRate: 1},
+			event:    event("create", "registry.terraform.io/Azure/foo/azurerm", "id"),
+			expected: false,
+		},
+		{
+			desc:     "module source match",
+			route:    eventRoute{moduleSourceRegex: regexp.MustCompile("^registry.terraform.io/MyOrg/"), samplingRate: 1},
+			event:    event("create", "registry.terraform.io/MyOrg/foo/azurerm", "id"),
+			expected: true,
+		},
+		{
+			desc:     "sampled out",
+			route:    eventRoute{samplingRate: 0},
+			event:    event("create", "foo", "id"),
+			expected: false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.route.matches(c.event))
+		})
+	}
+}
+
+func TestEventRoute_samplingIsPerResource(t *testing.T) {
+	route := eventRoute{samplingRate: 0.5}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		expected := route.matches(&telemetryEvent{name: "create", tags: map[string]string{"resource_id": id}})
+		assert.Equal(t, expected, route.matches(&telemetryEvent{name: "delete", tags: map[string]string{"resource_id": id}}))
+	}
+}
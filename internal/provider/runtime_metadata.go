@@ -0,0 +1,35 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"runtime"
+)
+
+// runtimeMetadata returns the tags describing the Terraform and provider runtime that events are sent from.
+func runtimeMetadata(terraformVersion, providerVersion string) map[string]string {
+	return map[string]string{
+		"terraform_version": terraformVersion,
+		"provider_version":  providerVersion,
+		"os":                runtime.GOOS,
+		"arch":              runtime.GOARCH,
+	}
+}
+
+// runtimeMetadataStage tags the event with the runtime metadata, unless the tags are already set or empty. A nil
+// metadata means the collection is turned off.
+func runtimeMetadataStage(metadata map[string]string) eventStage {
+	return eventStage{
+		name: stageRuntimeMetadata,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			for k, v := range metadata {
+				if _, ok := e.tags[k]; !ok && v != "" {
+					e.tags[k] = v
+				}
+			}
+			return true
+		},
+	}
+}
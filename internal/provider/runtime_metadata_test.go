@@ -0,0 +1,26 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntimeMetadataStage(t *testing.T) {
+	e := &telemetryEvent{tags: map[string]string{"os": "custom"}}
+	assert.True(t, runtimeMetadataStage(runtimeMetadata("1.9.0", "")).process(context.Background(), e))
+	assert.Equal(t, map[string]string{
+		"terraform_version": "1.9.0",
+		"os":                "custom",
+		"arch":              runtime.GOARCH,
+	}, e.tags)
+
+	e = &telemetryEvent{tags: map[string]string{}}
+	assert.True(t, runtimeMetadataStage(nil).process(context.Background(), e))
+	assert.Empty(t, e.tags)
+}
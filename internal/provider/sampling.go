@@ -0,0 +1,64 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// SamplingRuleModel describes an item of provider's `sampling_rules`.
+type SamplingRuleModel struct {
+	ModuleSourceRegex types.String  `tfsdk:"module_source_regex"`
+	Rate              types.Float64 `tfsdk:"rate"`
+}
+
+// sampleRateTag is the tag carrying the rate of the events kept by a sampling rule whose rate is below 1.
+const sampleRateTag = "sample_rate"
+
+type samplingRule struct {
+	moduleSourceRegex *regexp.Regexp
+	rate              float64
+}
+
+// samplingStage keeps the event according to the rate of the first rule whose regex matches the event's
+// `module_source` tag, events that match no rule are always kept. The decision is made on the `resource_id`
+// tag, so all events of the same resource are either kept or dropped together. Kept events are tagged with
+// `sample_rate` when the rate is below 1, so the collector could weight them accordingly.
+func samplingStage(rules func() []samplingRule) eventStage {
+	return eventStage{
+		name: stageSampling,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			if rules == nil {
+				return true
+			}
+			src := e.tags["module_source"]
+			for _, rule := range rules() {
+				if !rule.moduleSourceRegex.MatchString(src) {
+					continue
+				}
+				if rule.rate >= 1 {
+					return true
+				}
+				if samplingPoint(e.tags["resource_id"]) >= rule.rate {
+					return false
+				}
+				e.tags[sampleRateTag] = strconv.FormatFloat(rule.rate, 'f', -1, 64)
+				return true
+			}
+			return true
+		},
+	}
+}
+
+// samplingPoint maps the id to a stable point in [0, 1).
+func samplingPoint(id string) float64 {
+	sum := sha256.Sum256([]byte(id))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / float64(uint64(1)<<53)
+}
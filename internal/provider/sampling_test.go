@@ -0,0 +1,66 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func staticSamplingRules(rules ...samplingRule) func() []samplingRule {
+	return func() []samplingRule {
+		return rules
+	}
+}
+
+func TestSamplingStage(t *testing.T) {
+	stage := samplingStage(staticSamplingRules(
+		samplingRule{moduleSourceRegex: regexp.MustCompile(`^Azure/avm-`), rate: 1},
+		samplingRule{moduleSourceRegex: regexp.MustCompile(`^Azure/legacy-`), rate: 0},
+		samplingRule{moduleSourceRegex: regexp.MustCompile(`.*`), rate: 0.25},
+	))
+	kept := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		for _, src := range []string{"Azure/avm-res-storage", "Azure/legacy-vnet", "contoso/network"} {
+			e := &telemetryEvent{name: "create", tags: map[string]string{
+				"module_source": src,
+				"resource_id":   fmt.Sprintf("00000000-0000-0000-0000-%012d", i),
+			}}
+			if stage.process(context.Background(), e) {
+				kept[src]++
+				if src == "contoso/network" {
+					assert.Equal(t, "0.25", e.tags["sample_rate"])
+				} else {
+					assert.NotContains(t, e.tags, "sample_rate")
+				}
+			}
+		}
+	}
+	assert.Equal(t, 1000, kept["Azure/avm-res-storage"])
+	assert.Equal(t, 0, kept["Azure/legacy-vnet"])
+	assert.InDelta(t, 250, kept["contoso/network"], 50)
+}
+
+func TestSamplingStage_sameResourceSameDecision(t *testing.T) {
+	stage := samplingStage(staticSamplingRules(samplingRule{moduleSourceRegex: regexp.MustCompile(`.*`), rate: 0.5}))
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+		var decisions []bool
+		for _, event := range []string{"create", "read", "update", "delete"} {
+			e := &telemetryEvent{name: event, tags: map[string]string{"module_source": "foo", "resource_id": id}}
+			decisions = append(decisions, stage.process(context.Background(), e))
+		}
+		assert.Equal(t, []bool{decisions[0], decisions[0], decisions[0], decisions[0]}, decisions)
+	}
+}
+
+func TestSamplingStage_noMatchingRuleKeepsEvent(t *testing.T) {
+	stage := samplingStage(staticSamplingRules(samplingRule{moduleSourceRegex: regexp.MustCompile(`^bar$`), rate: 0}))
+	e := &telemetryEvent{name: "create", tags: map[string]string{"module_source": "foo", "resource_id": "id"}}
+	assert.True(t, stage.process(context.Background(), e))
+}
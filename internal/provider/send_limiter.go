@@ -0,0 +1,161 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+const (
+	// queueOverflowBlock makes new events wait for room in the queue.
+	queueOverflowBlock = "block"
+	// queueOverflowDropOldest drops the event that has been waiting for the longest time to make room for the new one.
+	queueOverflowDropOldest = "drop_oldest"
+	// queueOverflowDropNewest drops the new event.
+	queueOverflowDropNewest = "drop_newest"
+)
+
+var queueOverflowPolicies = []string{queueOverflowBlock, queueOverflowDropOldest, queueOverflowDropNewest}
+
+var errSendDropped = errors.New("telemetry event dropped since send queue is full")
+
+// sendLimiter limits the number of telemetry requests that are in flight at the same time, the excess
+// requests wait in a queue until a slot is released, high priority events are granted a slot before low
+// priority ones. When queueSize is positive, the queue is bounded: low priority events are shed first when
+// it's full, then overflowPolicy decides what happens. A nil *sendLimiter doesn't limit anything.
+type sendLimiter struct {
+	mu             sync.Mutex
+	available      int64
+	queue          []*sendWaiter
+	queueSize      int
+	overflowPolicy string
+}
+
+// sendWaiter is an event waiting in the queue, ready receives true once a slot is granted to it, or
+// false if it has been dropped.
+type sendWaiter struct {
+	ready        chan bool
+	highPriority bool
+}
+
+func newSendLimiter(maxConcurrentSends int64, queueSize int64, overflowPolicy string) *sendLimiter {
+	if maxConcurrentSends <= 0 {
+		return nil
+	}
+	if overflowPolicy == "" {
+		overflowPolicy = queueOverflowBlock
+	}
+	return &sendLimiter{
+		available:      maxConcurrentSends,
+		queueSize:      int(queueSize),
+		overflowPolicy: overflowPolicy,
+	}
+}
+
+// acquire waits for a free slot. It returns errSendDropped if the event has been dropped since the queue
+// is full, or ctx's error if ctx is done before a slot is available.
+func (l *sendLimiter) acquire(ctx context.Context, highPriority bool) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	if l.available > 0 && len(l.queue) == 0 {
+		l.available--
+		l.mu.Unlock()
+		return nil
+	}
+	if l.queueSize > 0 && len(l.queue) >= l.queueSize && !l.shed(highPriority) {
+		l.mu.Unlock()
+		return errSendDropped
+	}
+	w := &sendWaiter{ready: make(chan bool, 1), highPriority: highPriority}
+	l.queue = append(l.queue, w)
+	l.mu.Unlock()
+
+	select {
+	case granted := <-w.ready:
+		if !granted {
+			return errSendDropped
+		}
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.removeWaiter(w) {
+			return ctx.Err()
+		}
+		// The waiter has been granted a slot or dropped right before it's removed.
+		if <-w.ready {
+			l.releaseLocked()
+		}
+		return ctx.Err()
+	}
+}
+
+// release frees the slot taken by a previous successful acquire, the slot is handed over to the first
+// waiting event if there is any.
+func (l *sendLimiter) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.releaseLocked()
+}
+
+func (l *sendLimiter) releaseLocked() {
+	if len(l.queue) == 0 {
+		l.available++
+		return
+	}
+	next := l.oldestWaiter(true)
+	if next == nil {
+		next = l.queue[0]
+	}
+	l.removeWaiter(next)
+	next.ready <- true
+}
+
+// shed makes room in the full queue for a new event, it returns false if the new event should be dropped
+// instead. Low priority events are shed first: a low priority event never waits beyond the queue size, and
+// a high priority event takes the place of the oldest low priority one. Otherwise overflowPolicy applies.
+func (l *sendLimiter) shed(highPriority bool) bool {
+	victim := l.oldestWaiter(false)
+	switch {
+	case victim != nil && (highPriority || l.overflowPolicy == queueOverflowDropOldest):
+	case !highPriority:
+		return false
+	case l.overflowPolicy == queueOverflowDropOldest:
+		victim = l.queue[0]
+	case l.overflowPolicy == queueOverflowDropNewest:
+		return false
+	default:
+		return true
+	}
+	l.removeWaiter(victim)
+	victim.ready <- false
+	return true
+}
+
+// oldestWaiter returns the waiter that has been waiting for the longest time with the given priority, or nil.
+func (l *sendLimiter) oldestWaiter(highPriority bool) *sendWaiter {
+	for _, w := range l.queue {
+		if w.highPriority == highPriority {
+			return w
+		}
+	}
+	return nil
+}
+
+func (l *sendLimiter) removeWaiter(w *sendWaiter) bool {
+	for i, waiter := range l.queue {
+		if waiter == w {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendLimiter_limitsConcurrentSends(t *testing.T) {
+	l := newSendLimiter(2, 0, "")
+	var inFlight, maxInFlight, sent int32
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.acquire(context.Background(), true) != nil {
+				return
+			}
+			defer l.release()
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if current <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			atomic.AddInt32(&sent, 1)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(10), sent)
+	assert.Equal(t, int32(2), maxInFlight)
+}
+
+func TestSendLimiter_acquireHonorsContextCancellation(t *testing.T) {
+	l := newSendLimiter(1, 0, "")
+	assert.NoError(t, l.acquire(context.Background(), true))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.acquire(ctx, true), context.DeadlineExceeded)
+	assert.Empty(t, l.queue)
+	l.release()
+	assert.NoError(t, l.acquire(context.Background(), true))
+}
+
+func TestSendLimiter_queueOverflowPolicies(t *testing.T) {
+	cases := []struct {
+		policy          string
+		expectedDropped []int
+	}{
+		{policy: queueOverflowDropNewest, expectedDropped: []int{2}},
+		{policy: queueOverflowDropOldest, expectedDropped: []int{0}},
+		{policy: queueOverflowBlock, expectedDropped: nil},
+	}
+	for _, c := range cases {
+		t.Run(c.policy, func(t *testing.T) {
+			l := newSendLimiter(1, 2, c.policy)
+			assert.NoError(t, l.acquire(context.Background(), true))
+			results := make([]chan error, 3)
+			for i := range results {
+				results[i] = make(chan error, 1)
+				go func(result chan error) {
+					err := l.acquire(context.Background(), true)
+					if err == nil {
+						l.release()
+					}
+					result <- err
+				}(results[i])
+				// make sure the events are queued in order
+				assert.Eventually(t, func() bool {
+					l.mu.Lock()
+					defer l.mu.Unlock()
+					queued := len(l.queue)
+					for _, result := range results[:i+1] {
+						queued += len(result)
+					}
+					return queued == i+1
+				}, time.Second, time.Millisecond)
+			}
+			l.release()
+			var dropped []int
+			for i, result := range results {
+				if err := <-result; err != nil {
+					assert.ErrorIs(t, err, errSendDropped)
+					dropped = append(dropped, i)
+				}
+			}
+			assert.Equal(t, c.expectedDropped, dropped)
+		})
+	}
+}
+
+func TestSendLimiter_nilLimiterIsUnlimited(t *testing.T) {
+	l := newSendLimiter(0, 0, "")
+	assert.Nil(t, l)
+	assert.NoError(t, l.acquire(context.Background(), true))
+	l.release()
+}
+
+func TestSendLimiter_lowPriorityEventsAreShedFirst(t *testing.T) {
+	cases := []struct {
+		desc            string
+		policy          string
+		priorities      []bool
+		expectedDropped []int
+		expectedOrder   []int
+	}{
+		{
+			desc:            "high priority event takes the place of low priority one",
+			policy:          queueOverflowDropNewest,
+			priorities:      []bool{false, true, true},
+			expectedDropped: []int{0},
+			expectedOrder:   []int{1, 2},
+		},
+		{
+			desc:            "low priority event is dropped when queue is full of high priority events",
+			policy:          queueOverflowBlock,
+			priorities:      []bool{true, true, false},
+			expectedDropped: []int{2},
+			expectedOrder:   []int{0, 1},
+		},
+		{
+			desc:            "block policy queues high priority event beyond the size",
+			policy:          queueOverflowBlock,
+			priorities:      []bool{true, true, true},
+			expectedDropped: nil,
+			expectedOrder:   []int{0, 1, 2},
+		},
+		{
+			desc:            "high priority events are sent first",
+			policy:          queueOverflowDropOldest,
+			priorities:      []bool{false, true},
+			expectedDropped: nil,
+			expectedOrder:   []int{1, 0},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			l := newSendLimiter(1, 2, c.policy)
+			assert.NoError(t, l.acquire(context.Background(), true))
+			var mu sync.Mutex
+			var order []int
+			results := make([]chan error, len(c.priorities))
+			for i, highPriority := range c.priorities {
+				results[i] = make(chan error, 1)
+				go func(i int, highPriority bool, result chan error) {
+					err := l.acquire(context.Background(), highPriority)
+					if err == nil {
+						mu.Lock()
+						order = append(order, i)
+						mu.Unlock()
+						l.release()
+					}
+					result <- err
+				}(i, highPriority, results[i])
+				assert.Eventually(t, func() bool {
+					l.mu.Lock()
+					defer l.mu.Unlock()
+					queued := len(l.queue)
+					for _, result := range results[:i+1] {
+						queued += len(result)
+					}
+					return queued == i+1
+				}, time.Second, time.Millisecond)
+			}
+			l.release()
+			var dropped []int
+			for i, result := range results {
+				if err := <-result; err != nil {
+					assert.ErrorIs(t, err, errSendDropped)
+					dropped = append(dropped, i)
+				}
+			}
+			assert.Equal(t, c.expectedDropped, dropped)
+			assert.Equal(t, c.expectedOrder, order)
+		})
+	}
+}
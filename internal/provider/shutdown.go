@@ -0,0 +1,34 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	shutdownMu    sync.Mutex
+	shutdownHooks []func(ctx context.Context)
+)
+
+// registerShutdownHook registers a function that runs when the provider process is about to exit, e.g. to
+// flush events that are still buffered.
+func registerShutdownHook(hook func(ctx context.Context)) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, hook)
+}
+
+// Shutdown runs the registered shutdown hooks in reverse order of registration. It's called once the provider
+// server has stopped, Terraform kills the provider shortly after, so ctx should carry a short deadline.
+func Shutdown(ctx context.Context) {
+	shutdownMu.Lock()
+	hooks := shutdownHooks
+	shutdownHooks = nil
+	shutdownMu.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i](ctx)
+	}
+}
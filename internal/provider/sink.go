@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	sinkHttp   = "http"
+	sinkFile   = "file"
+	sinkStdout = "stdout"
+	sinkStderr = "stderr"
+	// sinkEnv sets `sink` when it's not set in the provider block.
+	sinkEnv = "MODTM_SINK"
+)
+
+// sinks are the possible values of `sink`.
+var sinks = []string{sinkHttp, sinkFile, sinkStdout, sinkStderr}
+
+// eventSink receives the tags of every telemetry event locally, in addition to or instead of the endpoint.
+type eventSink interface {
+	write(tags map[string]string) error
+}
+
+// newEventSink returns the local sink of the `sink` mode, nil if events are not written locally.
+func newEventSink(sink, sinkPath string) eventSink {
+	switch sink {
+	case sinkStdout:
+		return &writerSink{w: os.Stdout}
+	case sinkStderr:
+		return &writerSink{w: os.Stderr}
+	}
+	if s := newFileSink(sinkPath); s != nil {
+		return s
+	}
+	return nil
+}
+
+var _ eventSink = &writerSink{}
+
+// writerSink prints telemetry events to a stream as JSON lines, so the payload could be checked without an endpoint.
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *writerSink) write(tags map[string]string) error {
+	line, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
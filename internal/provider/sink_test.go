@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterSink_printsJsonLines(t *testing.T) {
+	buf := &bytes.Buffer{}
+	s := &writerSink{w: buf}
+	require.NoError(t, s.write(map[string]string{"event": "create"}))
+	require.NoError(t, s.write(map[string]string{"event": "delete"}))
+	assert.Equal(t, "{\"event\":\"create\"}\n{\"event\":\"delete\"}\n", buf.String())
+}
+
+func TestNewEventSink(t *testing.T) {
+	sinkPath := filepath.Join(t.TempDir(), "events.jsonl")
+	assert.Nil(t, newEventSink(sinkHttp, ""))
+	assert.Nil(t, newEventSink("", ""))
+	assert.Equal(t, &writerSink{w: os.Stdout}, newEventSink(sinkStdout, sinkPath))
+	assert.Equal(t, &writerSink{w: os.Stderr}, newEventSink(sinkStderr, ""))
+	assert.Equal(t, newFileSink(sinkPath), newEventSink(sinkFile, sinkPath))
+	assert.Equal(t, newFileSink(sinkPath), newEventSink(sinkHttp, sinkPath))
+}
+
+func TestIsOffline(t *testing.T) {
+	for sink, expected := range map[string]bool{"": false, sinkHttp: false, sinkFile: true, sinkStdout: true, sinkStderr: true} {
+		data := ModuleTelemetryProviderModel{}
+		if sink != "" {
+			data.Sink = types.StringValue(sink)
+		}
+		assert.Equal(t, expected, data.isOffline(), sink)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// spoolMaxAge is how long a spooled event is kept, older events are discarded without being sent.
+	spoolMaxAge = 7 * 24 * time.Hour
+	// spoolClaimTimeout is how long a spooled event claimed by a provider process is left alone, after that
+	// the process is assumed to have died before it could finish.
+	spoolClaimTimeout = time.Hour
+	spoolClaimSuffix  = ".sending"
+	// replayedTag marks the events sent again from the spool.
+	replayedTag = "replayed"
+)
+
+// spooledEvent is a telemetry event that could not be sent, persisted so a later provider run could send it.
+type spooledEvent struct {
+	Endpoint  string            `json:"endpoint"`
+	Tags      map[string]string `json:"tags"`
+	SpooledAt time.Time         `json:"spooled_at"`
+}
+
+// eventSpool persists failed events as files in dir, one event per file. A nil *eventSpool does nothing.
+type eventSpool struct {
+	dir string
+}
+
+func newEventSpool(dir string) *eventSpool {
+	if dir == "" {
+		return nil
+	}
+	return &eventSpool{dir: dir}
+}
+
+// write persists the event, the file is written under a temporary name first so a partially written event
+// is never replayed.
+func (s *eventSpool) write(endpoint string, tags map[string]string) error {
+	if s == nil {
+		return nil
+	}
+	content, err := json.Marshal(spooledEvent{Endpoint: endpoint, Tags: tags, SpooledAt: timeNow().UTC()})
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	name := filepath.Join(s.dir, uuid.NewString()+".json")
+	if err = os.WriteFile(name+".tmp", content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+// replay sends the spooled events again with client, which must send events immediately so an event is only removed
+// once it's delivered, or expired. Every event is claimed by renaming its file first, so concurrent provider
+// processes don't send the same event twice. Since the files could be tampered with, events to malformed endpoints,
+// or to insecure ones unless allowInsecureEndpoint is set, are discarded.
+func (s *eventSpool) replay(ctx context.Context, client telemetryClient, allowInsecureEndpoint bool) {
+	if s == nil {
+		return
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := filepath.Join(s.dir, entry.Name())
+		claimed := name + spoolClaimSuffix
+		switch {
+		case strings.HasSuffix(name, ".json"):
+			if os.Rename(name, claimed) != nil {
+				continue
+			}
+		case strings.HasSuffix(name, ".json"+spoolClaimSuffix):
+			info, err := entry.Info()
+			if err != nil || timeNow().Sub(info.ModTime()) < spoolClaimTimeout {
+				continue
+			}
+			claimed = name
+			name = strings.TrimSuffix(name, spoolClaimSuffix)
+		default:
+			continue
+		}
+		s.replayFile(ctx, client, name, claimed, allowInsecureEndpoint)
+	}
+}
+
+func (s *eventSpool) replayFile(ctx context.Context, client telemetryClient, name, claimed string, allowInsecureEndpoint bool) {
+	content, err := os.ReadFile(filepath.Clean(claimed))
+	if err != nil {
+		return
+	}
+	var e spooledEvent
+	if err = json.Unmarshal(content, &e); err != nil || e.Tags == nil {
+		traceLog(ctx, fmt.Sprintf("discard invalid spooled telemetry event %s", name))
+		_ = os.Remove(claimed)
+		return
+	}
+	if timeNow().Sub(e.SpooledAt) > spoolMaxAge {
+		traceLog(ctx, fmt.Sprintf("discard expired spooled %s telemetry event", e.Tags["event"]))
+		_ = os.Remove(claimed)
+		return
+	}
+	if endpoint, err := configuredEndpointPolicy.validate(e.Endpoint); err != nil || endpoint != e.Endpoint || (isInsecureEndpoint(endpoint) && !allowInsecureEndpoint) {
+		traceLog(ctx, fmt.Sprintf("discard spooled %s telemetry event to invalid endpoint %q", e.Tags["event"], e.Endpoint))
+		_ = os.Remove(claimed)
+		return
+	}
+	e.Tags[replayedTag] = "true"
+	if err = client.send(ctx, e.Endpoint, e.Tags); err != nil {
+		_ = os.Rename(claimed, name)
+		return
+	}
+	traceLog(ctx, fmt.Sprintf("replayed spooled %s telemetry event", e.Tags["event"]))
+	_ = os.Remove(claimed)
+}
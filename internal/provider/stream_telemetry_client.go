@@ -0,0 +1,191 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"sync"
+)
+
+const (
+	transportHttp   = "http"
+	transportStream = "stream"
+	transportBatch  = "batch"
+)
+
+var transports = []string{transportHttp, transportStream, transportBatch}
+
+var errStreamClosed = errors.New("telemetry stream closed by endpoint")
+
+var _ queuingClient = &streamTelemetryClient{}
+
+// streamTelemetryClient keeps one long-running HTTP POST request open per endpoint and streams events
+// through its body as newline delimited JSON, avoiding per-event HTTP overhead in high-volume runs. A new
+// stream is opened when the previous one is closed by the endpoint or broken. Events are only delivered once the
+// endpoint has responded the request with a 2xx status, until then they're queued.
+type streamTelemetryClient struct {
+	*httpTelemetryClient
+	mu      sync.Mutex
+	streams map[string]*eventStream
+}
+
+// eventStream is an open streaming request, done is closed once the request has finished. events are the events
+// written to the stream, guarded by the mutex of the client.
+type eventStream struct {
+	w      *io.PipeWriter
+	done   chan struct{}
+	events []streamedEvent
+}
+
+// streamedEvent is an event written to a stream and the handler of its delivery failure, if any.
+type streamedEvent struct {
+	ctx       context.Context
+	tags      map[string]string
+	onFailure deliveryFailureHandler
+}
+
+func newStreamTelemetryClient(client *http.Client) *streamTelemetryClient {
+	return &streamTelemetryClient{
+		httpTelemetryClient: newHttpTelemetryClient(client, payloadEncodingJSON),
+		streams:             make(map[string]*eventStream),
+	}
+}
+
+// send writes the tags as a line into the endpoint's stream.
+func (s *streamTelemetryClient) send(ctx context.Context, endpoint string, tags map[string]string) error {
+	return s.enqueue(ctx, endpoint, tags, nil)
+}
+
+// enqueue writes the tags as a line into the endpoint's stream. onFailure is called once the stream ends without a
+// 2xx status, or right away when the line cannot be written, in which case the error is returned too.
+func (s *streamTelemetryClient) enqueue(ctx context.Context, endpoint string, tags map[string]string, onFailure deliveryFailureHandler) error {
+	err := s.write(ctx, endpoint, tags, onFailure)
+	if err != nil && onFailure != nil {
+		onFailure(context.WithoutCancel(ctx), s.httpTelemetryClient, err)
+	}
+	return err
+}
+
+// write writes the tags as a line into the endpoint's stream, writes are serialized since they share one request
+// body.
+func (s *streamTelemetryClient) write(ctx context.Context, endpoint string, tags map[string]string, onFailure deliveryFailureHandler) error {
+	line, err := json.Marshal(payload(s.format, tags))
+	if err != nil {
+		errorLog(ctx, fmt.Sprintf("error on unmarshal telemetry resource: %s", err.Error()))
+		return err
+	}
+	event := tags["event"]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stream, err := s.stream(ctx, endpoint)
+	if err != nil {
+		errorLog(ctx, fmt.Sprintf("error on opening telemetry stream to %s: %+v", endpoint, err))
+		return err
+	}
+	sendCtx, cancel := withSendTimeout(ctx)
+	defer cancel()
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := stream.w.Write(append(line, '\n'))
+		errChan <- err
+	}()
+	select {
+	case err = <-errChan:
+	case <-sendCtx.Done():
+		err = fmt.Errorf("timeout on %s telemetry resource", event)
+		_ = stream.w.CloseWithError(err)
+	}
+	if err != nil {
+		errorLog(ctx, fmt.Sprintf("error on streaming %s telemetry resource: %+v", event, err))
+		delete(s.streams, endpoint)
+		return err
+	}
+	stream.events = append(stream.events, streamedEvent{ctx: context.WithoutCancel(ctx), tags: maps.Clone(tags), onFailure: onFailure})
+	traceLog(ctx, fmt.Sprintf("streamed %s telemetry event to %s", event, endpoint))
+	return nil
+}
+
+// stream returns the open stream of the endpoint, or opens a new one. s.mu must be held.
+func (s *streamTelemetryClient) stream(ctx context.Context, endpoint string) (*eventStream, error) {
+	if stream, ok := s.streams[endpoint]; ok {
+		select {
+		case <-stream.done:
+		default:
+			return stream, nil
+		}
+	}
+	r, w := io.Pipe()
+	req, err := http.NewRequest("POST", endpoint, r)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.setHeaders(ctx, req); err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	stream := &eventStream{w: w, done: make(chan struct{})}
+	go func() {
+		defer close(stream.done)
+		err := s.finish(ctx, endpoint, req)
+		// Unblock pending writes if the endpoint responded before the stream is closed by the provider.
+		_ = r.CloseWithError(errors.Join(errStreamClosed, err))
+		s.mu.Lock()
+		events := stream.events
+		stream.events = nil
+		s.mu.Unlock()
+		if err == nil {
+			traceLog(ctx, fmt.Sprintf("delivered %d telemetry events through stream to %s", len(events), endpoint))
+			return
+		}
+		errorLog(ctx, fmt.Sprintf("error on streaming %d telemetry events to %s: %+v", len(events), endpoint, err))
+		for _, e := range events {
+			if e.onFailure != nil {
+				e.onFailure(e.ctx, s.httpTelemetryClient, err)
+			}
+		}
+	}()
+	s.streams[endpoint] = stream
+	traceLog(ctx, fmt.Sprintf("opened telemetry stream to %s", endpoint))
+	return stream, nil
+}
+
+// finish sends the streaming request and waits for the endpoint to respond, the events of the stream are only
+// delivered when the endpoint responds a 2xx status.
+func (s *streamTelemetryClient) finish(ctx context.Context, endpoint string, req *http.Request) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	traceLog(ctx, fmt.Sprintf("telemetry stream to %s closed with status: %s", endpoint, resp.Status))
+	closeBody(resp)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return &endpointStatusError{status: resp.StatusCode}
+	}
+	return nil
+}
+
+// close ends all streams and waits for the endpoints to respond until ctx is done.
+func (s *streamTelemetryClient) close(ctx context.Context) {
+	s.mu.Lock()
+	streams := s.streams
+	s.streams = make(map[string]*eventStream)
+	s.mu.Unlock()
+	for _, stream := range streams {
+		_ = stream.w.Close()
+	}
+	for endpoint, stream := range streams {
+		select {
+		case <-stream.done:
+		case <-ctx.Done():
+			traceLog(ctx, fmt.Sprintf("timeout on closing telemetry stream to %s", endpoint))
+			return
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamServer struct {
+	s        *httptest.Server
+	mu       sync.Mutex
+	requests int
+	events   []string
+	// maxEvents closes the stream after the given number of events when it's positive.
+	maxEvents int
+}
+
+func newStreamServer(maxEvents int) *streamServer {
+	ss := &streamServer{maxEvents: maxEvents}
+	ss.s = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ss.mu.Lock()
+		ss.requests++
+		ss.mu.Unlock()
+		// Respond without waiting for the rest of the body when the stream is closed by the server.
+		_ = http.NewResponseController(writer).EnableFullDuplex()
+		scanner := bufio.NewScanner(request.Body)
+		read := 0
+		for scanner.Scan() {
+			var tags map[string]string
+			_ = json.Unmarshal(scanner.Bytes(), &tags)
+			ss.mu.Lock()
+			ss.events = append(ss.events, tags["event"])
+			ss.mu.Unlock()
+			read++
+			if ss.maxEvents > 0 && read >= ss.maxEvents {
+				return
+			}
+		}
+	}))
+	return ss
+}
+
+func (ss *streamServer) received() (int, []string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.requests, append([]string(nil), ss.events...)
+}
+
+func TestStreamTelemetryClient_streamsEventsThroughOneRequest(t *testing.T) {
+	ss := newStreamServer(0)
+	defer ss.s.Close()
+	client := newStreamTelemetryClient(http.DefaultClient)
+	for _, event := range []string{"create", "read", "update", "delete"} {
+		require.NoError(t, client.send(context.Background(), ss.s.URL, map[string]string{"event": event}))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.close(ctx)
+
+	requests, events := ss.received()
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, []string{"create", "read", "update", "delete"}, events)
+}
+
+func TestStreamTelemetryClient_reopensClosedStream(t *testing.T) {
+	ss := newStreamServer(1)
+	defer ss.s.Close()
+	client := newStreamTelemetryClient(http.DefaultClient)
+	require.NoError(t, client.send(context.Background(), ss.s.URL, map[string]string{"event": "create"}))
+	assert.Eventually(t, func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		select {
+		case <-client.streams[ss.s.URL].done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, client.send(context.Background(), ss.s.URL, map[string]string{"event": "delete"}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.close(ctx)
+
+	requests, events := ss.received()
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, []string{"create", "delete"}, events)
+}
+
+func TestShutdown_runsHooksInReverseOrder(t *testing.T) {
+	var order []int
+	registerShutdownHook(func(ctx context.Context) { order = append(order, 1) })
+	registerShutdownHook(func(ctx context.Context) { order = append(order, 2) })
+	Shutdown(context.Background())
+	assert.Equal(t, []int{2, 1}, order)
+	Shutdown(context.Background())
+	assert.Equal(t, []int{2, 1}, order)
+}
+
+func TestStreamTelemetryClient_rejectedStreamRunsFailureHandlers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = io.Copy(io.Discard, request.Body)
+		writer.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	client := newStreamTelemetryClient(http.DefaultClient)
+	var mu sync.Mutex
+	var failed []string
+	for _, event := range []string{"create", "delete"} {
+		require.NoError(t, client.enqueue(context.Background(), server.URL, map[string]string{"event": event}, func(ctx context.Context, client telemetryClient, err error) {
+			assert.NotNil(t, client)
+			var statusErr *endpointStatusError
+			assert.ErrorAs(t, err, &statusErr)
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, event)
+		}))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.close(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"create", "delete"}, failed)
+}
+
+func TestStreamTelemetryClient_deliveredStreamSkipsFailureHandlers(t *testing.T) {
+	ss := newStreamServer(0)
+	defer ss.s.Close()
+	client := newStreamTelemetryClient(http.DefaultClient)
+	require.NoError(t, client.enqueue(context.Background(), ss.s.URL, map[string]string{"event": "delete"}, func(ctx context.Context, client telemetryClient, err error) {
+		assert.Fail(t, "unexpected delivery failure", err.Error())
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.close(ctx)
+
+	_, events := ss.received()
+	assert.Equal(t, []string{"delete"}, events)
+}
@@ -0,0 +1,210 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// systemProxyLookup returns the proxy configured at OS level for the given URL, nil means a direct connection.
+// The implementation is platform specific and is a variable so tests could stub it.
+var systemProxyLookup = lookupSystemProxy
+
+// proxyEnvVars are the environment variables honored by httpproxy.FromEnvironment.
+var proxyEnvVars = []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"}
+
+// proxySettings are the proxy settings of the provider.
+type proxySettings struct {
+	// url is the proxy of all outgoing requests when it's set, it wins over environment variables and OS-level settings.
+	url *url.URL
+	// detectSystemProxy consults OS-level proxy settings when no proxy environment variable is set.
+	detectSystemProxy bool
+	// credentials authenticate against proxies whose address doesn't carry credentials, nil if there's none.
+	credentials *url.Userinfo
+}
+
+// newProxyFunc returns the proxy function for outgoing requests. An explicit proxy URL always wins, then proxy
+// environment variables, OS-level proxy settings are only consulted when none of them is set and
+// detectSystemProxy is true.
+func newProxyFunc(settings proxySettings) func(*http.Request) (*url.URL, error) {
+	proxyFunc := resolveProxyFunc(settings.detectSystemProxy)
+	if settings.url != nil {
+		proxyFunc = func(*http.Request) (*url.URL, error) {
+			return settings.url, nil
+		}
+	}
+	if settings.credentials == nil {
+		return proxyFunc
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		proxy, err := proxyFunc(req)
+		if err != nil || proxy == nil || proxy.User != nil {
+			return proxy, err
+		}
+		// The transport sends the proxy URL's credentials as a basic Proxy-Authorization header, both on plain
+		// requests and on CONNECT for https endpoints, or uses them for SOCKS5 username/password authentication.
+		// Copy the URL since it's shared across requests.
+		authenticated := *proxy
+		authenticated.User = settings.credentials
+		return &authenticated, nil
+	}
+}
+
+func resolveProxyFunc(detectSystemProxy bool) func(*http.Request) (*url.URL, error) {
+	if !detectSystemProxy || proxyEnvSet() {
+		envProxy := httpproxy.FromEnvironment().ProxyFunc()
+		return func(req *http.Request) (*url.URL, error) {
+			return envProxy(req.URL)
+		}
+	}
+	cache := sync.Map{}
+	return func(req *http.Request) (*url.URL, error) {
+		key := req.URL.Scheme + "://" + req.URL.Host
+		if proxy, ok := cache.Load(key); ok {
+			return proxy.(*url.URL), nil
+		}
+		proxy, err := systemProxyLookup(req.URL)
+		if err != nil {
+			// A broken system proxy configuration shouldn't stop telemetry, try a direct connection.
+			proxy = nil
+		}
+		cache.Store(key, proxy)
+		return proxy, nil
+	}
+}
+
+// proxyEnv is the environment variable that sets the proxy of all outgoing requests of the provider, like `proxy_url`.
+const proxyEnv = "MODTM_PROXY"
+
+// proxyURLSchemes are the proxy protocols supported by the transport.
+var proxyURLSchemes = []string{"http", "https", "socks5"}
+
+// parseExplicitProxyURL parses `proxy_url` or MODTM_PROXY, it returns nil when raw is empty.
+func parseExplicitProxyURL(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(proxyURLSchemes, u.Scheme) || u.Host == "" {
+		return nil, fmt.Errorf("proxy URL %q must be an absolute URL with one of the schemes %s", u.Redacted(), strings.Join(proxyURLSchemes, ", "))
+	}
+	return u, nil
+}
+
+func proxyEnvSet() bool {
+	for _, env := range proxyEnvVars {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// staticProxySettings are manually configured OS-level proxy settings.
+type staticProxySettings struct {
+	httpProxy  string
+	httpsProxy string
+	// bypass are the hosts that should be connected directly, entries could be host names with `*` wildcards,
+	// IP ranges in CIDR notation, or `<local>` for simple host names.
+	bypass []string
+}
+
+// proxyFor returns the proxy for u, or nil if u should be connected directly.
+func (s staticProxySettings) proxyFor(u *url.URL) (*url.URL, error) {
+	proxy := s.httpProxy
+	if u.Scheme == "https" {
+		proxy = s.httpsProxy
+	}
+	if proxy == "" || s.bypassed(u.Hostname()) {
+		return nil, nil
+	}
+	return parseProxyURL(proxy)
+}
+
+func (s staticProxySettings) bypassed(host string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range s.bypass {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "<local>":
+			if ip == nil && !strings.Contains(host, ".") {
+				return true
+			}
+		case strings.Contains(entry, "/"):
+			if _, cidr, err := net.ParseCIDR(expandShortCIDR(entry)); err == nil && ip != nil && cidr.Contains(ip) {
+				return true
+			}
+		default:
+			if host == entry || strings.HasSuffix(host, "."+strings.TrimPrefix(entry, ".")) {
+				return true
+			}
+			if matched, _ := path.Match(entry, host); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expandShortCIDR expands IPv4 ranges with omitted octets like `169.254/16`, which are accepted by macOS.
+func expandShortCIDR(cidr string) string {
+	ip, bits, _ := strings.Cut(cidr, "/")
+	if strings.Contains(ip, ":") {
+		return cidr
+	}
+	for strings.Count(ip, ".") < 3 {
+		ip += ".0"
+	}
+	return ip + "/" + bits
+}
+
+// parseProxyURL parses proxy addresses as they are stored by the OS, usually `host:port` without scheme.
+func parseProxyURL(proxy string) (*url.URL, error) {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	return url.Parse(proxy)
+}
+
+// parseWindowsProxyList parses proxy lists in the format of WinHTTP and Internet Options, which is either a
+// single `host:port` for all protocols or per protocol entries like `http=host:port;https=host:port`.
+func parseWindowsProxyList(proxyList string, bypassList string) staticProxySettings {
+	s := staticProxySettings{}
+	for _, entry := range strings.FieldsFunc(proxyList, func(r rune) bool { return r == ';' || r == ' ' }) {
+		scheme, proxy, found := strings.Cut(entry, "=")
+		if !found {
+			if s.httpProxy == "" {
+				s.httpProxy = entry
+			}
+			if s.httpsProxy == "" {
+				s.httpsProxy = entry
+			}
+			continue
+		}
+		switch strings.ToLower(scheme) {
+		case "http":
+			s.httpProxy = proxy
+		case "https":
+			s.httpsProxy = proxy
+		}
+	}
+	s.bypass = strings.FieldsFunc(bypassList, func(r rune) bool { return r == ';' || r == ' ' })
+	return s
+}
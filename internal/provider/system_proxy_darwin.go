@@ -0,0 +1,30 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build darwin
+
+package provider
+
+import (
+	"net/url"
+	"os/exec"
+	"sync"
+)
+
+var scutilProxySettings = sync.OnceValues(func() (staticProxySettings, error) {
+	output, err := exec.Command("/usr/sbin/scutil", "--proxy").Output()
+	if err != nil {
+		return staticProxySettings{}, err
+	}
+	return parseScutilProxy(string(output)), nil
+})
+
+// lookupSystemProxy reads the proxy settings from SCDynamicStore through `scutil`, so the provider doesn't
+// require cgo.
+func lookupSystemProxy(u *url.URL) (*url.URL, error) {
+	settings, err := scutilProxySettings()
+	if err != nil {
+		return nil, err
+	}
+	return settings.proxyFor(u)
+}
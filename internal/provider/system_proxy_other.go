@@ -0,0 +1,13 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !windows && !darwin
+
+package provider
+
+import "net/url"
+
+// lookupSystemProxy returns nil since there's no OS-level proxy settings besides environment variables.
+func lookupSystemProxy(*url.URL) (*url.URL, error) {
+	return nil, nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bufio"
+	"strings"
+)
+
+// parseScutilProxy parses the output of `scutil --proxy`, which dumps the proxy settings of macOS's
+// SCDynamicStore. Proxy auto-config is not supported and is ignored.
+func parseScutilProxy(output string) staticProxySettings {
+	values := make(map[string]string)
+	s := staticProxySettings{}
+	inExceptions := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if inExceptions {
+			if line == "}" {
+				inExceptions = false
+				continue
+			}
+			if _, exception, found := strings.Cut(line, " : "); found {
+				s.bypass = append(s.bypass, exception)
+			}
+			continue
+		}
+		key, value, found := strings.Cut(line, " : ")
+		if !found {
+			continue
+		}
+		if key == "ExceptionsList" {
+			inExceptions = true
+			continue
+		}
+		values[key] = value
+	}
+	proxy := func(protocol string) string {
+		if values[protocol+"Enable"] != "1" || values[protocol+"Proxy"] == "" {
+			return ""
+		}
+		if port := values[protocol+"Port"]; port != "" {
+			return values[protocol+"Proxy"] + ":" + port
+		}
+		return values[protocol+"Proxy"]
+	}
+	s.httpProxy = proxy("HTTP")
+	s.httpsProxy = proxy("HTTPS")
+	if values["ExcludeSimpleHostnames"] == "1" {
+		s.bypass = append(s.bypass, "<local>")
+	}
+	return s
+}
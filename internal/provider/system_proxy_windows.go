@@ -0,0 +1,139 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build windows
+
+package provider
+
+import (
+	"net/url"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	winHttpAccessTypeNoProxy    = 1
+	winHttpAccessTypeNamedProxy = 3
+	winHttpAutoProxyAutoDetect  = 0x1
+	winHttpAutoProxyConfigUrl   = 0x2
+	winHttpAutoDetectTypeDhcp   = 0x1
+	winHttpAutoDetectTypeDnsA   = 0x2
+)
+
+var (
+	winHttp                                 = windows.NewLazySystemDLL("winhttp.dll")
+	procWinHttpOpen                         = winHttp.NewProc("WinHttpOpen")
+	procWinHttpCloseHandle                  = winHttp.NewProc("WinHttpCloseHandle")
+	procWinHttpGetProxyForUrl               = winHttp.NewProc("WinHttpGetProxyForUrl")
+	procWinHttpGetIEProxyConfigForUser      = winHttp.NewProc("WinHttpGetIEProxyConfigForCurrentUser")
+	procWinHttpGetDefaultProxyConfiguration = winHttp.NewProc("WinHttpGetDefaultProxyConfiguration")
+	procGlobalFree                          = windows.NewLazySystemDLL("kernel32.dll").NewProc("GlobalFree")
+)
+
+// winHttpCurrentUserIEProxyConfig is WINHTTP_CURRENT_USER_IE_PROXY_CONFIG.
+type winHttpCurrentUserIEProxyConfig struct {
+	autoDetect    int32
+	autoConfigUrl *uint16
+	proxy         *uint16
+	proxyBypass   *uint16
+}
+
+// winHttpAutoProxyOptions is WINHTTP_AUTOPROXY_OPTIONS.
+type winHttpAutoProxyOptions struct {
+	flags                 uint32
+	autoDetectFlags       uint32
+	autoConfigUrl         *uint16
+	reserved              uintptr
+	reserved2             uint32
+	autoLogonIfChallenged int32
+}
+
+// winHttpProxyInfo is WINHTTP_PROXY_INFO.
+type winHttpProxyInfo struct {
+	accessType  uint32
+	proxy       *uint16
+	proxyBypass *uint16
+}
+
+// lookupSystemProxy reads the proxy settings of the current user from Internet Options, evaluating WPAD and
+// PAC files through WinHTTP, then falls back to the machine wide WinHTTP proxy configured by `netsh winhttp`.
+func lookupSystemProxy(u *url.URL) (*url.URL, error) {
+	ie := winHttpCurrentUserIEProxyConfig{}
+	if r, _, err := procWinHttpGetIEProxyConfigForUser.Call(uintptr(unsafe.Pointer(&ie))); r == 0 {
+		return defaultWinHttpProxy(u, err)
+	}
+	defer globalFree(ie.autoConfigUrl, ie.proxy, ie.proxyBypass)
+	if ie.autoDetect != 0 || ie.autoConfigUrl != nil {
+		if proxy, ok := autoProxyForUrl(u, ie.autoDetect != 0, ie.autoConfigUrl); ok {
+			return proxy, nil
+		}
+	}
+	if ie.proxy != nil {
+		return parseWindowsProxyList(windows.UTF16PtrToString(ie.proxy), windows.UTF16PtrToString(ie.proxyBypass)).proxyFor(u)
+	}
+	return defaultWinHttpProxy(u, nil)
+}
+
+// autoProxyForUrl evaluates the PAC file, it returns false when the proxy cannot be decided this way.
+func autoProxyForUrl(u *url.URL, autoDetect bool, autoConfigUrl *uint16) (*url.URL, bool) {
+	session, _, _ := procWinHttpOpen.Call(uintptr(unsafe.Pointer(windows.StringToUTF16Ptr("terraform-provider-modtm"))), winHttpAccessTypeNoProxy, 0, 0, 0)
+	if session == 0 {
+		return nil, false
+	}
+	defer func() {
+		_, _, _ = procWinHttpCloseHandle.Call(session)
+	}()
+	options := winHttpAutoProxyOptions{autoLogonIfChallenged: 1}
+	if autoDetect {
+		options.flags |= winHttpAutoProxyAutoDetect
+		options.autoDetectFlags = winHttpAutoDetectTypeDhcp | winHttpAutoDetectTypeDnsA
+	}
+	if autoConfigUrl != nil {
+		options.flags |= winHttpAutoProxyConfigUrl
+		options.autoConfigUrl = autoConfigUrl
+	}
+	info := winHttpProxyInfo{}
+	target, err := windows.UTF16PtrFromString(u.String())
+	if err != nil {
+		return nil, false
+	}
+	if r, _, _ := procWinHttpGetProxyForUrl.Call(session, uintptr(unsafe.Pointer(target)), uintptr(unsafe.Pointer(&options)), uintptr(unsafe.Pointer(&info))); r == 0 {
+		return nil, false
+	}
+	defer globalFree(info.proxy, info.proxyBypass)
+	if info.accessType != winHttpAccessTypeNamedProxy || info.proxy == nil {
+		return nil, true
+	}
+	// The PAC file could return a list of proxies, only the first one is used.
+	first, _, _ := strings.Cut(strings.ReplaceAll(windows.UTF16PtrToString(info.proxy), " ", ";"), ";")
+	proxy, err := parseProxyURL(first)
+	if err != nil {
+		return nil, false
+	}
+	return proxy, true
+}
+
+func defaultWinHttpProxy(u *url.URL, ieErr error) (*url.URL, error) {
+	info := winHttpProxyInfo{}
+	if r, _, err := procWinHttpGetDefaultProxyConfiguration.Call(uintptr(unsafe.Pointer(&info))); r == 0 {
+		if ieErr != nil {
+			return nil, ieErr
+		}
+		return nil, err
+	}
+	defer globalFree(info.proxy, info.proxyBypass)
+	if info.accessType != winHttpAccessTypeNamedProxy || info.proxy == nil {
+		return nil, nil
+	}
+	return parseWindowsProxyList(windows.UTF16PtrToString(info.proxy), windows.UTF16PtrToString(info.proxyBypass)).proxyFor(u)
+}
+
+func globalFree(ptrs ...*uint16) {
+	for _, p := range ptrs {
+		if p != nil {
+			_, _, _ = procGlobalFree.Call(uintptr(unsafe.Pointer(p)))
+		}
+	}
+}
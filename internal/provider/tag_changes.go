@@ -0,0 +1,53 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+)
+
+// tagChangesTag is the tag carrying the changed tags of an update event.
+const tagChangesTag = "tag_changes"
+
+// tagChange is the previous and the current value of a changed tag, a nil value means the tag didn't exist.
+type tagChange struct {
+	Previous *string `json:"previous,omitempty"`
+	Current  *string `json:"current,omitempty"`
+}
+
+// tagChanges returns the tags that have been added, removed or changed between previous and current, keyed
+// by tag name, so the service could compute upgrade paths like `version` moving from one release to another.
+func tagChanges(previous, current map[string]string) map[string]tagChange {
+	changes := make(map[string]tagChange)
+	for k, v := range previous {
+		if c, ok := current[k]; !ok || c != v {
+			changes[k] = tagChange{Previous: stringPtr(v)}
+		}
+	}
+	for k, v := range current {
+		if p, ok := previous[k]; !ok || p != v {
+			change := changes[k]
+			change.Current = stringPtr(v)
+			changes[k] = change
+		}
+	}
+	return changes
+}
+
+// tagChangesTags returns the extra tags to send with an update event, nil if no tag has been changed.
+func tagChangesTags(previous, current map[string]string) map[string]string {
+	changes := tagChanges(previous, current)
+	if len(changes) == 0 {
+		return nil
+	}
+	content, err := json.Marshal(changes)
+	if err != nil {
+		return nil
+	}
+	return map[string]string{tagChangesTag: string(content)}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
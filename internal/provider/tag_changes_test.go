@@ -0,0 +1,34 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagChanges(t *testing.T) {
+	changes := tagChanges(map[string]string{
+		"module_source": "foo",
+		"version":       "1.0.0",
+		"removed":       "bar",
+	}, map[string]string{
+		"module_source": "foo",
+		"version":       "1.1.0",
+		"added":         "baz",
+	})
+	assert.Equal(t, map[string]tagChange{
+		"version": {Previous: stringPtr("1.0.0"), Current: stringPtr("1.1.0")},
+		"removed": {Previous: stringPtr("bar")},
+		"added":   {Current: stringPtr("baz")},
+	}, changes)
+}
+
+func TestTagChangesTags(t *testing.T) {
+	assert.Nil(t, tagChangesTags(map[string]string{"a": "b"}, map[string]string{"a": "b"}))
+	assert.Equal(t, map[string]string{
+		tagChangesTag: `{"a":{"previous":"b","current":""}}`,
+	}, tagChangesTags(map[string]string{"a": "b"}, map[string]string{"a": ""}))
+}
@@ -0,0 +1,172 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// telemetryClient is how the provider talks to the outside world, it's injected through providerConfig so
+// tests could replace it with a fake.
+type telemetryClient interface {
+	// discoverEndpoint reads the default telemetry endpoint.
+	discoverEndpoint(ctx context.Context) (string, error)
+	// send sends the tags of an event to the endpoint, it returns an error if the event might not have been received.
+	send(ctx context.Context, endpoint string, tags map[string]string) error
+}
+
+var endpointBlobUrl = "https://avmtftelemetrysvc.blob.core.windows.net/blob/endpoint"
+
+const (
+	// sendTimeout is how long the provider waits for the endpoint to respond to a telemetry event, unless it's
+	// overridden by `request_timeout`.
+	sendTimeout = 5 * time.Second
+	// maxRequestTimeout is the longest `request_timeout`, a telemetry event should never hold an apply for long.
+	maxRequestTimeout = 2 * time.Minute
+	// endpointDiscoveryTimeout is how long the provider waits for the default endpoint to be read from the blob.
+	endpointDiscoveryTimeout = 5 * time.Second
+)
+
+// withRequestTimeout bounds the sending of an event by timeout, the clients fall back to sendTimeout when it's not
+// positive.
+func withRequestTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// withSendTimeout bounds ctx by sendTimeout, unless the caller has already set a deadline with withRequestTimeout.
+func withSendTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, sendTimeout)
+}
+
+var _ telemetryClient = &httpTelemetryClient{}
+
+// httpTelemetryClient sends events over HTTP and discovers the default endpoint from a blob.
+type httpTelemetryClient struct {
+	client   *http.Client
+	encoding string
+	// format is the payload format, `legacy` or `v2`, empty means `legacy`.
+	format string
+	// headers are added to every request that sends events, e.g. the credential of a collector.
+	headers http.Header
+	// token is the AAD token source of the collector, nil if requests are not authenticated with AAD.
+	token *aadTokenSource
+	// encodingRejected is set once the endpoint doesn't accept the encoding, JSON is used since then.
+	encodingRejected atomic.Bool
+}
+
+func newHttpTelemetryClient(client *http.Client, encoding string) *httpTelemetryClient {
+	return &httpTelemetryClient{client: client, encoding: encoding}
+}
+
+// discoverEndpoint reads the default endpoint from the blob, the request is cancelled when ctx is done or
+// endpointDiscoveryTimeout has passed.
+func (h *httpTelemetryClient) discoverEndpoint(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, endpointDiscoveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointBlobUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", fmt.Errorf("timeout on reading default endpoint")
+		}
+		return "", err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("default endpoint blob responded %d", resp.StatusCode)
+	}
+	// The discovery document is a single URL, anything much longer is malformed.
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxEndpointLength+1))
+	if err != nil {
+		return "", err
+	}
+	return discoveredEndpointPolicy.validate(string(content))
+}
+
+// send sends an HTTP POST request to the endpoint with the encoded tags as body. When the endpoint rejects
+// a non-JSON encoding with 415 Unsupported Media Type, the event is sent again as JSON, and so are all
+// later events.
+func (h *httpTelemetryClient) send(ctx context.Context, url string, tags map[string]string) error {
+	encoding := h.encoding
+	if h.encodingRejected.Load() {
+		encoding = payloadEncodingJSON
+	}
+	status, err := h.post(ctx, url, tags, encoding)
+	if err == nil && status == http.StatusUnsupportedMediaType && encoding != payloadEncodingJSON {
+		traceLog(ctx, fmt.Sprintf("%s payload is rejected by %s, fall back to json", encoding, url))
+		h.encodingRejected.Store(true)
+		status, err = h.post(ctx, url, tags, payloadEncodingJSON)
+	}
+	if err == nil && (status < http.StatusOK || status >= http.StatusMultipleChoices) {
+		err = &endpointStatusError{status: status}
+	}
+	return err
+}
+
+func (h *httpTelemetryClient) post(ctx context.Context, url string, tags map[string]string, encoding string) (int, error) {
+	body, contentType, err := encodePayload(encoding, h.format, tags)
+	if err != nil {
+		errorLog(ctx, fmt.Sprintf("error on unmarshal telemetry resource: %s", err.Error()))
+		return 0, err
+	}
+	event := tags["event"]
+	traceLog(ctx, fmt.Sprintf("sending tags to %s", url))
+	ctx, cancel := withSendTimeout(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		errorLog(ctx, fmt.Sprintf("error on composing http request for %s telemetry resource: %+v", event, err))
+		return 0, err
+	}
+	if err = h.setHeaders(ctx, req); err != nil {
+		errorLog(ctx, fmt.Sprintf("error on authenticating %s telemetry resource: %+v", event, err))
+		return 0, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := h.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			errorLog(ctx, fmt.Sprintf("timeout on %s telemetry resource", event))
+			return 0, fmt.Errorf("timeout on %s telemetry resource: %w", event, ctx.Err())
+		}
+		errorLog(ctx, fmt.Sprintf("error on %s telemetry resource: %+v", event, err))
+		return 0, err
+	}
+	defer closeBody(resp)
+	traceLog(ctx, fmt.Sprintf("response Status for %s telemetry resource: %s", event, resp.Status))
+	return resp.StatusCode, nil
+}
+
+// setHeaders adds the configured headers and the AAD token to a request that sends events, the endpoint discovery
+// doesn't send them.
+func (h *httpTelemetryClient) setHeaders(ctx context.Context, req *http.Request) error {
+	for k, values := range h.headers {
+		req.Header[k] = append([]string(nil), values...)
+	}
+	if h.token == nil {
+		return nil
+	}
+	token, err := h.token.get(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
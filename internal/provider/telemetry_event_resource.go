@@ -0,0 +1,173 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+var _ resource.Resource = &TelemetryEventResource{}
+var _ resource.ResourceWithConfigure = &TelemetryEventResource{}
+var _ resource.ResourceWithModifyPlan = &TelemetryEventResource{}
+
+// reservedEvents are the events sent by the provider itself, they cannot be used as custom event names.
+var reservedEvents = []string{
+	"create",
+	readEvent,
+	"update",
+	"delete",
+	destroyPlannedEvent,
+	heartbeatEvent,
+	planEvent,
+	sessionOpenEvent,
+	sessionCloseEvent,
+	functionEvent,
+}
+
+func NewTelemetryEventResource() resource.Resource {
+	return &TelemetryEventResource{}
+}
+
+// TelemetryEventResource sends a single custom event when it's created, it delivers the event exactly like
+// `modtm_telemetry` does.
+type TelemetryEventResource struct {
+	sender TelemetryResource
+}
+
+// TelemetryEventResourceModel describes the resource data model.
+type TelemetryEventResourceModel struct {
+	Id             types.String `tfsdk:"id"`
+	EventName      types.String `tfsdk:"event_name"`
+	Tags           types.Map    `tfsdk:"tags"`
+	Endpoint       types.String `tfsdk:"endpoint"`
+	RequestTimeout types.String `tfsdk:"request_timeout"`
+}
+
+func (r *TelemetryEventResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_telemetry_event"
+}
+
+func (r *TelemetryEventResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "`modtm_telemetry_event` resource sends a single custom event, e.g. `module_upgraded` or `feature_enabled`, when it's created. Unlike `modtm_telemetry`, no event is sent when the resource is read, updated or deleted. Changing `event_name` or `tags` replaces the resource, so the event is sent again. The event goes through the same event pipeline, routes and endpoint as the events of `modtm_telemetry`.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Resource identifier, sent as the `resource_id` tag",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"event_name": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: fmt.Sprintf("The name of the event, sent as the `event` tag. The events sent by the provider itself, %s, cannot be used.", markdownCodeList(reservedEvents)),
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+					stringvalidator.NoneOf(reservedEvents...),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"tags": schema.MapAttribute{
+				Required:            true,
+				MarkdownDescription: fmt.Sprintf("Tags to be sent with the event. The following tags are set by the provider, so they're reserved and cannot be used: %s.", markdownCodeList(reservedTags)),
+				ElementType:         basetypes.StringType{},
+				Validators: []validator.Map{
+					mapValidator{},
+				},
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"endpoint": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Telemetry endpoint to send the event to, it's used in the same way as `endpoint` of `modtm_telemetry`.",
+				Validators: []validator.String{
+					MustBeValidEndpoint{},
+				},
+			},
+			"request_timeout": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: fmt.Sprintf("How long to wait for the endpoint to respond to the event, e.g. `10s`, no longer than `%s`. Overrides provider's `request_timeout`.", maxRequestTimeout),
+				Validators: []validator.String{
+					MustBeValidDuration{Max: maxRequestTimeout},
+				},
+			},
+		},
+	}
+}
+
+func (r *TelemetryEventResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.sender.Configure(ctx, req, resp)
+}
+
+// ModifyPlan checks the tags against the provider's `tag_limits`, and the endpoint must be HTTPS unless
+// `allow_insecure_endpoint` is set.
+func (r *TelemetryEventResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	data := &TelemetryEventResourceModel{}
+	resp.Diagnostics.Append(req.Plan.Get(ctx, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(r.sender.tagLimits.validate(path.Root("tags"), data.Tags)...)
+	resp.Diagnostics.Append(insecureEndpointDiagnostics(path.Root("endpoint"), data.Endpoint.ValueString(), "the resource", r.sender.allowInsecureEndpoint)...)
+}
+
+func (r *TelemetryEventResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	data := &TelemetryEventResourceModel{}
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(uuid.NewString())
+	traceLog(ctx, fmt.Sprintf("created telemetry event resource with id %s", data.Id.ValueString()))
+	attempt := r.sender.sendEvent(ctx, data.EventName.ValueString(), data.Id.ValueString(), mergeTags(data.Tags), data.Endpoint.ValueString(), data.RequestTimeout.ValueString())
+	resp.Diagnostics.Append(resp.State.Set(ctx, data)...)
+	resp.Diagnostics.Append(updateDeliveryState(ctx, resp.Private, attempt)...)
+}
+
+func (r *TelemetryEventResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	data := &TelemetryEventResourceModel{}
+
+	resp.Diagnostics.Append(req.State.Get(ctx, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, data)...)
+}
+
+func (r *TelemetryEventResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	data := &TelemetryEventResourceModel{}
+
+	// Only `endpoint` and `request_timeout` could be updated in place, they only matter when the event is sent.
+	resp.Diagnostics.Append(req.Plan.Get(ctx, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, data)...)
+}
+
+func (r *TelemetryEventResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
@@ -0,0 +1,119 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccTelemetryEventResource_sendsEventOnCreateOnly(t *testing.T) {
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.contoso.com"}
+	config := func(feature string) string {
+		return `
+provider "modtm" {
+  module_source_regex = ["foo"]
+}
+
+resource "modtm_telemetry_event" "test" {
+  event_name = "feature_enabled"
+  tags = {
+    module_source = "foo"
+    feature       = "` + feature + `"
+  }
+}
+`
+	}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: config("private_endpoint"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("modtm_telemetry_event.test", "id", regexp.MustCompile(uuidRegex)),
+				),
+			},
+			{
+				Config: config("diagnostic_settings"),
+			},
+		},
+	})
+	var features []string
+	for _, e := range client.sentEvents() {
+		assert.Equal(t, "feature_enabled", e.tags["event"])
+		features = append(features, e.tags["feature"])
+	}
+	assert.Equal(t, []string{"private_endpoint", "diagnostic_settings"}, features)
+}
+
+func TestAccTelemetryEventResource_lifecycleEventNameIsRejected(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(&fakeTelemetryClient{}),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "modtm" {
+  module_source_regex = ["foo"]
+}
+
+resource "modtm_telemetry_event" "test" {
+  event_name = "delete"
+  tags = {
+    module_source = "foo"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`event_name`),
+			},
+		},
+	})
+}
+
+func TestTelemetryEventResource_providerEventNamesAreRejected(t *testing.T) {
+	resp := &fwresource.SchemaResponse{}
+	(&TelemetryEventResource{}).Schema(context.Background(), fwresource.SchemaRequest{}, resp)
+	validators := resp.Schema.Attributes["event_name"].(schema.StringAttribute).Validators
+	for _, name := range []string{"create", "destroy_planned", "heartbeat", "plan", "open", "close", "function"} {
+		validateResp := &validator.StringResponse{}
+		for _, v := range validators {
+			v.ValidateString(context.Background(), validator.StringRequest{Path: path.Root("event_name"), ConfigValue: types.StringValue(name)}, validateResp)
+		}
+		assert.True(t, validateResp.Diagnostics.HasError(), name)
+	}
+}
+
+func TestSendEvent_customEvent(t *testing.T) {
+	client := &fakeTelemetryClient{}
+	res := &TelemetryResource{
+		providerEndpointFunc: func() string {
+			return "https://provider.contoso.com"
+		},
+		enabled:                        true,
+		defaultEndpointOnProviderBlock: true,
+		highPriorityEvents:             defaultHighPriorityEvents,
+		pipeline:                       eventPipeline{moduleSourceFilterStage([]*regexp.Regexp{regexp.MustCompile("foo")}, nil)},
+		sequence:                       &eventSequence{},
+		client:                         client,
+	}
+	attempt := res.sendEvent(context.Background(), "module_upgraded", "00000000-0000-0000-0000-000000000000", map[string]string{"module_source": "foo"}, "https://resource.contoso.com", "")
+	require.NotNil(t, attempt)
+	assert.NoError(t, attempt.err)
+	sent := client.sentEvents()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "https://resource.contoso.com", sent[0].endpoint)
+	assert.Equal(t, "module_upgraded", sent[0].tags["event"])
+	assert.Equal(t, "00000000-0000-0000-0000-000000000000", sent[0].tags["resource_id"])
+}
@@ -4,18 +4,15 @@
 package provider
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
-	"path/filepath"
-	"regexp"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -30,6 +27,7 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &TelemetryResource{}
 var _ resource.ResourceWithImportState = &TelemetryResource{}
+var _ resource.ResourceWithModifyPlan = &TelemetryResource{}
 
 var traceLog = tflog.Trace
 var errorLog = tflog.Error
@@ -43,25 +41,82 @@ type TelemetryResource struct {
 	providerEndpointFunc           func() string
 	enabled                        bool
 	defaultEndpointOnProviderBlock bool
-	moduleSourceRegex              []*regexp.Regexp
+	pipeline                       eventPipeline
+	sendLimiter                    *sendLimiter
+	rateLimiter                    *rateLimiter
+	highPriorityEvents             []string
+	destroyPlannedEvent            bool
+	sequence                       *eventSequence
+	timestampFormat                string
+	timestampPrecision             string
+	client                         telemetryClient
+	offline                        bool
+	sink                           eventSink
+	spool                          *eventSpool
+	routes                         []eventRoute
+	requestTimeout                 time.Duration
+	providerTags                   map[string]string
+	modulesJsonPath                string
+	modulesJson                    *modulesJsonCache
+	strictModuleResolution         bool
+	tagLimits                      tagLimits
+	allowInsecureEndpoint          bool
+	// endpoints are the provider's `endpoints`, every event is sent to them too.
+	endpoints []string
+	// fallbackEndpoints are the provider's `fallback_endpoints`, tried in order when the provider's endpoint fails.
+	fallbackEndpoints []string
 }
 
 // TelemetryResourceModel describes the resource data model.
 type TelemetryResourceModel struct {
-	Id       types.String `tfsdk:"id"`
-	Tags     types.Map    `tfsdk:"tags"`
-	Endpoint types.String `tfsdk:"endpoint"`
+	Id             types.String `tfsdk:"id"`
+	Tags           types.Map    `tfsdk:"tags"`
+	AdditionalTags types.Map    `tfsdk:"additional_tags"`
+	Endpoint       types.String `tfsdk:"endpoint"`
+	Endpoints      types.List   `tfsdk:"endpoints"`
+	InstanceKey    types.String `tfsdk:"instance_key"`
+	RequestTimeout types.String `tfsdk:"request_timeout"`
+	Enabled        types.Bool   `tfsdk:"enabled"`
+	ModulePath     types.String `tfsdk:"module_path"`
+	ModuleSource   types.String `tfsdk:"module_source"`
+	ModuleVersion  types.String `tfsdk:"module_version"`
+	Triggers       types.Map    `tfsdk:"triggers"`
+	// HeartbeatInterval is how long a Read waits since the last sent event before it sends a heartbeat event.
+	HeartbeatInterval types.String `tfsdk:"heartbeat_interval"`
 	//TODO: Remove these fields in v1
 	Nonce           types.Number `tfsdk:"nonce"`
 	EphemeralNumber types.Number `tfsdk:"ephemeral_number"`
 }
 
+var _ moduleSource = &TelemetryResourceModel{}
+
+func (r *TelemetryResourceModel) GetModuleVersion() types.String {
+	return r.ModuleVersion
+}
+
+func (r *TelemetryResourceModel) SetModuleVersion(v types.String) {
+	r.ModuleVersion = v
+}
+
+func (r *TelemetryResourceModel) GetModuleSource() types.String {
+	return r.ModuleSource
+}
+
+func (r *TelemetryResourceModel) SetModuleSource(v types.String) {
+	r.ModuleSource = v
+}
+
+func (r *TelemetryResourceModel) GetModulePath() types.String {
+	return r.ModulePath
+}
+
 func (r *TelemetryResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_telemetry"
 }
 
 func (r *TelemetryResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version: telemetryResourceSchemaVersion,
 		// This description is used by the documentation generator and the language server.
 		MarkdownDescription: "`modtm_telemetry` resource gathers and sends telemetry data to a specified endpoint. The aim is to provide visibility into the lifecycle of your Terraform modules - whether they are being created, updated, or deleted.",
 
@@ -75,12 +130,25 @@ func (r *TelemetryResource) Schema(ctx context.Context, req resource.SchemaReque
 			},
 			"tags": schema.MapAttribute{
 				Required:            true,
-				MarkdownDescription: "Tags to be sent to telemetry endpoint. The following tags are reserved and cannot be used: `event`. When specififying `module_path`, the `source` and `version` tags will be automatically added to the tags sent to the telemetry endpoint.",
+				MarkdownDescription: fmt.Sprintf("Tags to be sent to telemetry endpoint. The following tags are set by the provider, so they're reserved and cannot be used: %s. When specifying `module_path`, the `module_source` and `module_version` tags will be automatically added to the tags sent to the telemetry endpoint, unless they're set explicitly. Update events also carry a `tag_changes` tag, a JSON object with the `previous` and `current` values of every changed tag.", markdownCodeList(reservedTags)),
+				ElementType:         basetypes.StringType{},
+				Validators: []validator.Map{
+					mapValidator{},
+				},
+			},
+			"additional_tags": schema.MapAttribute{
+				Optional:            true,
+				MarkdownDescription: "Extra tags merged over `tags`, so wrapper modules could add context without changing the `tags` map passed in by an embedded telemetry block. A tag in `additional_tags` wins over the tag with the same key in `tags`, while the tags added by the provider, e.g. `event`, win over both. The same reserved tags apply.",
 				ElementType:         basetypes.StringType{},
 				Validators: []validator.Map{
 					mapValidator{},
 				},
 			},
+			"triggers": schema.MapAttribute{
+				Optional:            true,
+				MarkdownDescription: "Arbitrary values whose change plans an update of the resource, so an `update` event is sent even when the tags are unchanged, e.g. `{ module_version = local.module_version }`. It works like the `triggers` of `null_resource`, except the resource is updated instead of replaced. The values are not sent with the event.",
+				ElementType:         basetypes.StringType{},
+			},
 			"endpoint": schema.StringAttribute{
 				Optional: true,
 				MarkdownDescription: "Telemetry endpoint to send data to, will override provider's default `endpoint` setting.\n" +
@@ -95,6 +163,51 @@ func (r *TelemetryResource) Schema(ctx context.Context, req resource.SchemaReque
 					"| × | ✓ | × | `MODTM_ENDPOINT` environment variable | \n" +
 					"| × | × | ✓ | Explicit `endpoint` in resource block | \n" +
 					"| × | × | × | Default Microsoft telemetry service endpoint | \n",
+				Validators: []validator.String{
+					MustBeValidEndpoint{},
+				},
+			},
+			"endpoints": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Additional telemetry endpoints that the events of this resource are sent to, along with the endpoint resolved as described in `endpoint` and the provider's `endpoints`. The events are sent to all endpoints in parallel. Like `endpoint`, it's ignored when the provider's endpoint is set explicitly by the `endpoint` argument or `MODTM_ENDPOINT` environment variable.",
+				Validators: []validator.List{
+					listvalidator.ValueStringsAre(MustBeValidEndpoint{}),
+				},
+			},
+			"instance_key": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "The instance key of the module when the resource is created under `count` or `for_each`, usually `each.key` or `count.index`. When set, it's sent as the `instance_key` tag so the instances of a multi-instance deployment are distinguishable.",
+			},
+			"request_timeout": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: fmt.Sprintf("How long to wait for the endpoint to respond to an event of this resource, e.g. `10s`, no longer than `%s`. Overrides provider's `request_timeout`.", maxRequestTimeout),
+				Validators: []validator.String{
+					MustBeValidDuration{Max: maxRequestTimeout},
+				},
+			},
+			"heartbeat_interval": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: fmt.Sprintf("Send a `%s` event instead of the `%s` event when the resource is read, e.g. by `terraform plan`, and no event of this resource has been accepted by the endpoint for this long, e.g. `24h`, so module owners could tell the deployments that are still alive. The time of the last sent event is kept in the resource's private state. With `send_mode = \"async\"` or `transport = \"batch\"` the events are delivered after the resource is read, so the last queued event counts as sent. Defaults to no heartbeat.", heartbeatEvent, readEvent),
+				Validators: []validator.String{
+					MustBeValidDuration{},
+				},
+			},
+			"enabled": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Sending telemetry of this resource or not, overrides provider's `enabled` setting so a composition could turn telemetry off for a specific module instance while leaving others on. Telemetry is always off when it's opted out by environment variables.",
+			},
+			"module_path": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "The path of the module that the telemetry resource is associated with, usually `path.module`. The provider reads the `$TF_DATA_DIR/modules/modules.json` file during the plan time to resolve `module_source` and `module_version`, an update is planned when they change, e.g. after `terraform init -upgrade`.",
+			},
+			"module_source": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The source of the module at `module_path`, read from `modules.json`. Null when `module_path` is not set or not found.",
+			},
+			"module_version": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The version of the module at `module_path`, read from `modules.json`. Null when `module_path` is not set or not found, and empty for local modules.",
 			},
 			//TODO: Remove these fields in v1
 			"nonce": schema.NumberAttribute{
@@ -135,7 +248,82 @@ func (r *TelemetryResource) Configure(ctx context.Context, req resource.Configur
 	r.providerEndpointFunc = c.endpointFunc
 	r.enabled = c.enabled
 	r.defaultEndpointOnProviderBlock = c.defaultEndpoint
-	r.moduleSourceRegex = c.moduleSourceRegex
+	r.pipeline = c.pipeline
+	r.sendLimiter = c.sendLimiter
+	r.rateLimiter = c.rateLimiter
+	r.highPriorityEvents = c.highPriorityEvents
+	r.destroyPlannedEvent = c.destroyPlannedEvent
+	r.sequence = c.sequence
+	r.timestampFormat = c.timestampFormat
+	r.timestampPrecision = c.timestampPrecision
+	r.client = c.client
+	r.offline = c.offline
+	r.sink = c.sink
+	r.spool = c.spool
+	r.routes = c.routes
+	r.requestTimeout = c.requestTimeout
+	r.providerTags = c.tags
+	r.modulesJsonPath = c.modulesJsonPath
+	r.modulesJson = c.modulesJson
+	r.strictModuleResolution = c.strictModuleResolution
+	r.tagLimits = c.tagLimits
+	r.allowInsecureEndpoint = c.allowInsecureEndpoint
+	r.endpoints = c.endpoints
+	r.fallbackEndpoints = c.fallbackEndpoints
+}
+
+// destroyPlannedEvent is the event sent when the resource is planned for destruction, see `destroy_planned_event`.
+const destroyPlannedEvent = "destroy_planned"
+
+// The tags that dispatchEvent and sendTags set on every event, overwriting the tags with the same keys.
+const (
+	eventTag       = "event"
+	resourceIdTag  = "resource_id"
+	sequenceTag    = "sequence"
+	timestampTag   = "timestamp"
+	instanceKeyTag = "instance_key"
+)
+
+// ModifyPlan resolves `module_source` and `module_version` from `module_path` during the plan time, so a changed
+// modules.json shows up as an update of the resource. They're unknown until `module_path` is known. The tags are
+// checked against the provider's `tag_limits`, and the endpoint must be HTTPS unless `allow_insecure_endpoint` is set.
+// When the resource is planned for destruction, the `destroy_planned` event is sent if it's enabled.
+func (r *TelemetryResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		if r.destroyPlannedEvent && !req.State.Raw.IsNull() {
+			prior := &TelemetryResourceModel{}
+			resp.Diagnostics.Append(req.State.Get(ctx, prior)...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+			traceLog(ctx, fmt.Sprintf("telemetry resource with id %s is planned for destruction", prior.Id.String()))
+			prior.sendTags(ctx, r, destroyPlannedEvent, nil)
+		}
+		return
+	}
+	data := &TelemetryResourceModel{}
+	resp.Diagnostics.Append(req.Plan.Get(ctx, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(r.tagLimits.validate(path.Root("tags"), data.Tags, data.AdditionalTags)...)
+	resp.Diagnostics.Append(insecureEndpointDiagnostics(path.Root("endpoint"), data.Endpoint.ValueString(), "the resource", r.allowInsecureEndpoint)...)
+	for i, v := range data.Endpoints.Elements() {
+		if e, ok := v.(types.String); ok {
+			resp.Diagnostics.Append(insecureEndpointDiagnostics(path.Root("endpoints").AtListIndex(i), e.ValueString(), "the resource", r.allowInsecureEndpoint)...)
+		}
+	}
+	if data.ModulePath.IsUnknown() {
+		data.ModuleSource = types.StringUnknown()
+		data.ModuleVersion = types.StringUnknown()
+	} else {
+		var err error
+		data, err = withModuleSourceAndVersion(ctx, data, r.modulesJson, r.modulesJsonPath)
+		resp.Diagnostics.Append(moduleResolutionDiagnostics(err, r.strictModuleResolution)...)
+	}
+	traceLog(ctx, fmt.Sprintf("planned module source %s and version %s for path %s", data.ModuleSource.String(), data.ModuleVersion.String(), data.ModulePath.String()))
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("module_source"), data.ModuleSource)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("module_version"), data.ModuleVersion)...)
 }
 
 func (r *TelemetryResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -156,9 +344,14 @@ func (r *TelemetryResource) Create(ctx context.Context, req resource.CreateReque
 	if data.EphemeralNumber.IsUnknown() {
 		data.EphemeralNumber = types.NumberNull()
 	}
+	if data.ModuleSource.IsUnknown() {
+		data, _ = withModuleSourceAndVersion(ctx, data, r.modulesJson, r.modulesJsonPath)
+	}
 	traceLog(ctx, fmt.Sprintf("created telemetry resource with id %s", newId))
-	data.sendTags(ctx, r, "create")
+	attempt := data.sendTags(ctx, r, "create", nil)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(setTelemetryResourceIdentity(ctx, resp.Identity, data.Id)...)
+	resp.Diagnostics.Append(updateDeliveryState(ctx, resp.Private, attempt)...)
 }
 
 func (r *TelemetryResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -172,14 +365,22 @@ func (r *TelemetryResource) Read(ctx context.Context, req resource.ReadRequest,
 	}
 
 	traceLog(ctx, fmt.Sprintf("read telemetry resource with id %s", data.Id.String()))
-	data.sendTags(ctx, r, "read")
+	event := readEvent
+	if delivery, diags := readDeliveryState(ctx, req.Private); !diags.HasError() && delivery.heartbeatDue(data.HeartbeatInterval.ValueString()) {
+		event = heartbeatEvent
+	}
+	attempt := data.sendTags(ctx, r, event, nil)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(setTelemetryResourceIdentity(ctx, resp.Identity, data.Id)...)
+	resp.Diagnostics.Append(updateDeliveryState(ctx, resp.Private, attempt)...)
 }
 
 func (r *TelemetryResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	data := &TelemetryResourceModel{}
+	prior := &TelemetryResourceModel{}
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, prior)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -190,10 +391,15 @@ func (r *TelemetryResource) Update(ctx context.Context, req resource.UpdateReque
 	if data.EphemeralNumber.IsUnknown() {
 		data.EphemeralNumber = types.NumberNull()
 	}
+	if data.ModuleSource.IsUnknown() {
+		data, _ = withModuleSourceAndVersion(ctx, data, r.modulesJson, r.modulesJsonPath)
+	}
 	traceLog(ctx, fmt.Sprintf("update telemetry resource with id %s", data.Id.String()))
-	data.sendTags(ctx, r, "update")
+	attempt := data.sendTags(ctx, r, "update", tagChangesTags(prior.readTags(), data.readTags()))
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(setTelemetryResourceIdentity(ctx, resp.Identity, data.Id)...)
+	resp.Diagnostics.Append(updateDeliveryState(ctx, resp.Private, attempt)...)
 }
 
 func (r *TelemetryResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
@@ -207,89 +413,127 @@ func (r *TelemetryResource) Delete(ctx context.Context, req resource.DeleteReque
 	}
 
 	traceLog(ctx, fmt.Sprintf("delete telemetry resource with id %s", data.Id.String()))
-	data.sendTags(ctx, r, "delete")
+	data.sendTags(ctx, r, "delete", nil)
 }
 
 func (r *TelemetryResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	//　Since it's a fake resource, we won't support import
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
-}
-
-// sendPostRequest sends an HTTP POST request to the specified URL with the given body.
-func sendPostRequest(ctx context.Context, url string, tags map[string]string) {
-	jsonStr, err := json.Marshal(tags)
-	if err != nil {
-		errorLog(ctx, fmt.Sprintf("error on unmarshal telemetry resource: %s", err.Error()))
+	// `tags` is required and cannot be read back from anywhere, so it must be carried by the import ID.
+	if req.ID == "" {
+		resp.Diagnostics.AddError("Import by identity is not supported", "`modtm_telemetry` cannot be imported by identity since its `tags` cannot be reconstructed from the id. "+telemetryImportIdSyntax)
 		return
 	}
-	event := tags["event"]
-	client := &http.Client{}
-	traceLog(ctx, fmt.Sprintf("sending tags to %s", url))
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonStr))
+	id, tags, err := parseTelemetryImportId(req.ID)
 	if err != nil {
-		errorLog(ctx, fmt.Sprintf("error on composing http request for %s telemetry resource: %+v", event, err))
+		resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("%s. %s", err.Error(), telemetryImportIdSyntax))
 		return
 	}
-	req.Header.Set("Content-Type", "application/json")
-	c := make(chan int)
-	errChan := make(chan error)
-	go func() {
-		defer close(c)
-		resp, err := client.Do(req)
-		if err != nil {
-			errorLog(ctx, fmt.Sprintf("error on %s telemetry resource: %+v", event, err))
-			errChan <- err
-			return
-		}
-		traceLog(ctx, fmt.Sprintf("response Status for %s telemetry resource: %s", event, resp.Status))
-		defer func() {
-			_ = resp.Body.Close()
-		}()
-		c <- 1
-	}()
-	select {
-	case <-c:
-		return
-	case <-errChan:
-		return
-	case <-time.After(5 * time.Second):
-		errorLog(ctx, fmt.Sprintf("timeout on %s telemetry resource", event))
+	tagsValue, diags := types.MapValueFrom(ctx, types.StringType, tags)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
+	traceLog(ctx, fmt.Sprintf("import telemetry resource with id %s", id))
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("tags"), tagsValue)...)
+	resp.Diagnostics.Append(setTelemetryResourceIdentity(ctx, resp.Identity, types.StringValue(id))...)
 }
 
 // sendTags sends the tags to the telemetry endpoint.
-// It adds (and ovwewrites) the `source`, `version`, `event`, and `resource_id` tags to the tags map.
-func (r *TelemetryResourceModel) sendTags(ctx context.Context, res *TelemetryResource, event string) {
-	if !res.enabled {
-		return
+// It adds (and ovwewrites) the `instance_key` tag and extraTags to the tags map, then sends the
+// event with dispatchEvent unless telemetry is turned off by the resource's or provider's `enabled` setting. It returns the outcome of the delivery, or nil if the event hasn't been sent to the telemetry endpoint.
+func (r *TelemetryResourceModel) sendTags(ctx context.Context, res *TelemetryResource, event string, extraTags map[string]string) *deliveryAttempt {
+	enabled := res.enabled
+	if !r.Enabled.IsNull() && !r.Enabled.IsUnknown() {
+		enabled = r.Enabled.ValueBool()
 	}
-	tags := r.readTags()
-	tags["event"] = event
-	tags["resource_id"] = r.readResourceId()
-	src, ok := tags["module_source"]
-	if !ok {
-		return
+	if !enabled {
+		return nil
 	}
-	match := false
-	for _, regex := range res.moduleSourceRegex {
-		if regex.MatchString(src) {
-			match = true
-			break
-		}
+	tags := r.readTags()
+	for k, v := range extraTags {
+		tags[k] = v
 	}
-	if !match {
-		return
+	if !r.InstanceKey.IsNull() && !r.InstanceKey.IsUnknown() {
+		tags[instanceKeyTag] = r.InstanceKey.ValueString()
 	}
 	var endpoint string
-	if !res.defaultEndpointOnProviderBlock || r.Endpoint.IsNull() {
-		endpoint = res.providerEndpointFunc()
-	} else {
+	if !r.Endpoint.IsNull() {
 		endpoint = r.readEndpoint()
 	}
-	if endpoint != "" {
-		sendPostRequest(ctx, endpoint, tags)
+	return res.dispatchEvent(ctx, event, r.readResourceId(), tags, endpoint, r.readEndpoints(), r.RequestTimeout.ValueString())
+}
+
+// sendEvent sends the event with dispatchEvent, unless telemetry is turned off by the provider's `enabled` setting.
+func (res *TelemetryResource) sendEvent(ctx context.Context, event, resourceId string, tags map[string]string, endpoint, requestTimeout string) *deliveryAttempt {
+	if !res.enabled {
+		return nil
 	}
+	return res.dispatchEvent(ctx, event, resourceId, tags, endpoint, nil, requestTimeout)
+}
+
+// dispatchEvent adds the provider's tags that are missing from the tags map, adds (and overwrites) the `event`,
+// `resource_id`, `sequence` and `timestamp` tags, then passes the event through the provider's event pipeline and
+// sends it. endpoint, endpoints and requestTimeout are the resource's settings, empty when they're not set. The event
+// is sent to the resolved endpoint and the provider's and resource's `endpoints` in parallel, the resource's
+// `endpoints` are ignored when the provider's endpoint is set explicitly, like the resource's `endpoint`. The
+// provider's endpoint fails over to its `fallback_endpoints`, but the resource's endpoint doesn't. It returns
+// the outcome of the delivery, or nil if the event hasn't been sent to any endpoint. The event is also mirrored to the
+// matching routes.
+func (res *TelemetryResource) dispatchEvent(ctx context.Context, event, resourceId string, tags map[string]string, endpoint string, endpoints []string, requestTimeout string) *deliveryAttempt {
+	if env := telemetryOptOut(); env != "" {
+		traceLog(ctx, fmt.Sprintf("skip %s telemetry event: opted out by %s environment variable", event, env))
+		return nil
+	}
+	for k, v := range res.providerTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+	tags[eventTag] = event
+	tags[resourceIdTag] = resourceId
+	tags[sequenceTag] = strconv.FormatUint(res.sequence.next(), 10)
+	tags[timestampTag] = formatTimestamp(timeNow(), res.timestampFormat, res.timestampPrecision)
+	e := &telemetryEvent{
+		name:         event,
+		tags:         tags,
+		highPriority: slices.Contains(res.highPriorityEvents, event),
+	}
+	if !res.pipeline.run(ctx, e) {
+		return nil
+	}
+	if res.sink != nil {
+		if err := res.sink.write(tags); err != nil {
+			errorLog(ctx, fmt.Sprintf("error on writing %s telemetry event to sink: %+v", event, err))
+		}
+	}
+	if res.offline {
+		return nil
+	}
+	timeout := res.requestTimeout
+	if d, err := time.ParseDuration(requestTimeout); err == nil {
+		timeout = d
+	}
+	rateCtx, cancel := withRequestTimeout(ctx, timeout)
+	err := res.rateLimiter.wait(rateCtx)
+	cancel()
+	if err != nil {
+		traceLog(ctx, fmt.Sprintf("skip %s telemetry event: %s", event, err.Error()))
+		return nil
+	}
+	res.sendToRoutes(ctx, e, timeout)
+	var fallbacks []string
+	if !res.defaultEndpointOnProviderBlock || endpoint == "" {
+		// The provider's endpoint fails over to the `fallback_endpoints`, which take its place when it's empty.
+		chain := fanOutEndpoints([]string{res.providerEndpointFunc()}, res.fallbackEndpoints)
+		endpoint = ""
+		if len(chain) > 0 {
+			endpoint, fallbacks = chain[0], chain[1:]
+		}
+	}
+	if !res.defaultEndpointOnProviderBlock {
+		endpoints = nil
+	}
+	return res.fanOut(ctx, e, deliveryTargets(fanOutEndpoints([]string{endpoint}, res.endpoints, endpoints), fallbacks), timeout)
 }
 
 func (r *TelemetryResourceModel) readEndpoint() string {
@@ -301,6 +545,17 @@ func (r *TelemetryResourceModel) readEndpoint() string {
 	return endpoint
 }
 
+// readEndpoints returns the known values of `endpoints`.
+func (r *TelemetryResourceModel) readEndpoints() []string {
+	var endpoints []string
+	for _, v := range r.Endpoints.Elements() {
+		if e, ok := v.(types.String); ok && !e.IsNull() && !e.IsUnknown() {
+			endpoints = append(endpoints, e.ValueString())
+		}
+	}
+	return endpoints
+}
+
 func (r *TelemetryResourceModel) readResourceId() string {
 	resourceId, err := strconv.Unquote(r.Id.String())
 	if err != nil {
@@ -309,50 +564,37 @@ func (r *TelemetryResourceModel) readResourceId() string {
 	return resourceId
 }
 
+// readTags returns `tags` merged with `additional_tags`, the latter wins on conflicts. The resolved `module_source`
+// and `module_version` are added unless they're set explicitly.
 func (r *TelemetryResourceModel) readTags() map[string]string {
-	tags := make(map[string]string)
-	for k, v := range r.Tags.Elements() {
-		raw := v.String()
-		value, err := strconv.Unquote(raw)
-		if err != nil {
-			value = raw
+	return withModuleTags(mergeTags(r.Tags, r.AdditionalTags), r)
+}
+
+// withModuleTags adds the resolved module source and version of m as `module_source` and `module_version` tags,
+// unless they're null, unknown or already in tags.
+func withModuleTags(tags map[string]string, m moduleSource) map[string]string {
+	for k, v := range map[string]types.String{"module_source": m.GetModuleSource(), "module_version": m.GetModuleVersion()} {
+		if _, ok := tags[k]; !ok && !v.IsNull() && !v.IsUnknown() {
+			tags[k] = v.ValueString()
 		}
-		tags[k] = value
 	}
 	return tags
 }
 
-// parseModulesJson reads the modules.json file and returns the module entry with the specified key.
-func parseModulesJson(modulePath string) (*modulesJsonModulesModel, error) {
-	dataDir := envOrDefault("TF_DATA_DIR", ".terraform")
-	modulesJsonPath := filepath.Join(dataDir, "modules", "modules.json")
-	content, err := os.ReadFile(filepath.Clean(modulesJsonPath))
-	if err != nil {
-		return nil, fmt.Errorf("parseModulesJson: error reading modules.json file: %w", err)
-	}
-	var modules modulesJsonModel
-	if err = json.Unmarshal(content, &modules); err != nil {
-		return nil, fmt.Errorf("parseModulesJson: error unmarshalling modules.json file: %w", err)
-	}
-	for _, moduleEntry := range modules.Modules {
-		if moduleEntry.Dir == modulePath {
-			return &moduleEntry, nil
+// mergeTags merges the string maps into a new map, later maps win on conflicts.
+func mergeTags(maps ...types.Map) map[string]string {
+	tags := make(map[string]string)
+	for _, m := range maps {
+		for k, v := range m.Elements() {
+			raw := v.String()
+			value, err := strconv.Unquote(raw)
+			if err != nil {
+				value = raw
+			}
+			tags[k] = value
 		}
 	}
-	return nil, fmt.Errorf("parseModulesJson: module with dir %s not found in modules.json", modulePath)
-}
-
-// modulesJsonModel represents the base structure of the modules.json file.
-type modulesJsonModel struct {
-	Modules []modulesJsonModulesModel `json:"Modules"`
-}
-
-// modulesJsonModulesModel represents the structure of the modules.json file's `Modules` array.
-type modulesJsonModulesModel struct {
-	Key     string `json:"Key"`
-	Source  string `json:"Source"`
-	Version string `json:"Version"`
-	Dir     string `json:"Dir"`
+	return tags
 }
 
 func envOrDefault(env, defaultValue string) string {
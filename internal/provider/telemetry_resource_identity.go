@@ -0,0 +1,41 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.ResourceWithIdentity = &TelemetryResource{}
+
+// TelemetryResourceIdentityModel is the identity of `modtm_telemetry`, the UUID that is also the resource's `id`.
+type TelemetryResourceIdentityModel struct {
+	Id types.String `tfsdk:"id"`
+}
+
+func (r *TelemetryResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"id": identityschema.StringAttribute{
+				RequiredForImport: true,
+				Description:       "The UUID of the telemetry resource",
+			},
+		},
+	}
+}
+
+// setTelemetryResourceIdentity sets the identity to id. It does nothing when Terraform doesn't support resource
+// identity, or id is not known yet.
+func setTelemetryResourceIdentity(ctx context.Context, identity *tfsdk.ResourceIdentity, id types.String) diag.Diagnostics {
+	if identity == nil || id.IsNull() || id.IsUnknown() {
+		return nil
+	}
+	return identity.Set(ctx, TelemetryResourceIdentityModel{Id: id})
+}
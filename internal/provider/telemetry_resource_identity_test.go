@@ -0,0 +1,39 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTelemetryResourceIdentity(t *testing.T) {
+	ctx := context.Background()
+	schemaResp := &resource.IdentitySchemaResponse{}
+	(&TelemetryResource{}).IdentitySchema(ctx, resource.IdentitySchemaRequest{}, schemaResp)
+	require.False(t, schemaResp.Diagnostics.HasError())
+	identity := &tfsdk.ResourceIdentity{
+		Schema: schemaResp.IdentitySchema,
+		Raw:    tftypes.NewValue(schemaResp.IdentitySchema.Type().TerraformType(ctx), nil),
+	}
+
+	require.False(t, setTelemetryResourceIdentity(ctx, identity, types.StringUnknown()).HasError())
+	assert.True(t, identity.Raw.IsNull(), "unknown id must not be set as identity")
+
+	require.False(t, setTelemetryResourceIdentity(ctx, identity, types.StringValue("00000000-0000-0000-0000-000000000000")).HasError())
+	got := TelemetryResourceIdentityModel{}
+	require.False(t, identity.Get(ctx, &got).HasError())
+	assert.Equal(t, "00000000-0000-0000-0000-000000000000", got.Id.ValueString())
+}
+
+func TestSetTelemetryResourceIdentity_identityNotSupported(t *testing.T) {
+	assert.Empty(t, setTelemetryResourceIdentity(context.Background(), nil, types.StringValue("00000000-0000-0000-0000-000000000000")))
+}
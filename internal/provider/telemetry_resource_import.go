@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// telemetryImportIdSyntax describes the import ID of `modtm_telemetry`, returned in the import diagnostics.
+const telemetryImportIdSyntax = "The import ID must be `<id>:<tags>`, where `<id>` is the UUID of the resource and `<tags>` is the base64 encoded JSON object of the `tags` in the configuration, e.g. `$(echo -n '{\"module_source\":\"...\"}' | base64)`."
+
+// parseTelemetryImportId parses the import ID of `modtm_telemetry` into the resource's id and tags. Both the
+// standard and the URL-safe base64 encodings are accepted, with or without padding.
+func parseTelemetryImportId(importId string) (string, map[string]string, error) {
+	id, encodedTags, ok := strings.Cut(importId, ":")
+	if !ok {
+		return "", nil, fmt.Errorf("missing tags in import ID %q", importId)
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return "", nil, fmt.Errorf("invalid id %q in import ID: %w", id, err)
+	}
+	var content []byte
+	var err error
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if content, err = encoding.DecodeString(encodedTags); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid base64 tags in import ID: %w", err)
+	}
+	tags := make(map[string]string)
+	if err = json.Unmarshal(content, &tags); err != nil {
+		return "", nil, fmt.Errorf("invalid tags in import ID, a JSON object of strings is expected: %w", err)
+	}
+	return id, tags, nil
+}
@@ -0,0 +1,38 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTelemetryImportId(t *testing.T) {
+	tags := `{"module_source":"foo","module_version":"1.0.0"}`
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		id, got, err := parseTelemetryImportId("00000000-0000-0000-0000-000000000000:" + encoding.EncodeToString([]byte(tags)))
+		require.NoError(t, err)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000000", id)
+		assert.Equal(t, map[string]string{"module_source": "foo", "module_version": "1.0.0"}, got)
+	}
+}
+
+func TestParseTelemetryImportId_invalid(t *testing.T) {
+	cases := map[string]string{
+		"missing tags":   "00000000-0000-0000-0000-000000000000",
+		"invalid id":     "foo:" + base64.StdEncoding.EncodeToString([]byte(`{}`)),
+		"invalid base64": "00000000-0000-0000-0000-000000000000:!!!",
+		"not an object":  "00000000-0000-0000-0000-000000000000:" + base64.StdEncoding.EncodeToString([]byte(`["foo"]`)),
+		"not strings":    "00000000-0000-0000-0000-000000000000:" + base64.StdEncoding.EncodeToString([]byte(`{"foo":1}`)),
+	}
+	for name, importId := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := parseTelemetryImportId(importId)
+			assert.Error(t, err)
+		})
+	}
+}
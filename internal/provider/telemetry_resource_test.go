@@ -5,23 +5,35 @@ package provider
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	toxiproxy "github.com/Shopify/toxiproxy/v2/client"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
 	"github.com/prashantv/gostub"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -31,6 +43,7 @@ var uuidRegexR = regexp.MustCompile(uuidRegex)
 
 type mockServer struct {
 	s     *httptest.Server
+	mu    sync.Mutex
 	tags  []map[string]string
 	delay *time.Duration
 }
@@ -48,7 +61,9 @@ func newMockServer() *mockServer {
 			time.Sleep(*ms.delay)
 		}
 		_ = json.Unmarshal(data, &tags)
+		ms.mu.Lock()
 		ms.tags = append(ms.tags, tags)
+		ms.mu.Unlock()
 		writer.WriteHeader(200)
 	}))
 	return ms
@@ -84,6 +99,13 @@ func (l *stubLogger) traceLog(ctx context.Context, msg string, additionalFields
 	l.traces = append(l.traces, fmt.Sprintf(msg, additionalFields))
 }
 
+// stubLoggers redirects traceLog and errorLog to logger until the returned stubs are reset.
+func stubLoggers(logger *stubLogger) *gostub.Stubs {
+	stub := gostub.Stub(&traceLog, logger.traceLog)
+	stub.Stub(&errorLog, logger.errorLog)
+	return stub
+}
+
 type accTelemetryResourceSuite struct {
 	suite.Suite
 }
@@ -150,6 +172,7 @@ func (s *accTelemetryResourceSuite) TestAccTelemetryResource_endpointByBlob() {
 		"module_source":            "foo",
 	}
 	stub := gostub.Stub(&endpointBlobUrl, blobMs.serverUrl())
+	stub.Stub(&discoveredEndpointPolicy, testEndpointPolicy)
 	defer stub.Reset()
 	resource.Test(t, resource.TestCase{
 		PreCheck:                 func() { testAccPreCheck(t) },
@@ -181,6 +204,117 @@ func (s *accTelemetryResourceSuite) TestAccTelemetryResource_endpointByBlob() {
 	assertEventTags(t, "delete", tags2, ms)
 }
 
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_updateEventCarriesTagChanges() {
+	t := s.T()
+	ms := newMockServer()
+	defer ms.close()
+	tags1 := map[string]string{
+		"module_source": "foo",
+		"module_ver":    "1.0.0",
+		"removed":       "bar",
+	}
+	tags2 := map[string]string{
+		"module_source": "foo",
+		"module_ver":    "1.1.0",
+		"added":         "baz",
+	}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccTelemetryResourceConfig(ms.serverUrl(), true, tags1),
+			},
+			{
+				Config: testAccTelemetryResourceConfig(ms.serverUrl(), true, tags2),
+			},
+		},
+	})
+	var changes []string
+	for _, tags := range ms.tags {
+		if tags["event"] != "create" {
+			changes = append(changes, tags[tagChangesTag])
+		}
+	}
+	s.Contains(changes, `{"added":{"current":"baz"},"module_ver":{"previous":"1.0.0","current":"1.1.0"},"removed":{"previous":"bar"}}`)
+	s.NotContains(ms.tags[0], tagChangesTag)
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_instanceKey() {
+	t := s.T()
+	ms := newMockServer()
+	defer ms.close()
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  endpoint            = "%s"
+  module_source_regex = ["foo"]
+}
+
+resource "modtm_telemetry" "test" {
+  count        = 2
+  instance_key = count.index
+  tags = {
+    module_source = "foo"
+  }
+}
+`, ms.serverUrl()),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("modtm_telemetry.test.0", "instance_key", "0"),
+					resource.TestCheckResourceAttr("modtm_telemetry.test.1", "instance_key", "1"),
+				),
+			},
+		},
+	})
+	var instanceKeys []string
+	for _, tags := range ms.tags {
+		if tags["event"] == "create" {
+			instanceKeys = append(instanceKeys, tags["instance_key"])
+		}
+	}
+	s.ElementsMatch([]string{"0", "1"}, instanceKeys)
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_additionalTagsMergedOverTags() {
+	t := s.T()
+	ms := newMockServer()
+	defer ms.close()
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  endpoint            = "%s"
+  module_source_regex = ["foo"]
+}
+
+resource "modtm_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+    caller        = "avm"
+  }
+  additional_tags = {
+    caller  = "wrapper"
+    wrapper = "landing-zone"
+  }
+}
+`, ms.serverUrl()),
+			},
+		},
+	})
+	assertEventTags(t, "create", map[string]string{
+		"module_source": "foo",
+		"caller":        "wrapper",
+		"wrapper":       "landing-zone",
+	}, ms)
+}
+
 func (s *accTelemetryResourceSuite) TestAccTelemetryResource_endpointUnreachableShouldFallbackToDisabledProvider() {
 	t := s.T()
 	ms := newMockServer()
@@ -346,6 +480,7 @@ resource "modtm_telemetry" "test" {
 				tagsBuilder.WriteString("\n")
 			}
 			stub := gostub.Stub(&endpointBlobUrl, blobMs.serverUrl())
+			stub.Stub(&discoveredEndpointPolicy, testEndpointPolicy)
 			defer stub.Reset()
 			resource.Test(t, resource.TestCase{
 				PreCheck:                 func() { testAccPreCheck(t) },
@@ -439,6 +574,818 @@ resource "modtm_telemetry" "test" {
 	}
 }
 
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_terraformTestShouldTagEvents() {
+	t := s.T()
+	ms := newMockServer()
+	defer ms.close()
+	stub := gostub.Stub(&detectTerraformCommand, func() string {
+		return "test"
+	})
+	defer stub.Reset()
+	tags1, tags2 := testTelemetryResource(t, ms.serverUrl(), true)
+	s.NotEmpty(ms.tags)
+	for _, tags := range ms.tags {
+		s.Equal("true", tags["terraform_test"])
+		delete(tags, "terraform_test")
+	}
+	assertEventTags(t, "create", tags1, ms)
+	assertEventTags(t, "update", tags2, ms)
+	assertEventTags(t, "delete", tags2, ms)
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_skipOnTerraformTest() {
+	t := s.T()
+	ms := newMockServer()
+	defer ms.close()
+	stub := gostub.Stub(&detectTerraformCommand, func() string {
+		return "test"
+	})
+	defer stub.Reset()
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  endpoint               = "%s"
+  module_source_regex    = ["foo"]
+  skip_on_terraform_test = true
+}
+
+resource "modtm_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+  }
+}
+`, ms.serverUrl()),
+				Check: resourceIdIsUuidCheck(),
+			},
+		},
+	})
+	s.Empty(ms.tags)
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_maxConcurrentSends() {
+	t := s.T()
+	ms := newMockServer()
+	defer ms.close()
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  endpoint             = "%s"
+  module_source_regex  = ["foo"]
+  max_concurrent_sends = 1
+}
+
+resource "modtm_telemetry" "test" {
+  count = 3
+  tags = {
+    module_source = "foo"
+  }
+}
+`, ms.serverUrl()),
+			},
+		},
+	})
+	created := 0
+	for _, tags := range ms.tags {
+		if tags["event"] == "create" {
+			created++
+		}
+	}
+	s.Equal(3, created)
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_sequenceAndTimestamp() {
+	t := s.T()
+	ms := newMockServer()
+	defer ms.close()
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	})
+	defer stub.Reset()
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  endpoint            = "%s"
+  module_source_regex = ["foo"]
+  timestamp_format    = "unix"
+  timestamp_precision = "s"
+}
+
+resource "modtm_telemetry" "test" {
+  count = 3
+  tags = {
+    module_source = "foo"
+  }
+}
+`, ms.serverUrl()),
+			},
+		},
+	})
+	var sequences []string
+	for _, tags := range ms.tags {
+		s.Equal("1714979289", tags["timestamp"])
+		sequences = append(sequences, tags["sequence"])
+	}
+	s.Subset(sequences, []string{"1", "2", "3"})
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_disableDefaultEndpointDiscovery() {
+	t := s.T()
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.contoso.com"}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "modtm" {
+  module_source_regex                = ["foo"]
+  disable_default_endpoint_discovery = true
+}
+
+resource "modtm_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+  }
+}
+`,
+			},
+		},
+	})
+	s.Empty(client.sentEvents())
+	s.Zero(client.discoverCalls)
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_offlineShouldOnlyWriteToSink() {
+	t := s.T()
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.contoso.com"}
+	sinkPath := filepath.Join(t.TempDir(), "events.jsonl")
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  module_source_regex = ["foo"]
+  offline             = true
+  sink_path           = "%s"
+}
+
+resource "modtm_telemetry" "test" {
+  endpoint = "https://resource.contoso.com"
+  tags = {
+    module_source = "foo"
+  }
+}
+`, filepath.ToSlash(sinkPath)),
+			},
+		},
+	})
+	s.Empty(client.sentEvents())
+	s.Zero(client.discoverCalls)
+	content, err := os.ReadFile(sinkPath)
+	s.Require().NoError(err)
+	var events []string
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		tags := make(map[string]string)
+		s.Require().NoError(json.Unmarshal([]byte(line), &tags))
+		s.Equal("foo", tags["module_source"])
+		events = append(events, tags["event"])
+	}
+	s.Contains(events, "create")
+	s.Contains(events, "delete")
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_fileSinkShouldNotSendOverHttp() {
+	t := s.T()
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.contoso.com"}
+	sinkPath := filepath.Join(t.TempDir(), "events.jsonl")
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  module_source_regex = ["foo"]
+  sink                = "file"
+  sink_path           = "%s"
+}
+
+resource "modtm_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+  }
+}
+`, filepath.ToSlash(sinkPath)),
+			},
+		},
+	})
+	s.Empty(client.sentEvents())
+	s.Zero(client.discoverCalls)
+	content, err := os.ReadFile(sinkPath)
+	s.Require().NoError(err)
+	s.Contains(string(content), `"event":"create"`)
+	s.Contains(string(content), `"event":"delete"`)
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_fileSinkRequiresSinkPath() {
+	t := s.T()
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(&fakeTelemetryClient{}),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "modtm" {
+  module_source_regex = ["foo"]
+  sink                = "file"
+}
+
+resource "modtm_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile("sink_path"),
+			},
+		},
+	})
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_identity() {
+	t := s.T()
+	client := &fakeTelemetryClient{}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_12_0),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "modtm" {
+  module_source_regex = ["foo"]
+}
+
+resource "modtm_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+  }
+}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectIdentityValueMatchesState("modtm_telemetry.test", tfjsonpath.New("id")),
+				},
+			},
+		},
+	})
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_import() {
+	t := s.T()
+	client := &fakeTelemetryClient{}
+	config := `
+provider "modtm" {
+  module_source_regex = ["foo"]
+}
+
+resource "modtm_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+  }
+}
+`
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+			},
+			{
+				Config:        config,
+				ResourceName:  "modtm_telemetry.test",
+				ImportState:   true,
+				ImportStateId: "00000000-0000-0000-0000-000000000000",
+				ExpectError:   regexp.MustCompile("Invalid import ID"),
+			},
+			{
+				Config:       config,
+				ResourceName: "modtm_telemetry.test",
+				ImportState:  true,
+				ImportStateIdFunc: func(state *terraform.State) (string, error) {
+					return state.RootModule().Resources["modtm_telemetry.test"].Primary.ID + ":" + base64.StdEncoding.EncodeToString([]byte(`{"module_source":"foo"}`)), nil
+				},
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_insecureEndpointIsRejected() {
+	t := s.T()
+	client := &fakeTelemetryClient{}
+	config := func(allowInsecure bool) string {
+		return fmt.Sprintf(`
+provider "modtm" {
+  module_source_regex     = ["foo"]
+  endpoint                = "http://telemetry.contoso.com"
+  allow_insecure_endpoint = %t
+}
+
+resource "modtm_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+  }
+}
+`, allowInsecure)
+	}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config:      config(false),
+				ExpectError: regexp.MustCompile("Insecure endpoint"),
+			},
+			{
+				Config: config(true),
+			},
+		},
+	})
+	s.Require().NotEmpty(client.sentEvents())
+	s.Equal("http://telemetry.contoso.com", client.sentEvents()[0].endpoint)
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_samplingRules() {
+	t := s.T()
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.contoso.com"}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "modtm" {
+  module_source_regex = [".*"]
+  sampling_rules = [
+    {
+      module_source_regex = "^foo$"
+      rate                = 1
+    },
+    {
+      module_source_regex = ".*"
+      rate                = 0
+    },
+  ]
+}
+
+resource "modtm_telemetry" "foo" {
+  tags = {
+    module_source = "foo"
+  }
+}
+
+resource "modtm_telemetry" "bar" {
+  tags = {
+    module_source = "bar"
+  }
+}
+`,
+			},
+		},
+	})
+	events := client.sentEvents()
+	s.NotEmpty(events)
+	for _, e := range events {
+		s.Equal("foo", e.tags["module_source"])
+	}
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_destroyPlannedEvent() {
+	t := s.T()
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.contoso.com"}
+	provider := `
+provider "modtm" {
+  module_source_regex   = ["foo"]
+  destroy_planned_event = true
+}
+`
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: provider + `
+resource "modtm_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+  }
+}
+`,
+			},
+			{
+				Config: provider,
+			},
+		},
+	})
+	var events []string
+	for _, e := range client.sentEvents() {
+		events = append(events, e.tags["event"])
+	}
+	s.Contains(events, destroyPlannedEvent)
+	s.Less(slices.Index(events, destroyPlannedEvent), slices.Index(events, "delete"))
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_triggers() {
+	t := s.T()
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.contoso.com"}
+	config := func(trigger string) string {
+		return fmt.Sprintf(`
+provider "modtm" {
+  module_source_regex = ["foo"]
+}
+
+resource "modtm_telemetry" "test" {
+  tags = {
+    module_source = "foo"
+  }
+  triggers = {
+    module_version = %q
+  }
+}
+`, trigger)
+	}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: config("1.0.0"),
+			},
+			{
+				Config: config("1.1.0"),
+				Check:  resource.TestCheckResourceAttr("modtm_telemetry.test", "triggers.module_version", "1.1.0"),
+			},
+		},
+	})
+	updates := 0
+	for _, e := range client.sentEvents() {
+		if e.tags["event"] == "update" {
+			updates++
+		}
+		_, ok := e.tags["module_version"]
+		s.False(ok, "triggers must not be sent")
+	}
+	s.Equal(1, updates)
+}
+
+func (s *accTelemetryResourceSuite) TestAccTelemetryResource_routes() {
+	t := s.T()
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.contoso.com"}
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "modtm" {
+  module_source_regex = [".*"]
+  routes = [
+    {
+      endpoint            = "https://mirror.contoso.com"
+      events              = ["create", "delete"]
+      module_source_regex = "^registry.terraform.io/MyOrg/"
+    },
+  ]
+}
+
+resource "modtm_telemetry" "mine" {
+  tags = {
+    module_source = "registry.terraform.io/MyOrg/foo/azurerm"
+  }
+}
+
+resource "modtm_telemetry" "other" {
+  tags = {
+    module_source = "registry.terraform.io/Azure/avm-res-foo/azurerm"
+  }
+}
+`,
+			},
+		},
+	})
+	mirrored := make(map[string][]string)
+	var sent []string
+	for _, e := range client.sentEvents() {
+		if e.endpoint == "https://mirror.contoso.com" {
+			mirrored[e.tags["module_source"]] = append(mirrored[e.tags["module_source"]], e.tags["event"])
+			continue
+		}
+		s.Equal("https://telemetry.contoso.com", e.endpoint)
+		sent = append(sent, e.tags["module_source"])
+	}
+	s.Equal(map[string][]string{"registry.terraform.io/MyOrg/foo/azurerm": {"create", "delete"}}, mirrored)
+	s.Contains(sent, "registry.terraform.io/MyOrg/foo/azurerm")
+	s.Contains(sent, "registry.terraform.io/Azure/avm-res-foo/azurerm")
+}
+
+func TestSendTags(t *testing.T) {
+	cases := []struct {
+		desc                           string
+		enabled                        bool
+		offline                        bool
+		defaultEndpointOnProviderBlock bool
+		resourceEndpoint               string
+		moduleSource                   string
+		expectedEndpoints              []string
+	}{
+		{
+			desc:                           "send to provider endpoint",
+			enabled:                        true,
+			defaultEndpointOnProviderBlock: true,
+			moduleSource:                   "foo",
+			expectedEndpoints:              []string{"https://provider.contoso.com"},
+		},
+		{
+			desc:                           "resource endpoint overrides default provider endpoint",
+			enabled:                        true,
+			defaultEndpointOnProviderBlock: true,
+			resourceEndpoint:               "https://resource.contoso.com",
+			moduleSource:                   "foo",
+			expectedEndpoints:              []string{"https://resource.contoso.com"},
+		},
+		{
+			desc:              "explicit provider endpoint overrides resource endpoint",
+			enabled:           true,
+			resourceEndpoint:  "https://resource.contoso.com",
+			moduleSource:      "foo",
+			expectedEndpoints: []string{"https://provider.contoso.com"},
+		},
+		{
+			desc:         "disabled provider sends nothing",
+			enabled:      false,
+			moduleSource: "foo",
+		},
+		{
+			desc:             "offline provider sends nothing",
+			enabled:          true,
+			offline:          true,
+			resourceEndpoint: "https://resource.contoso.com",
+			moduleSource:     "foo",
+		},
+		{
+			desc:         "event dropped by pipeline",
+			enabled:      true,
+			moduleSource: "bar",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			client := &fakeTelemetryClient{}
+			res := &TelemetryResource{
+				providerEndpointFunc: func() string {
+					return "https://provider.contoso.com"
+				},
+				enabled:                        c.enabled,
+				offline:                        c.offline,
+				defaultEndpointOnProviderBlock: c.defaultEndpointOnProviderBlock,
+				pipeline:                       eventPipeline{moduleSourceFilterStage([]*regexp.Regexp{regexp.MustCompile("foo")}, nil)},
+				sequence:                       &eventSequence{},
+				client:                         client,
+			}
+			endpoint := types.StringNull()
+			if c.resourceEndpoint != "" {
+				endpoint = types.StringValue(c.resourceEndpoint)
+			}
+			model := &TelemetryResourceModel{
+				Id:       types.StringValue("00000000-0000-0000-0000-000000000000"),
+				Tags:     types.MapValueMust(types.StringType, map[string]attr.Value{"module_source": types.StringValue(c.moduleSource)}),
+				Endpoint: endpoint,
+			}
+			model.sendTags(context.Background(), res, "create", nil)
+			var endpoints []string
+			for _, sent := range client.sentEvents() {
+				endpoints = append(endpoints, sent.endpoint)
+				assert.Equal(t, "create", sent.tags["event"])
+				assert.Equal(t, "1", sent.tags["sequence"])
+			}
+			assert.Equal(t, c.expectedEndpoints, endpoints)
+		})
+	}
+}
+
+func TestSendTags_providerTags(t *testing.T) {
+	client := &fakeTelemetryClient{}
+	res := &TelemetryResource{
+		providerEndpointFunc: func() string {
+			return "https://provider.contoso.com"
+		},
+		enabled:      true,
+		sequence:     &eventSequence{},
+		client:       client,
+		providerTags: map[string]string{"environment": "prod", "team": "platform", "event": "ignored"},
+	}
+	model := &TelemetryResourceModel{
+		Id: types.StringValue("00000000-0000-0000-0000-000000000000"),
+		Tags: types.MapValueMust(types.StringType, map[string]attr.Value{
+			"module_source": types.StringValue("foo"),
+			"team":          types.StringValue("avm"),
+		}),
+		Endpoint: types.StringNull(),
+	}
+	model.sendTags(context.Background(), res, "create", nil)
+	sent := client.sentEvents()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "prod", sent[0].tags["environment"])
+	assert.Equal(t, "avm", sent[0].tags["team"])
+	assert.Equal(t, "create", sent[0].tags["event"])
+}
+
+func TestSendTags_resourceEnabledOverridesProvider(t *testing.T) {
+	cases := []struct {
+		desc            string
+		providerEnabled bool
+		enabled         types.Bool
+		expectedSent    bool
+	}{
+		{desc: "provider_default", providerEnabled: true, enabled: types.BoolNull(), expectedSent: true},
+		{desc: "resource_disabled", providerEnabled: true, enabled: types.BoolValue(false), expectedSent: false},
+		{desc: "resource_enabled", providerEnabled: false, enabled: types.BoolValue(true), expectedSent: true},
+		{desc: "provider_disabled", providerEnabled: false, enabled: types.BoolNull(), expectedSent: false},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			client := &fakeTelemetryClient{}
+			res := &TelemetryResource{
+				providerEndpointFunc: func() string {
+					return "https://provider.contoso.com"
+				},
+				enabled:  c.providerEnabled,
+				sequence: &eventSequence{},
+				client:   client,
+			}
+			model := &TelemetryResourceModel{
+				Id:       types.StringValue("00000000-0000-0000-0000-000000000000"),
+				Tags:     types.MapValueMust(types.StringType, map[string]attr.Value{"module_source": types.StringValue("foo")}),
+				Endpoint: types.StringNull(),
+				Enabled:  c.enabled,
+			}
+			model.sendTags(context.Background(), res, "create", nil)
+			assert.Equal(t, c.expectedSent, len(client.sentEvents()) == 1)
+		})
+	}
+}
+
+func TestReadTags_moduleSourceAndVersion(t *testing.T) {
+	model := &TelemetryResourceModel{
+		Tags: types.MapValueMust(types.StringType, map[string]attr.Value{
+			"module_version": types.StringValue("explicit"),
+		}),
+		ModuleSource:  types.StringValue("registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm"),
+		ModuleVersion: types.StringValue("0.6.1"),
+	}
+	assert.Equal(t, map[string]string{
+		"module_source":  "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm",
+		"module_version": "explicit",
+	}, model.readTags())
+	model.ModuleSource = types.StringNull()
+	model.ModuleVersion = types.StringUnknown()
+	assert.Equal(t, map[string]string{"module_version": "explicit"}, model.readTags())
+}
+
+func TestAccTelemetryResource_modulePathDrift(t *testing.T) {
+	dataDir := t.TempDir()
+	writeModulesJson := func(version string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "modules"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dataDir, "modules", "modules.json"), []byte(fmt.Sprintf(`{
+  "Modules": [
+    {
+      "Key": "kv",
+      "Source": "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm",
+      "Version": %q,
+      "Dir": ".terraform/modules/kv"
+    }
+  ]
+}`, version)), 0600))
+	}
+	writeModulesJson("0.6.1")
+	client := &fakeTelemetryClient{endpoint: "https://telemetry.contoso.com"}
+	config := fmt.Sprintf(`
+provider "modtm" {
+  module_source_regex = ["avm-res-keyvault-vault"]
+  modules_json_path   = %q
+}
+
+resource "modtm_telemetry" "test" {
+  module_path = ".terraform/modules/kv"
+  tags = {
+    avm_git_file = "main.tf"
+  }
+}
+`, dataDir)
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithClient(client),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("modtm_telemetry.test", "module_source", "registry.terraform.io/Azure/avm-res-keyvault-vault/azurerm"),
+					resource.TestCheckResourceAttr("modtm_telemetry.test", "module_version", "0.6.1"),
+				),
+			},
+			{
+				PreConfig: func() { writeModulesJson("0.7.0") },
+				Config:    config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("modtm_telemetry.test", "module_version", "0.7.0"),
+				),
+			},
+		},
+	})
+	var versions []string
+	for _, e := range client.sentEvents() {
+		if e.tags["event"] == "create" || e.tags["event"] == "update" {
+			versions = append(versions, e.tags["module_version"])
+		}
+	}
+	assert.Equal(t, []string{"0.6.1", "0.7.0"}, versions)
+}
+
+func TestSendTags_failedDeleteIsSpooled(t *testing.T) {
+	client := &fakeTelemetryClient{sendErr: errors.New("outage")}
+	res := &TelemetryResource{
+		providerEndpointFunc: func() string {
+			return "https://provider.contoso.com"
+		},
+		enabled:  true,
+		sequence: &eventSequence{},
+		client:   client,
+		spool:    newEventSpool(t.TempDir()),
+	}
+	model := &TelemetryResourceModel{
+		Id:       types.StringValue("00000000-0000-0000-0000-000000000000"),
+		Tags:     types.MapValueMust(types.StringType, map[string]attr.Value{"module_source": types.StringValue("foo")}),
+		Endpoint: types.StringNull(),
+	}
+	attempt := model.sendTags(context.Background(), res, "update", nil)
+	require.NotNil(t, attempt)
+	assert.Error(t, attempt.err)
+	entries, err := os.ReadDir(res.spool.dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	attempt = model.sendTags(context.Background(), res, "delete", nil)
+	require.NotNil(t, attempt)
+	entries, err = os.ReadDir(res.spool.dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestSendTags_requestTimeout(t *testing.T) {
+	ms := newMockServer()
+	defer ms.close()
+	delay := 200 * time.Millisecond
+	ms.delay = &delay
+	res := &TelemetryResource{
+		providerEndpointFunc: ms.serverUrl,
+		enabled:              true,
+		sequence:             &eventSequence{},
+		client:               newHttpTelemetryClient(http.DefaultClient, payloadEncodingJSON),
+		requestTimeout:       50 * time.Millisecond,
+	}
+	model := &TelemetryResourceModel{
+		Id:             types.StringValue("00000000-0000-0000-0000-000000000000"),
+		Tags:           types.MapValueMust(types.StringType, map[string]attr.Value{"module_source": types.StringValue("foo")}),
+		Endpoint:       types.StringNull(),
+		RequestTimeout: types.StringNull(),
+	}
+	attempt := model.sendTags(context.Background(), res, "create", nil)
+	require.NotNil(t, attempt)
+	assert.ErrorContains(t, attempt.err, "timeout")
+
+	model.RequestTimeout = types.StringValue("1s")
+	attempt = model.sendTags(context.Background(), res, "update", nil)
+	require.NotNil(t, attempt)
+	assert.NoError(t, attempt.err)
+}
+
 type ChaosTestSuite struct {
 	suite.Suite
 	ms             *mockServer
@@ -629,6 +1576,7 @@ func (s *ChaosTestSuite) TestChaosTelemetryResource_ReadDefaultUrlFromBlobServer
 		s.T().Skip("chaos tests only run when there's `CHAOS` environment variable.")
 	}
 	stub := gostub.Stub(&endpointBlobUrl, fmt.Sprintf("http://%s", s.toxiBlobServer.Listen))
+	stub.Stub(&discoveredEndpointPolicy, testEndpointPolicy)
 	defer stub.Reset()
 
 	streams := []string{
@@ -659,6 +1607,7 @@ func (s *ChaosTestSuite) TestChaosTelemetryResource_ReadDefaultUrlFromBlobServer
 		s.T().Skip("chaos tests only run when there's `CHAOS` environment variable.")
 	}
 	stub := gostub.Stub(&endpointBlobUrl, fmt.Sprintf("http://%s", s.toxiBlobServer.Listen))
+	stub.Stub(&discoveredEndpointPolicy, testEndpointPolicy)
 	defer stub.Reset()
 
 	streams := []string{
@@ -691,6 +1640,7 @@ func (s *ChaosTestSuite) TestChaosTelemetryResource_ReadDefaultUrlFromBlobServer
 		s.T().Skip("chaos tests only run when there's `CHAOS` environment variable.")
 	}
 	stub := gostub.Stub(&endpointBlobUrl, fmt.Sprintf("http://%s", s.toxiBlobServer.Listen))
+	stub.Stub(&discoveredEndpointPolicy, testEndpointPolicy)
 	defer stub.Reset()
 
 	streams := []string{
@@ -723,6 +1673,7 @@ func (s *ChaosTestSuite) TestChaosTelemetryResource_ReadDefaultUrlFromBlobServer
 		s.T().Skip("chaos tests only run when there's `CHAOS` environment variable.")
 	}
 	stub := gostub.Stub(&endpointBlobUrl, fmt.Sprintf("http://%s", s.toxiBlobServer.Listen))
+	stub.Stub(&discoveredEndpointPolicy, testEndpointPolicy)
 	defer stub.Reset()
 	if err := s.toxiBlobServer.Disable(); err != nil {
 		s.FailNowf(`cannot setup toxiproxy: %s`, err.Error())
@@ -854,6 +1805,13 @@ func assertEventTags(t *testing.T, event string, tags map[string]string, s *mock
 			delete(tagsReceived, "resource_id")
 			delete(tagsReceived, "source")
 			delete(tagsReceived, "version")
+			delete(tagsReceived, "sequence")
+			delete(tagsReceived, "timestamp")
+			delete(tagsReceived, tagChangesTag)
+			delete(tagsReceived, "execution_environment")
+			for k := range runtimeMetadata("", "") {
+				delete(tagsReceived, k)
+			}
 			restPart := tagsReceived
 			if reflect.DeepEqual(restPart, tags) {
 				return
@@ -0,0 +1,51 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var _ resource.ResourceWithUpgradeState = &TelemetryResource{}
+
+// telemetryResourceSchemaVersion is the schema version of `modtm_telemetry`. Bump it when a change of the schema
+// needs the existing state to be migrated, and add an upgrader from the previous version to UpgradeState.
+const telemetryResourceSchemaVersion = 1
+
+// UpgradeState returns the upgraders of the state written by earlier schema versions to the current version.
+// Terraform calls the upgrader of the state's version only, so every upgrader must produce the current version.
+func (r *TelemetryResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		// Version 0 is the schema before versioning was introduced, the attributes are unchanged in version 1.
+		0: {
+			StateUpgrader: r.carryOverState,
+		},
+	}
+}
+
+// carryOverState upgrades the raw state by keeping the attributes that still exist in the current schema, the
+// attributes missing in the raw state are null and the attributes that have been removed are dropped.
+func (r *TelemetryResource) carryOverState(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	resp.Diagnostics.Append(schemaResp.Diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	raw, err := req.RawState.UnmarshalWithOpts(schemaResp.Schema.Type().TerraformType(ctx), tfprotov6.UnmarshalOpts{
+		ValueFromJSONOpts: tftypes.ValueFromJSONOpts{
+			IgnoreUndefinedAttributes: true,
+		},
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to upgrade state", fmt.Sprintf("Cannot read the prior state of the telemetry resource: %s", err.Error()))
+		return
+	}
+	resp.State.Raw = raw
+}
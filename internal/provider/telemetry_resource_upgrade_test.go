@@ -0,0 +1,57 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelemetryResource_upgradeStateV0(t *testing.T) {
+	ctx := context.Background()
+	r := &TelemetryResource{}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	require.Equal(t, int64(telemetryResourceSchemaVersion), schemaResp.Schema.Version)
+
+	upgrader, ok := r.UpgradeState(ctx)[0]
+	require.True(t, ok)
+	req := resource.UpgradeStateRequest{
+		RawState: &tfprotov6.RawState{
+			JSON: []byte(`{"id":"00000000-0000-0000-0000-000000000000","tags":{"module_source":"foo"},"nonce":null,"removed_attribute":"bar"}`),
+		},
+	}
+	resp := &resource.UpgradeStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema},
+	}
+	upgrader.StateUpgrader(ctx, req, resp)
+	require.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+
+	data := &TelemetryResourceModel{}
+	require.False(t, resp.State.Get(ctx, data).HasError())
+	assert.Equal(t, "00000000-0000-0000-0000-000000000000", data.Id.ValueString())
+	assert.Equal(t, map[string]string{"module_source": "foo"}, data.readTags())
+	assert.True(t, data.ModulePath.IsNull())
+	assert.True(t, data.Endpoints.IsNull())
+}
+
+func TestTelemetryResource_upgradeStateV0_invalidState(t *testing.T) {
+	ctx := context.Background()
+	r := &TelemetryResource{}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	resp := &resource.UpgradeStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema},
+	}
+	r.UpgradeState(ctx)[0].StateUpgrader(ctx, resource.UpgradeStateRequest{
+		RawState: &tfprotov6.RawState{JSON: []byte(`{"tags":"foo"}`)},
+	}, resp)
+	assert.True(t, resp.Diagnostics.HasError())
+}
@@ -0,0 +1,173 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+var _ ephemeral.EphemeralResource = &TelemetrySessionEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithConfigure = &TelemetrySessionEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithClose = &TelemetrySessionEphemeralResource{}
+
+const (
+	sessionOpenEvent  = "open"
+	sessionCloseEvent = "close"
+	// sessionDurationTag is the tag carrying how long the session has been open, sent with the close event.
+	sessionDurationTag = "session_duration_seconds"
+	// telemetrySessionPrivateKey is the key of the session in the ephemeral resource's private data.
+	telemetrySessionPrivateKey = "session"
+)
+
+func NewTelemetrySessionEphemeralResource() ephemeral.EphemeralResource {
+	return &TelemetrySessionEphemeralResource{}
+}
+
+// TelemetrySessionEphemeralResource sends an `open` event when it's opened and a `close` event when it's closed,
+// it delivers the events exactly like `modtm_telemetry` does.
+type TelemetrySessionEphemeralResource struct {
+	sender TelemetryResource
+}
+
+// TelemetrySessionEphemeralResourceModel describes the ephemeral resource data model.
+type TelemetrySessionEphemeralResourceModel struct {
+	Id             types.String `tfsdk:"id"`
+	Tags           types.Map    `tfsdk:"tags"`
+	Endpoint       types.String `tfsdk:"endpoint"`
+	RequestTimeout types.String `tfsdk:"request_timeout"`
+}
+
+// telemetrySession is kept in the private data between Open and Close, so the `close` event carries the same
+// tags as the `open` event.
+type telemetrySession struct {
+	Id             string            `json:"id"`
+	Tags           map[string]string `json:"tags"`
+	Endpoint       string            `json:"endpoint,omitempty"`
+	RequestTimeout string            `json:"request_timeout,omitempty"`
+	OpenedAt       time.Time         `json:"opened_at"`
+}
+
+func (r *TelemetrySessionEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_telemetry_session"
+}
+
+func (r *TelemetrySessionEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: fmt.Sprintf("`modtm_telemetry_session` ephemeral resource sends an `%s` event when Terraform opens it and a `%s` event when Terraform closes it at the end of the operation, giving session-style telemetry, e.g. of CI pipelines, without persisting anything to the state. The `%s` event carries the same tags as the `%s` event, and the `session_duration_seconds` tag. Both events go through the same event pipeline, routes, sink and endpoint as the events of `modtm_telemetry`. Requires Terraform 1.10 or later.", sessionOpenEvent, sessionCloseEvent, sessionCloseEvent, sessionOpenEvent),
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Session identifier, sent as the `resource_id` tag of both events",
+			},
+			"tags": schema.MapAttribute{
+				Required:            true,
+				MarkdownDescription: fmt.Sprintf("Tags to be sent with the events. The following tags are set by the provider, so they're reserved and cannot be used: %s.", markdownCodeList(reservedTags)),
+				ElementType:         basetypes.StringType{},
+				Validators: []validator.Map{
+					mapValidator{},
+				},
+			},
+			"endpoint": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Telemetry endpoint to send the events to, it's used in the same way as `endpoint` of `modtm_telemetry`.",
+				Validators: []validator.String{
+					MustBeValidEndpoint{},
+				},
+			},
+			"request_timeout": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: fmt.Sprintf("How long to wait for the endpoint to respond to an event, e.g. `10s`, no longer than `%s`. Overrides provider's `request_timeout`.", maxRequestTimeout),
+				Validators: []validator.String{
+					MustBeValidDuration{Max: maxRequestTimeout},
+				},
+			},
+		},
+	}
+}
+
+func (r *TelemetrySessionEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	configureResp := &resource.ConfigureResponse{}
+	r.sender.Configure(ctx, resource.ConfigureRequest{ProviderData: req.ProviderData}, configureResp)
+	resp.Diagnostics.Append(configureResp.Diagnostics...)
+}
+
+func (r *TelemetrySessionEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	data := &TelemetrySessionEphemeralResourceModel{}
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.sender.tagLimits.validate(path.Root("tags"), data.Tags)...)
+	resp.Diagnostics.Append(insecureEndpointDiagnostics(path.Root("endpoint"), data.Endpoint.ValueString(), "the ephemeral resource", r.sender.allowInsecureEndpoint)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(uuid.NewString())
+	session := telemetrySession{
+		Id:             data.Id.ValueString(),
+		Tags:           mergeTags(data.Tags),
+		Endpoint:       data.Endpoint.ValueString(),
+		RequestTimeout: data.RequestTimeout.ValueString(),
+	}
+	traceLog(ctx, fmt.Sprintf("open telemetry session with id %s", session.Id))
+	resp.Diagnostics.Append(r.openSession(ctx, resp.Private, session)...)
+	resp.Diagnostics.Append(resp.Result.Set(ctx, data)...)
+}
+
+func (r *TelemetrySessionEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	resp.Diagnostics.Append(r.closeSession(ctx, req.Private)...)
+}
+
+// openSession sends the `open` event and keeps the session in the private data for closeSession.
+func (r *TelemetrySessionEphemeralResource) openSession(ctx context.Context, private privateStateSetter, session telemetrySession) diag.Diagnostics {
+	session.OpenedAt = timeNow()
+	r.sender.sendEvent(ctx, sessionOpenEvent, session.Id, maps.Clone(session.Tags), session.Endpoint, session.RequestTimeout)
+	content, err := json.Marshal(session)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Failed to encode telemetry session", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, telemetrySessionPrivateKey, content)
+}
+
+// closeSession sends the `close` event of the session kept in the private data. A missing or invalid session is
+// ignored, it must never fail the operation.
+func (r *TelemetrySessionEphemeralResource) closeSession(ctx context.Context, private privateStateGetter) diag.Diagnostics {
+	content, diags := private.GetKey(ctx, telemetrySessionPrivateKey)
+	if diags.HasError() || len(content) == 0 {
+		return diags
+	}
+	var session telemetrySession
+	if err := json.Unmarshal(content, &session); err != nil {
+		traceLog(ctx, fmt.Sprintf("ignore invalid telemetry session: %+v", err))
+		return diags
+	}
+	tags := maps.Clone(session.Tags)
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	tags[sessionDurationTag] = strconv.FormatInt(int64(timeNow().Sub(session.OpenedAt)/time.Second), 10)
+	traceLog(ctx, fmt.Sprintf("close telemetry session with id %s", session.Id))
+	r.sender.sendEvent(ctx, sessionCloseEvent, session.Id, tags, session.Endpoint, session.RequestTimeout)
+	return diags
+}
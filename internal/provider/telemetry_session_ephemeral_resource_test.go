@@ -0,0 +1,57 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelemetrySession_openAndClose(t *testing.T) {
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return now
+	})
+	defer stub.Reset()
+	client := &fakeTelemetryClient{}
+	r := &TelemetrySessionEphemeralResource{sender: TelemetryResource{
+		providerEndpointFunc: func() string {
+			return "https://provider.contoso.com"
+		},
+		enabled:  true,
+		sequence: &eventSequence{},
+		client:   client,
+	}}
+	ctx := context.Background()
+	private := fakePrivateState{}
+
+	session := telemetrySession{Id: "00000000-0000-0000-0000-000000000000", Tags: map[string]string{"pipeline": "release"}}
+	require.False(t, r.openSession(ctx, private, session).HasError())
+	now = now.Add(90 * time.Second)
+	require.False(t, r.closeSession(ctx, private).HasError())
+
+	sent := client.sentEvents()
+	require.Len(t, sent, 2)
+	assert.Equal(t, sessionOpenEvent, sent[0].tags["event"])
+	assert.NotContains(t, sent[0].tags, "session_duration_seconds")
+	assert.Equal(t, sessionCloseEvent, sent[1].tags["event"])
+	assert.Equal(t, "90", sent[1].tags["session_duration_seconds"])
+	for _, e := range sent {
+		assert.Equal(t, "release", e.tags["pipeline"])
+		assert.Equal(t, session.Id, e.tags["resource_id"])
+	}
+}
+
+func TestTelemetrySession_closeWithoutSessionIsIgnored(t *testing.T) {
+	client := &fakeTelemetryClient{}
+	r := &TelemetrySessionEphemeralResource{sender: TelemetryResource{enabled: true, sequence: &eventSequence{}, client: client}}
+	assert.False(t, r.closeSession(context.Background(), fakePrivateState{}).HasError())
+	assert.False(t, r.closeSession(context.Background(), fakePrivateState{telemetrySessionPrivateKey: []byte("{")}).HasError())
+	assert.Empty(t, client.sentEvents())
+}
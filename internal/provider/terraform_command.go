@@ -0,0 +1,35 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// detectTerraformCommand returns the subcommand of the Terraform CLI process that launched the provider,
+// e.g. `plan`, `apply` or `test`, or an empty string if it cannot be detected.
+var detectTerraformCommand = func() string {
+	return terraformSubcommand(parentProcessArgs())
+}
+
+// terraformSubcommand returns the subcommand in the given Terraform CLI arguments, global options like
+// `-chdir=DIR` are skipped. args[0] is the executable, an empty string is returned when it's not a
+// Terraform (or OpenTofu) executable.
+func terraformSubcommand(args []string) string {
+	if len(args) < 2 {
+		return ""
+	}
+	executable := strings.ToLower(filepath.Base(args[0]))
+	if !strings.Contains(executable, "terraform") && !strings.Contains(executable, "tofu") {
+		return ""
+	}
+	for _, arg := range args[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		return arg
+	}
+	return ""
+}
@@ -0,0 +1,11 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !linux
+
+package provider
+
+// parentProcessArgs returns nil since reading other process' command line is not supported on this platform.
+func parentProcessArgs() []string {
+	return nil
+}
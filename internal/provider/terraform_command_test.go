@@ -0,0 +1,30 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerraformSubcommand(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"terraform", "test"}, expected: "test"},
+		{args: []string{"/usr/bin/terraform", "-chdir=tests", "test", "-verbose"}, expected: "test"},
+		{args: []string{"terraform", "apply", "-auto-approve"}, expected: "apply"},
+		{args: []string{`C:\tools\terraform.exe`, "test"}, expected: "test"},
+		{args: []string{"tofu", "test"}, expected: "test"},
+		{args: []string{"go", "test", "./..."}, expected: ""},
+		{args: []string{"provider.test", "-test.v"}, expected: ""},
+		{args: []string{"terraform"}, expected: ""},
+		{args: nil, expected: ""},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, terraformSubcommand(c.args), "%v", c.args)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &TerraformMetadataDataSource{}
+var _ datasource.DataSourceWithConfigure = &TerraformMetadataDataSource{}
+
+type TerraformMetadataDataSource struct {
+	terraformVersion string
+	terraformCommand string
+}
+
+func NewTerraformMetadataDataSource() datasource.DataSource {
+	return &TerraformMetadataDataSource{}
+}
+
+type TerraformMetadataDataSourceModel struct {
+	TerraformVersion types.String `tfsdk:"terraform_version"`
+	Workspace        types.String `tfsdk:"workspace"`
+	DataDir          types.String `tfsdk:"data_dir"`
+	Operation        types.String `tfsdk:"operation"`
+}
+
+func (m *TerraformMetadataDataSource) Metadata(ctx context.Context, request datasource.MetadataRequest, response *datasource.MetadataResponse) {
+	response.TypeName = request.ProviderTypeName + "_terraform_metadata"
+}
+
+func (m *TerraformMetadataDataSource) Schema(ctx context.Context, request datasource.SchemaRequest, response *datasource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		MarkdownDescription: "`modtm_terraform_metadata` data source exposes information about the Terraform run that the provider is launched by, so modules could build conditional telemetry logic without shelling out.",
+		Attributes: map[string]schema.Attribute{
+			"terraform_version": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The version of Terraform CLI, as reported to the provider during configuration",
+			},
+			"workspace": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The selected workspace, read from `TF_WORKSPACE` environment variable or the `environment` file in the Terraform data dir. Defaults to `default`.",
+			},
+			"data_dir": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The Terraform data dir, `TF_DATA_DIR` environment variable or `.terraform`",
+			},
+			"operation": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The Terraform CLI subcommand that is running, e.g. `plan`, `apply` or `test`. Null when it cannot be detected, detection relies on the command line of the Terraform process and is only supported on Linux.",
+			},
+		},
+	}
+}
+
+func (m *TerraformMetadataDataSource) Configure(ctx context.Context, request datasource.ConfigureRequest, response *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if request.ProviderData == nil {
+		return
+	}
+
+	c, ok := request.ProviderData.(providerConfig)
+
+	if !ok {
+		response.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected providerConfig, got: %T. Please report this issue to the provider developers.", request.ProviderData),
+		)
+
+		return
+	}
+
+	m.terraformVersion = c.terraformVersion
+	m.terraformCommand = c.terraformCommand
+}
+
+func (m *TerraformMetadataDataSource) Read(ctx context.Context, request datasource.ReadRequest, response *datasource.ReadResponse) {
+	data := &TerraformMetadataDataSourceModel{}
+	response.Diagnostics.Append(request.Config.Get(ctx, data)...)
+
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	dataDir := envOrDefault("TF_DATA_DIR", ".terraform")
+	data.TerraformVersion = types.StringValue(m.terraformVersion)
+	data.Workspace = types.StringValue(terraformWorkspace(dataDir))
+	data.DataDir = types.StringValue(dataDir)
+	data.Operation = types.StringNull()
+	if m.terraformCommand != "" {
+		data.Operation = types.StringValue(m.terraformCommand)
+	}
+	traceLog(ctx, fmt.Sprintf("read terraform metadata, version: %s, workspace: %s, operation: %s", data.TerraformVersion.String(), data.Workspace.String(), data.Operation.String()))
+	response.Diagnostics.Append(response.State.Set(ctx, data)...)
+}
+
+// terraformWorkspace returns the selected workspace the same way Terraform CLI does: `TF_WORKSPACE`
+// environment variable takes precedence over the `environment` file in the data dir.
+func terraformWorkspace(dataDir string) string {
+	if workspace := os.Getenv("TF_WORKSPACE"); workspace != "" {
+		return workspace
+	}
+	content, err := os.ReadFile(filepath.Clean(filepath.Join(dataDir, "environment")))
+	if err != nil {
+		return "default"
+	}
+	if workspace := strings.TrimSpace(string(content)); workspace != "" {
+		return workspace
+	}
+	return "default"
+}
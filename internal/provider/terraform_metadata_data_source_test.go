@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccTerraformMetadataDataSource(t *testing.T) {
+	stub := gostub.Stub(&detectTerraformCommand, func() string {
+		return "apply"
+	})
+	defer stub.Reset()
+	t.Setenv("TF_WORKSPACE", "staging")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "modtm" {
+  enabled = false
+  module_source_regex = ["foo"]
+}
+
+data "modtm_terraform_metadata" "test" {}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("data.modtm_terraform_metadata.test", "terraform_version", regexp.MustCompile(`^\d+\.\d+\.\d+`)),
+					resource.TestCheckResourceAttr("data.modtm_terraform_metadata.test", "workspace", "staging"),
+					resource.TestCheckResourceAttr("data.modtm_terraform_metadata.test", "data_dir", ".terraform"),
+					resource.TestCheckResourceAttr("data.modtm_terraform_metadata.test", "operation", "apply"),
+				),
+			},
+		},
+	})
+}
+
+func TestTerraformWorkspace(t *testing.T) {
+	dataDir := t.TempDir()
+	assert.Equal(t, "default", terraformWorkspace(dataDir))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "environment"), []byte("dev\n"), 0600))
+	assert.Equal(t, "dev", terraformWorkspace(dataDir))
+	t.Setenv("TF_WORKSPACE", "prod")
+	assert.Equal(t, "prod", terraformWorkspace(dataDir))
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// eventThrottle suppresses repeated events of the same module source, version and event name within window, so
+// tight plan/apply loops during development don't flood the endpoint. The time every key was last let through is
+// cached in a local file, shared by all provider processes on the machine. A nil *eventThrottle lets everything through.
+type eventThrottle struct {
+	mu     sync.Mutex
+	path   string
+	window time.Duration
+}
+
+func newEventThrottle(path string, window time.Duration) *eventThrottle {
+	if window <= 0 || path == "" {
+		return nil
+	}
+	return &eventThrottle{path: path, window: window}
+}
+
+// defaultThrottleCachePath returns the throttle cache file in the user's cache directory, or an empty string if
+// there's no such directory.
+func defaultThrottleCachePath() string {
+	return userCachePath("throttle.json")
+}
+
+// allow returns true if the key hasn't been let through within the window, and records it as let through now.
+// The cache is best effort: when it cannot be read or written the event is let through.
+func (t *eventThrottle) allow(key string) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := timeNow().UTC()
+	cache := make(map[string]time.Time)
+	if content, err := os.ReadFile(t.path); err == nil {
+		_ = json.Unmarshal(content, &cache)
+	}
+	if last, ok := cache[key]; ok && now.Sub(last) < t.window {
+		return false
+	}
+	cache[key] = now
+	for k, last := range cache {
+		if now.Sub(last) >= t.window {
+			delete(cache, k)
+		}
+	}
+	_ = t.write(cache)
+	return true
+}
+
+// write replaces the cache file atomically.
+func (t *eventThrottle) write(cache map[string]time.Time) error {
+	content, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(t.path, content)
+}
+
+// throttleStage drops the event if an event with the same `module_source`, `module_version` and name has been let
+// through within the throttle window.
+func throttleStage(throttle *eventThrottle) eventStage {
+	return eventStage{
+		name: stageThrottle,
+		process: func(ctx context.Context, e *telemetryEvent) bool {
+			return throttle.allow(strings.Join([]string{e.tags["module_source"], e.tags["module_version"], e.name}, "|"))
+		},
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/prashantv/gostub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventThrottle_allow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	stub := gostub.Stub(&timeNow, func() time.Time {
+		return now
+	})
+	defer stub.Reset()
+	path := filepath.Join(t.TempDir(), "modtm", "throttle.json")
+	throttle := newEventThrottle(path, 24*time.Hour)
+
+	assert.True(t, throttle.allow("foo|1.0.0|create"))
+	assert.False(t, throttle.allow("foo|1.0.0|create"))
+	assert.True(t, throttle.allow("foo|1.1.0|create"))
+	assert.True(t, throttle.allow("foo|1.0.0|update"))
+
+	// Another provider process on the same machine shares the cache.
+	assert.False(t, newEventThrottle(path, 24*time.Hour).allow("foo|1.0.0|create"))
+
+	now = now.Add(24 * time.Hour)
+	assert.True(t, throttle.allow("foo|1.0.0|create"))
+}
+
+func TestEventThrottle_corruptedCacheLetsEventsThrough(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "throttle.json")
+	assert.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+	throttle := newEventThrottle(path, time.Hour)
+	assert.True(t, throttle.allow("foo|1.0.0|create"))
+	assert.False(t, throttle.allow("foo|1.0.0|create"))
+}
+
+func TestEventThrottle_nilLetsEverythingThrough(t *testing.T) {
+	throttle := newEventThrottle(filepath.Join(t.TempDir(), "throttle.json"), 0)
+	assert.Nil(t, throttle)
+	assert.True(t, throttle.allow("foo|1.0.0|create"))
+	assert.True(t, throttle.allow("foo|1.0.0|create"))
+}
+
+func TestAccTelemetryResource_throttle(t *testing.T) {
+	ms := newMockServer()
+	defer ms.close()
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "modtm" {
+  endpoint            = "%s"
+  module_source_regex = ["foo"]
+  throttle_window     = "24h"
+  throttle_cache_path = "%s"
+}
+
+resource "modtm_telemetry" "test" {
+  count = 3
+  tags = {
+    module_source  = "foo"
+    module_version = "1.0.0"
+  }
+}
+`, ms.serverUrl(), filepath.ToSlash(filepath.Join(t.TempDir(), "throttle.json"))),
+			},
+		},
+	})
+	events := make(map[string]int)
+	for _, tags := range ms.tags {
+		events[tags["event"]]++
+	}
+	assert.Equal(t, 1, events["create"])
+	assert.Equal(t, 1, events["delete"])
+}
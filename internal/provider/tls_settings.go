@@ -0,0 +1,59 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// tlsSettings customize the TLS configuration of outgoing requests, e.g. to reach a collector behind a private CA.
+type tlsSettings struct {
+	// rootCAs are the system roots plus the custom CA certificates, nil means the system roots only.
+	rootCAs            *x509.CertPool
+	certificates       []tls.Certificate
+	insecureSkipVerify bool
+}
+
+// newTLSSettings parses the PEM encoded certificates, the client certificate and key must be set together.
+func newTLSSettings(caCertPem, clientCertPem, clientKeyPem string, insecureSkipVerify bool) (tlsSettings, error) {
+	s := tlsSettings{insecureSkipVerify: insecureSkipVerify}
+	if caCertPem != "" {
+		// Keep the system roots so public endpoints, e.g. the default endpoint discovery, are still trusted.
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(caCertPem)) {
+			return tlsSettings{}, errors.New("no valid certificate found in `ca_cert_pem`")
+		}
+		s.rootCAs = pool
+	}
+	if clientCertPem != "" || clientKeyPem != "" {
+		cert, err := tls.X509KeyPair([]byte(clientCertPem), []byte(clientKeyPem))
+		if err != nil {
+			return tlsSettings{}, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		s.certificates = []tls.Certificate{cert}
+	}
+	return s, nil
+}
+
+// apply returns config with the settings applied, config is not modified. nil config means Go's defaults.
+func (s tlsSettings) apply(config *tls.Config) *tls.Config {
+	if s.rootCAs == nil && len(s.certificates) == 0 && !s.insecureSkipVerify {
+		return config
+	}
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+	config.RootCAs = s.rootCAs
+	config.Certificates = s.certificates
+	config.InsecureSkipVerify = s.insecureSkipVerify // #nosec G402 -- opted in by `insecure_skip_verify`
+	return config
+}
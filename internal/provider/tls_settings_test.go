@@ -0,0 +1,106 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serverCertPem(server *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+}
+
+func newClientCertPem(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "modtm"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+}
+
+func TestTLSSettings_customCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer server.Close()
+
+	_, err := newHTTPClient(cryptoPolicy{}, proxySettings{}, tlsSettings{}).Get(server.URL)
+	require.Error(t, err)
+
+	settings, err := newTLSSettings(serverCertPem(server), "", "", false)
+	require.NoError(t, err)
+	resp, err := newHTTPClient(cryptoPolicy{}, proxySettings{}, settings).Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+}
+
+func TestTLSSettings_clientCertificate(t *testing.T) {
+	var clientCerts int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		clientCerts = len(request.TLS.PeerCertificates)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	certPem, keyPem := newClientCertPem(t)
+	settings, err := newTLSSettings(serverCertPem(server), certPem, keyPem, false)
+	require.NoError(t, err)
+	resp, err := newHTTPClient(cryptoPolicy{}, proxySettings{}, settings).Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 1, clientCerts)
+}
+
+func TestTLSSettings_insecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer server.Close()
+
+	settings, err := newTLSSettings("", "", "", true)
+	require.NoError(t, err)
+	resp, err := newHTTPClient(cryptoPolicy{}, proxySettings{}, settings).Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+}
+
+func TestTLSSettings_invalid(t *testing.T) {
+	_, err := newTLSSettings("not a certificate", "", "", false)
+	assert.Error(t, err)
+	certPem, _ := newClientCertPem(t)
+	_, otherKeyPem := newClientCertPem(t)
+	_, err = newTLSSettings("", certPem, otherKeyPem, false)
+	assert.Error(t, err)
+}
+
+func TestTLSSettings_keepsFipsPolicy(t *testing.T) {
+	settings, err := newTLSSettings("", "", "", true)
+	require.NoError(t, err)
+	policy := cryptoPolicy{fipsMode: true}
+	config := settings.apply(policy.tlsConfig())
+	assert.True(t, config.InsecureSkipVerify)
+	assert.Equal(t, policy.tlsConfig().CipherSuites, config.CipherSuites)
+	assert.Nil(t, tlsSettings{}.apply(nil))
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &UuidV5Function{}
+
+// uuidNamespaces are the well-known namespaces defined by RFC 4122.
+var uuidNamespaces = map[string]uuid.UUID{
+	"dns":  uuid.NameSpaceDNS,
+	"url":  uuid.NameSpaceURL,
+	"oid":  uuid.NameSpaceOID,
+	"x500": uuid.NameSpaceX500,
+}
+
+func NewUuidV5Function() function.Function {
+	return &UuidV5Function{}
+}
+
+type UuidV5Function struct {
+}
+
+func (m *UuidV5Function) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "uuidv5"
+}
+
+func (m *UuidV5Function) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "`uuidv5` function",
+		MarkdownDescription: "This function derives a name-based UUID (version 5, RFC 4122) from a namespace and a name, so stable identifiers like resource ids, trace ids or correlation keys could be derived purely in HCL. " +
+			"The same namespace and name always produce the same UUID, and the result is identical to Terraform's built-in `uuidv5` function.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "namespace",
+				MarkdownDescription: "Either a UUID, or one of the well-known namespaces `dns`, `url`, `oid` and `x500`",
+			},
+			function.StringParameter{
+				Name:                "name",
+				MarkdownDescription: "The name to derive the UUID from",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (m *UuidV5Function) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var namespace, name string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &namespace, &name))
+	if resp.Error != nil {
+		return
+	}
+	ns, ok := uuidNamespaces[strings.ToLower(namespace)]
+	if !ok {
+		var err error
+		if ns, err = uuid.Parse(namespace); err != nil {
+			resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("invalid namespace %q, must be a UUID or one of dns, url, oid, x500", namespace))
+			return
+		}
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, uuid.NewSHA1(ns, []byte(name)).String()))
+}
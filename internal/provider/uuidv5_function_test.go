@@ -0,0 +1,53 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestAccUuidV5Function(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccUuidV5FunctionConfig("dns", "python.org"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "886313e1-3b8a-5372-9b90-0c9aee199e5d"),
+					resource.TestCheckOutput("builtin", "true"),
+				),
+			},
+			{
+				Config: testAccUuidV5FunctionConfig("6ba7b810-9dad-11d1-80b4-00c04fd430c8", "python.org"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "886313e1-3b8a-5372-9b90-0c9aee199e5d"),
+				),
+			},
+			{
+				Config:      testAccUuidV5FunctionConfig("not-a-namespace", "python.org"),
+				ExpectError: regexp.MustCompile("invalid namespace"),
+			},
+		},
+	})
+}
+
+func testAccUuidV5FunctionConfig(namespace, name string) string {
+	return fmt.Sprintf(`
+output "test" {
+  value = provider::modtm::uuidv5("%[1]s", "%[2]s")
+}
+
+output "builtin" {
+  value = provider::modtm::uuidv5("%[1]s", "%[2]s") == uuidv5("%[1]s", "%[2]s")
+}
+`, namespace, name)
+}
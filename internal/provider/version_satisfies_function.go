@@ -0,0 +1,65 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &VersionSatisfiesFunction{}
+
+func NewVersionSatisfiesFunction(modulesJson *modulesJsonCache) function.Function {
+	return &VersionSatisfiesFunction{modulesJson: modulesJson}
+}
+
+type VersionSatisfiesFunction struct {
+	modulesJson *modulesJsonCache
+}
+
+func (m *VersionSatisfiesFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "version_satisfies"
+}
+
+func (m *VersionSatisfiesFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "`version_satisfies` function",
+		MarkdownDescription: "This function takes in `${path.module}` and a version constraint, resolves the installed module version from `modules.json` file in the current root module's `.terraform/module` folder and returns whether the version satisfies the constraint. " +
+			"Returns `false` when the module cannot be found in `modules.json` or has no version, e.g. modules that are not installed from a registry.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "module_path",
+				MarkdownDescription: "`${path.module}`",
+			},
+			function.StringParameter{
+				Name:                "constraint",
+				MarkdownDescription: "Version constraint in Terraform's syntax, e.g. `>= 0.6.0, < 1.0.0` or `~> 0.6`",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (m *VersionSatisfiesFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var modulePath, constraint string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &modulePath, &constraint))
+	if resp.Error != nil {
+		return
+	}
+	constraints, err := version.NewConstraint(constraint)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("invalid version constraint %q: %s", constraint, err.Error()))
+		return
+	}
+	satisfied := false
+	if module, err := m.modulesJson.parseModulesJson("", modulePath); err == nil && module.Version != "" {
+		if v, err := version.NewVersion(module.Version); err == nil {
+			satisfied = constraints.Check(v)
+		}
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, satisfied))
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccVersionSatisfiesFunction(t *testing.T) {
+	require.NoError(t, createModulesJson())
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVersionSatisfiesFunctionConfig(".terraform/modules/kv", ">= 0.6.0, < 1.0.0"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "true"),
+				),
+			},
+			{
+				Config: testAccVersionSatisfiesFunctionConfig(".terraform/modules/kv", "~> 0.7"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "false"),
+				),
+			},
+			{
+				Config: testAccVersionSatisfiesFunctionConfig(".terraform/modules/kv/modules/key", ">= 0.0.0"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckOutput("test", "false"),
+				),
+			},
+			{
+				Config:      testAccVersionSatisfiesFunctionConfig(".terraform/modules/kv", "not a constraint"),
+				ExpectError: regexp.MustCompile("invalid version constraint"),
+			},
+		},
+	})
+}
+
+func testAccVersionSatisfiesFunctionConfig(modulePath, constraint string) string {
+	return fmt.Sprintf(`
+output "test" {
+  value = provider::modtm::version_satisfies("%s", "%s")
+}
+`, modulePath, constraint)
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"flag"
 	"log"
+	"time"
 
 	"github.com/Azure/terraform-provider-modtm/internal/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
@@ -44,6 +45,11 @@ func main() {
 
 	err := providerserver.Serve(context.Background(), provider.New(version), opts)
 
+	// Terraform kills the provider shortly after the server has stopped, so flush what's left quickly.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	provider.Shutdown(ctx)
+	cancel()
+
 	if err != nil {
 		log.Fatal(err.Error())
 	}